@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+	"git.pepabo.com/yukyan/gh-pric/github/output"
+)
+
+// validateActionsSummary checks that --gha-summary runs inside a GitHub Actions job
+func validateActionsSummary() error {
+	if os.Getenv("GITHUB_STEP_SUMMARY") == "" {
+		return fmt.Errorf("--gha-summary can only be used inside GitHub Actions (GITHUB_STEP_SUMMARY is not set)")
+	}
+	return nil
+}
+
+// writeActionsSummary appends the Markdown report to the job summary and sets the counts as step outputs
+// The summary is always Markdown, whatever --output-format the file was written in
+func writeActionsSummary(reports []model.Report, team bool, outputFile string, opts output.Options) error {
+	summary, err := os.OpenFile(os.Getenv("GITHUB_STEP_SUMMARY"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if team {
+		err = output.WriteTeamReport(summary, model.TeamReport{DateRange: reports[0].DateRange, Members: reports}, "md", opts)
+	} else {
+		err = output.WriteReport(summary, reports[0], "md", opts)
+	}
+	if closeErr := summary.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write the job summary: %w", err)
+	}
+
+	// Step outputs are only available when the step has an id, but GITHUB_OUTPUT is always set
+	outputPath := os.Getenv("GITHUB_OUTPUT")
+	if outputPath == "" {
+		return nil
+	}
+	file, err := os.OpenFile(outputPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	counts := countActivity(reports)
+	fmt.Fprintf(file, "report=%s\n", outputFile)
+	fmt.Fprintf(file, "items=%d\n", counts.items)
+	fmt.Fprintf(file, "prs=%d\n", counts.prs)
+	fmt.Fprintf(file, "issues=%d\n", counts.issues)
+	fmt.Fprintf(file, "merged_prs=%d\n", counts.mergedPRs)
+	fmt.Fprintf(file, "reviewed_prs=%d\n", counts.reviewedPRs)
+	fmt.Fprintf(file, "commits=%d\n", counts.commits)
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write the step outputs: %w", err)
+	}
+	return nil
+}
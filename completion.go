@@ -0,0 +1,249 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"git.pepabo.com/yukyan/gh-pric/github/cache"
+	"git.pepabo.com/yukyan/gh-pric/github/output"
+	"git.pepabo.com/yukyan/gh-pric/github/state"
+)
+
+// Fixed values offered when completing a flag value
+var completionChoices = map[string][]string{
+	"output-format":   output.Formats(),
+	"group-by":        {"involvement", "day", "repo"},
+	"sort":            {"created", "updated", "repo", "number"},
+	"order":           {"asc", "desc"},
+	"mermaid":         {"gantt", "timeline"},
+	"visibility":      {"public", "private", "all"},
+	"progress-format": {"text", "json"},
+	"publish":         {"notion", "gist", "esa", "kibela", "confluence", "gdocs"},
+	"post":            {"webhook", "discord", "teams"},
+}
+
+// Values looked up when completing, by "gh pric __complete <kind>"
+var completionDynamic = map[string]string{
+	"profile":      "profiles",
+	"repo":         "repos",
+	"exclude-repo": "repos",
+	"org":          "orgs",
+	"theme":        "themes",
+}
+
+// Flags whose value is a file or directory name
+var completionFiles = map[string]bool{
+	"output":    true,
+	"o":         true,
+	"input":     true,
+	"config":    true,
+	"cache-dir": true,
+	"theme-dir": true,
+}
+
+// runCompletion prints the completion script for a shell (gh pric completion bash|zsh|fish)
+func runCompletion(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: gh pric completion bash|zsh|fish\n")
+		return 1
+	}
+	switch args[0] {
+	case "bash":
+		writeBashCompletion()
+	case "zsh":
+		writeZshCompletion()
+	case "fish":
+		writeFishCompletion()
+	default:
+		fmt.Fprintf(os.Stderr, "Unsupported shell: %s (please specify bash, zsh or fish)\n", args[0])
+		return 1
+	}
+	return 0
+}
+
+// runCompleteValues prints the candidates of a dynamic completion, one per line
+// Errors are ignored since the output is consumed by shell completion
+func runCompleteValues(args []string) int {
+	if len(args) != 1 {
+		return 1
+	}
+	cfg, _ := loadConfig(defaultConfigPath())
+
+	var values []string
+	switch args[0] {
+	case "profiles":
+		for name := range cfg.Profiles {
+			values = append(values, name)
+		}
+	case "repos", "orgs":
+		// Repositories from the --since-last-run datasets and from the profiles
+		repos, _ := state.Repositories(filepath.Join(cache.DefaultDir(), "state"))
+		for _, profile := range cfg.Profiles {
+			for _, key := range []string{"repo", "exclude-repo"} {
+				if list, ok := profile[key].([]interface{}); ok {
+					for _, v := range list {
+						repos = append(repos, fmt.Sprint(v))
+					}
+				} else if v, ok := profile[key]; ok {
+					repos = append(repos, fmt.Sprint(v))
+				}
+			}
+		}
+		for _, repo := range repos {
+			if args[0] == "orgs" {
+				repo, _, _ = strings.Cut(repo, "/")
+			}
+			values = append(values, repo)
+		}
+	case "themes":
+		values = output.BuiltinThemes()
+		paths, _ := filepath.Glob(filepath.Join(defaultThemeDir(), "*.tmpl"))
+		for _, path := range paths {
+			values = append(values, strings.TrimSuffix(filepath.Base(path), ".tmpl"))
+		}
+	default:
+		return 1
+	}
+
+	sort.Strings(values)
+	for i, value := range values {
+		if i == 0 || value != values[i-1] {
+			fmt.Println(value)
+		}
+	}
+	return 0
+}
+
+// Flag with what its value completes to
+type completionFlag struct {
+	name    string
+	usage   string
+	isBool  bool
+	choices []string // Fixed values
+	dynamic string   // Kind for "gh pric __complete" (empty = none)
+	file    bool     // File or directory name
+}
+
+// completionFlags lists every defined flag, sorted by name
+func completionFlags() []completionFlag {
+	var flags []completionFlag
+	flag.VisitAll(func(f *flag.Flag) {
+		boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completionFlag{
+			name:    f.Name,
+			usage:   f.Usage,
+			isBool:  ok && boolFlag.IsBoolFlag(),
+			choices: completionChoices[f.Name],
+			dynamic: completionDynamic[f.Name],
+			file:    completionFiles[f.Name],
+		})
+	})
+	return flags
+}
+
+// bash: completes "gh pric ..." and hands everything else to the completion of gh itself
+func writeBashCompletion() {
+	var names []string
+	fmt.Println("# bash completion for gh pric (load it after the completion of gh)")
+	fmt.Println("_gh_pric() {")
+	fmt.Println(`	local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Println(`	if [[ $COMP_CWORD -eq 2 && $cur != -* ]]; then`)
+	fmt.Println(`		COMPREPLY=($(compgen -W "completion serve version" -- "$cur"))`)
+	fmt.Println(`		return`)
+	fmt.Println(`	fi`)
+	fmt.Println(`	case "$prev" in`)
+	for _, f := range completionFlags() {
+		names = append(names, "--"+f.name)
+		switch {
+		case f.isBool:
+		case len(f.choices) > 0:
+			fmt.Printf("\t--%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", f.name, strings.Join(f.choices, " "))
+		case f.dynamic != "":
+			fmt.Printf("\t--%s) COMPREPLY=($(compgen -W \"$(gh pric __complete %s 2>/dev/null)\" -- \"$cur\")); return ;;\n", f.name, f.dynamic)
+		case f.file:
+			fmt.Printf("\t--%s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;\n", f.name)
+		default:
+			fmt.Printf("\t--%s) return ;;\n", f.name)
+		}
+	}
+	fmt.Println(`	esac`)
+	fmt.Printf("\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	fmt.Println("}")
+	fmt.Println()
+	fmt.Println("_gh_with_pric() {")
+	fmt.Println(`	if [[ ${COMP_WORDS[1]} == pric ]]; then`)
+	fmt.Println(`		_gh_pric`)
+	fmt.Println(`	elif declare -F __start_gh >/dev/null; then`)
+	fmt.Println(`		__start_gh "$@"`)
+	fmt.Println(`	fi`)
+	fmt.Println("}")
+	fmt.Println("complete -o default -F _gh_with_pric gh")
+}
+
+// zsh: completes "gh pric ..." and hands everything else to the completion of gh itself
+func writeZshCompletion() {
+	escape := strings.NewReplacer(`'`, `'\''`, "[", `\[`, "]", `\]`, ":", `\:`)
+	fmt.Println("#compdef gh")
+	fmt.Println("# zsh completion for gh pric (load it after the completion of gh)")
+	fmt.Println("_gh_pric() {")
+	fmt.Println("\t_arguments -s \\")
+	fmt.Println("\t\t'1:command:(completion serve version)' \\")
+	for _, f := range completionFlags() {
+		spec := fmt.Sprintf("--%s[%s]", f.name, escape.Replace(f.usage))
+		switch {
+		case f.isBool:
+		case len(f.choices) > 0:
+			spec += fmt.Sprintf(":%s:(%s)", f.name, strings.Join(f.choices, " "))
+		case f.dynamic != "":
+			spec += fmt.Sprintf(`:%s:{compadd -- ${(f)"$(gh pric __complete %s 2>/dev/null)"}}`, f.name, f.dynamic)
+		case f.file:
+			spec += fmt.Sprintf(":%s:_files", f.name)
+		default:
+			spec += fmt.Sprintf(":%s: ", f.name)
+		}
+		fmt.Printf("\t\t'%s' \\\n", spec)
+	}
+	fmt.Println("\t\t&& return 0")
+	fmt.Println("}")
+	fmt.Println()
+	fmt.Println("_gh_with_pric() {")
+	fmt.Println(`	if [[ ${words[2]} == pric ]]; then`)
+	fmt.Println(`		shift words`)
+	fmt.Println(`		(( CURRENT-- ))`)
+	fmt.Println(`		_gh_pric`)
+	fmt.Println(`	elif (( $+functions[_gh] )); then`)
+	fmt.Println(`		_gh "$@"`)
+	fmt.Println(`	fi`)
+	fmt.Println("}")
+	fmt.Println("compdef _gh_with_pric gh")
+}
+
+// fish: completions for the pric subcommand of gh, next to those of gh itself
+func writeFishCompletion() {
+	escape := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	condition := "__fish_seen_subcommand_from pric"
+	fmt.Println("# fish completion for gh pric")
+	fmt.Printf("complete -c gh -n '%s' -f -a 'completion serve version'\n", condition)
+	for _, f := range completionFlags() {
+		line := fmt.Sprintf("complete -c gh -n '%s' -l %s -d '%s'", condition, f.name, escape.Replace(f.usage))
+		if len(f.name) == 1 {
+			line = fmt.Sprintf("complete -c gh -n '%s' -o %s -d '%s'", condition, f.name, escape.Replace(f.usage))
+		}
+		switch {
+		case f.isBool:
+		case len(f.choices) > 0:
+			line += fmt.Sprintf(" -x -a '%s'", strings.Join(f.choices, " "))
+		case f.dynamic != "":
+			line += fmt.Sprintf(" -x -a '(gh pric __complete %s 2>/dev/null)'", f.dynamic)
+		case f.file:
+			line += " -r -F"
+		default:
+			line += " -x"
+		}
+		fmt.Println(line)
+	}
+}
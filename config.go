@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/util"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Settings read from the config file
+type config struct {
+	LLM      llmConfig                         `yaml:"llm"`      // OpenAI-compatible API used by --summarize
+	Sprint   sprintConfig                      `yaml:"sprint"`   // Iterations used by --sprint
+	Profiles map[string]map[string]interface{} `yaml:"profiles"` // Flag values by profile name, keyed by flag name (--profile)
+
+	UpdateCheck *bool `yaml:"update_check"` // Check for a newer release once per day (default true)
+}
+
+// Settings for the LLM used by --summarize
+type llmConfig struct {
+	Endpoint string `yaml:"endpoint"` // Base URL of the API
+	APIKey   string `yaml:"api_key"`  // API key
+	Model    string `yaml:"model"`    // Model name
+}
+
+// Settings for the iterations used by --sprint
+type sprintConfig struct {
+	Length int    `yaml:"length"` // Length in days (default 14)
+	Anchor string `yaml:"anchor"` // First day of any sprint (YYYY-MM-DD)
+}
+
+// sprint returns the configured iterations (a zero anchor when none is configured)
+func (c sprintConfig) sprint() (util.Sprint, error) {
+	sprint := util.Sprint{Length: c.Length}
+	if sprint.Length == 0 {
+		sprint.Length = 14
+	}
+	if c.Anchor == "" {
+		return sprint, nil
+	}
+	anchor, err := time.Parse("2006-01-02", c.Anchor)
+	if err != nil {
+		return sprint, fmt.Errorf("invalid sprint anchor %q (YYYY-MM-DD format)", c.Anchor)
+	}
+	sprint.Anchor = anchor
+	return sprint, nil
+}
+
+// defaultConfigPath returns the path of the config file in the user config directory
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gh-pric", "config.yml")
+}
+
+// defaultThemeDir returns the templates directory next to the config file
+func defaultThemeDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gh-pric", "templates")
+}
+
+// loadConfig reads the config file (a missing file is the same as an empty one)
+func loadConfig(path string) (config, error) {
+	var c config
+	if path == "" {
+		return c, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// applyProfile sets the flags of the named profile that were not given on the command line
+// Lists set repeatable flags (like repo) once per value and are joined with commas otherwise
+func applyProfile(c config, name string) error {
+	values, ok := c.Profiles[name]
+	if !ok {
+		var names []string
+		for n := range c.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("no profile named %q in the config file (profiles: %s)", name, strings.Join(names, ", "))
+	}
+
+	// Aliases (like -o for --output) share their value, so given values are tracked instead of names
+	explicit := make(map[flag.Value]bool)
+	flag.Visit(func(f *flag.Flag) { explicit[f.Value] = true })
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		f := flag.Lookup(key)
+		if f == nil || key == "profile" || key == "config" {
+			return fmt.Errorf("profile %q: unknown flag %q", name, key)
+		}
+		if explicit[f.Value] {
+			continue
+		}
+
+		var settings []string
+		if list, ok := values[key].([]interface{}); ok {
+			for _, v := range list {
+				settings = append(settings, fmt.Sprint(v))
+			}
+			if _, repeatable := f.Value.(*stringList); !repeatable {
+				settings = []string{strings.Join(settings, ",")}
+			}
+		} else {
+			settings = []string{fmt.Sprint(values[key])}
+		}
+		for _, setting := range settings {
+			if err := flag.Set(key, setting); err != nil {
+				return fmt.Errorf("profile %q: %s: %w", name, key, err)
+			}
+		}
+	}
+	return nil
+}
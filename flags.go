@@ -0,0 +1,15 @@
+package main
+
+import "strings"
+
+// stringList is a flag.Value that collects repeated flag values
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
@@ -0,0 +1,50 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// FetchWorkflowRuns は期間内にユーザーがリポジトリで実行した GitHub Actions のワークフローを取得します
+func (c *Client) FetchWorkflowRuns(ctx context.Context, repo string, opts FetchOptions) ([]model.WorkflowRun, error) {
+	var response struct {
+		WorkflowRuns []struct {
+			Name       string    `json:"name"`
+			RunNumber  int       `json:"run_number"`
+			Event      string    `json:"event"`
+			Status     string    `json:"status"`
+			Conclusion string    `json:"conclusion"`
+			Branch     string    `json:"head_branch"`
+			URL        string    `json:"html_url"`
+			CreatedAt  time.Time `json:"created_at"`
+		} `json:"workflow_runs"`
+	}
+
+	var runs []model.WorkflowRun
+	runsURL := fmt.Sprintf("repos/%s/actions/runs?actor=%s&created=%s..%s&per_page=100",
+		repo, opts.Username, formatSearchTime(opts.DateRange.StartDate), formatSearchTime(opts.DateRange.EndDate))
+	err := c.getPages(ctx, runsURL, &response, func() bool {
+		for _, run := range response.WorkflowRuns {
+			runs = append(runs, model.WorkflowRun{
+				Name:       run.Name,
+				Number:     run.RunNumber,
+				Repository: repo,
+				Event:      run.Event,
+				Status:     run.Status,
+				Conclusion: run.Conclusion,
+				Branch:     run.Branch,
+				URL:        run.URL,
+				CreatedAt:  run.CreatedAt,
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to retrieve workflow runs for %s: %w", repo, err)
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,287 @@
+package github
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// @-mentions of users in bodies and comments (the preceding character rules out e-mail addresses)
+var mentionPattern = regexp.MustCompile(`(^|[^A-Za-z0-9_.])@([A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?)`)
+
+// Anonymizer はレポートを共有できるように、他のユーザーのログインを仮名に、非公開リポジトリ名を伏せ字に置き換えます
+// 同じログインやリポジトリには実行中ずっと同じ仮名を割り当てます
+type Anonymizer struct {
+	mu    sync.Mutex
+	keep  map[string]bool   // Logins left as they are (lower-case)
+	users map[string]string // Login (lower-case) -> pseudonym
+	repos map[string]string // Private repository (lower-case) -> pseudonym
+
+	repoPattern *regexp.Regexp // Matches any private repository name, rebuilt when one is added
+}
+
+// NewAnonymizer は新しい Anonymizer を作成します
+func NewAnonymizer() *Anonymizer {
+	return &Anonymizer{
+		keep:  make(map[string]bool),
+		users: make(map[string]string),
+		repos: make(map[string]string),
+	}
+}
+
+// Keep は指定したユーザー（レポートの対象者）のログインを置き換えないようにします
+func (a *Anonymizer) Keep(logins ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, login := range logins {
+		a.keep[strings.ToLower(login)] = true
+	}
+}
+
+// Report はレポートを匿名化したコピーを返します
+func (a *Anonymizer) Report(report model.Report) model.Report {
+	// Private repositories are registered first so references to them are hidden as well
+	for _, item := range report.Items {
+		if item.Private {
+			a.repo(item.Repository)
+		}
+	}
+	for _, commit := range report.Commits {
+		if commit.Private {
+			a.repo(commit.Repository)
+		}
+	}
+	for _, repo := range report.Repositories {
+		if repo.Private {
+			a.repo(repo.Name)
+		}
+	}
+
+	anonymized := report
+	anonymized.Items = make([]model.Item, len(report.Items))
+	for i, item := range report.Items {
+		anonymized.Items[i] = a.Item(item)
+	}
+
+	anonymized.Commits = make([]model.Commit, len(report.Commits))
+	for i, commit := range report.Commits {
+		if commit.Private {
+			commit.Repository = a.repo(commit.Repository)
+			commit.URL = ""
+			commit.Message = a.text(commit.Message)
+		}
+		anonymized.Commits[i] = commit
+	}
+
+	anonymized.Repositories = make([]model.Repository, len(report.Repositories))
+	for i, repo := range report.Repositories {
+		if repo.Private {
+			repo.Name = a.repo(repo.Name)
+			repo.URL = ""
+			repo.Description = ""
+		}
+		anonymized.Repositories[i] = repo
+	}
+
+	anonymized.WorkflowRuns = make([]model.WorkflowRun, len(report.WorkflowRuns))
+	for i, run := range report.WorkflowRuns {
+		if pseudonym, ok := a.privateRepo(run.Repository); ok {
+			run.Repository = pseudonym
+			run.URL = ""
+			run.Branch = ""
+		}
+		anonymized.WorkflowRuns[i] = run
+	}
+
+	// Meeting titles often name people and projects
+	if report.Meetings != nil {
+		anonymized.Meetings = make([]model.Meeting, len(report.Meetings))
+		for i, meeting := range report.Meetings {
+			meeting.Summary = "(meeting)"
+			anonymized.Meetings[i] = meeting
+		}
+	}
+
+	if report.Previous != nil {
+		previous := a.Report(*report.Previous)
+		anonymized.Previous = &previous
+	}
+	return anonymized
+}
+
+// Item はアイテムを匿名化したコピーを返します（元のアイテムのスライスは変更しません）
+func (a *Anonymizer) Item(item model.Item) model.Item {
+	// Labels, projects, branch names and the code under review comments describe the private repository
+	if item.Private {
+		item.Repository = a.repo(item.Repository)
+		item.URL = ""
+		item.HeadRef = ""
+		item.Labels = nil
+		item.LabelColors = nil
+		item.Projects = nil
+	}
+	item.Author = a.user(item.Author)
+	item.Title = a.text(item.Title)
+	item.Body = a.text(item.Body)
+
+	assignees := make([]string, len(item.Assignees))
+	for i, assignee := range item.Assignees {
+		assignees[i] = a.user(assignee)
+	}
+	item.Assignees = assignees
+
+	comments := make([]model.Comment, len(item.Comments))
+	for i, comment := range item.Comments {
+		comment.Author = a.user(comment.Author)
+		comment.Body = a.text(comment.Body)
+		if item.Private {
+			comment.Path = ""
+			comment.Line = 0
+			comment.DiffHunk = ""
+		}
+		comments[i] = comment
+	}
+	item.Comments = comments
+
+	reviews := make([]model.Review, len(item.Reviews))
+	for i, review := range item.Reviews {
+		review.Author = a.user(review.Author)
+		review.Body = a.text(review.Body)
+		reviews[i] = review
+	}
+	item.Reviews = reviews
+
+	requests := make([]model.ReviewRequest, len(item.ReviewRequests))
+	for i, request := range item.ReviewRequests {
+		request.Reviewer = a.user(request.Reviewer)
+		requests[i] = request
+	}
+	item.ReviewRequests = requests
+
+	events := make([]model.Event, len(item.Events))
+	for i, event := range item.Events {
+		event.Actor = a.user(event.Actor)
+		switch {
+		case event.Type == "assigned" || event.Type == "unassigned":
+			event.Detail = a.user(event.Detail)
+		case item.Private && (event.Type == "labeled" || event.Type == "unlabeled"):
+			event.Detail = ""
+		default:
+			event.Detail = a.text(event.Detail)
+		}
+		events[i] = event
+	}
+	item.Events = events
+
+	references := make([]model.Reference, len(item.ReferencedBy))
+	for i, ref := range item.ReferencedBy {
+		ref.Actor = a.user(ref.Actor)
+		ref.Title = a.text(ref.Title)
+		if pseudonym, ok := a.privateRepo(ref.Repository); ok {
+			ref.Repository = pseudonym
+			ref.URL = ""
+		}
+		references[i] = ref
+	}
+	item.ReferencedBy = references
+
+	commits := make([]model.Commit, len(item.Commits))
+	for i, commit := range item.Commits {
+		if item.Private {
+			commit.Repository = item.Repository
+			commit.URL = ""
+		}
+		commit.Message = a.text(commit.Message)
+		commits[i] = commit
+	}
+	item.Commits = commits
+	return item
+}
+
+// user はログインに対応する仮名を返します（残すユーザー、ボット、空文字列はそのまま）
+func (a *Anonymizer) user(login string) string {
+	// Bots are no people to protect, and dependency updates are recognized by their author
+	if login == "" || strings.HasSuffix(login, "[bot]") {
+		return login
+	}
+
+	key := strings.ToLower(login)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.keep[key] {
+		return login
+	}
+	if pseudonym, ok := a.users[key]; ok {
+		return pseudonym
+	}
+	pseudonym := fmt.Sprintf("user-%d", len(a.users)+1)
+	a.users[key] = pseudonym
+	return pseudonym
+}
+
+// repo は非公開リポジトリに対応する仮名を返します
+func (a *Anonymizer) repo(name string) string {
+	key := strings.ToLower(name)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if pseudonym, ok := a.repos[key]; ok {
+		return pseudonym
+	}
+	pseudonym := fmt.Sprintf("private-repo-%d", len(a.repos)+1)
+	a.repos[key] = pseudonym
+	a.repoPattern = repoPattern(a.repos)
+	return pseudonym
+}
+
+// repoPattern は非公開リポジトリ名のいずれかに大文字小文字を区別せずに一致する正規表現を返します
+// 長い名前から順に並べるので、acme/api と acme/api-gateway の両方があっても長い方が優先されます
+func repoPattern(repos map[string]string) *regexp.Regexp {
+	names := make([]string, 0, len(repos))
+	for name := range repos {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+	for i, name := range names {
+		names[i] = regexp.QuoteMeta(name)
+	}
+	return regexp.MustCompile("(?i)" + strings.Join(names, "|"))
+}
+
+// privateRepo は既知の非公開リポジトリであればその仮名を返します
+func (a *Anonymizer) privateRepo(name string) (string, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	pseudonym, ok := a.repos[strings.ToLower(name)]
+	return pseudonym, ok
+}
+
+// text は本文中の @メンションと非公開リポジトリ名を置き換えます
+func (a *Anonymizer) text(s string) string {
+	s = mentionPattern.ReplaceAllStringFunc(s, func(match string) string {
+		groups := mentionPattern.FindStringSubmatch(match)
+		return groups[1] + "@" + a.user(groups[2])
+	})
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.repoPattern == nil {
+		return s
+	}
+	return a.repoPattern.ReplaceAllStringFunc(s, func(name string) string {
+		return a.repos[strings.ToLower(name)]
+	})
+}
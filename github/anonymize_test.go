@@ -0,0 +1,53 @@
+package github
+
+import (
+	"strings"
+	"testing"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+func TestAnonymizerHidesPrivateRepoNames(t *testing.T) {
+	report := model.Report{Items: []model.Item{
+		{Repository: "acme/api", Private: true, Body: "Moved from Acme/API-Gateway to acme/api."},
+		{Repository: "acme/api-gateway", Private: true},
+	}}
+
+	// The result must not depend on the order the names are tried in
+	for i := 0; i < 20; i++ {
+		anonymized := NewAnonymizer().Report(report)
+		if got, want := anonymized.Items[0].Body, "Moved from private-repo-2 to private-repo-1."; got != want {
+			t.Fatalf("Body = %q, want %q", got, want)
+		}
+		if strings.Contains(anonymized.Items[0].Body, "gateway") {
+			t.Fatalf("Body leaks part of a private repository name: %q", anonymized.Items[0].Body)
+		}
+	}
+}
+
+func TestAnonymizerClearsPrivateItemDetails(t *testing.T) {
+	item := model.Item{
+		Repository:  "acme/secret",
+		Private:     true,
+		HeadRef:     "feature/launch-plan",
+		Labels:      []string{"customer:bigco"},
+		LabelColors: map[string]string{"customer:bigco": "ff0000"},
+		Projects:    []model.ProjectItem{{Project: "Launch"}},
+		Comments:    []model.Comment{{Body: "nit", Path: "internal/billing.go", Line: 12, DiffHunk: "@@ -1 +1 @@\n+const key = 1"}},
+		Events:      []model.Event{{Type: "labeled", Detail: "customer:bigco"}},
+	}
+
+	got := NewAnonymizer().Item(item)
+	if got.HeadRef != "" || got.Labels != nil || got.LabelColors != nil || got.Projects != nil {
+		t.Errorf("private item keeps HeadRef %q, Labels %v, LabelColors %v or Projects %v", got.HeadRef, got.Labels, got.LabelColors, got.Projects)
+	}
+	if comment := got.Comments[0]; comment.Path != "" || comment.Line != 0 || comment.DiffHunk != "" {
+		t.Errorf("review comment keeps its code: %+v", comment)
+	}
+	if got.Events[0].Detail != "" {
+		t.Errorf("labeled event keeps the label %q", got.Events[0].Detail)
+	}
+	if item.Labels[0] != "customer:bigco" || item.Comments[0].Path == "" {
+		t.Error("the original item was changed")
+	}
+}
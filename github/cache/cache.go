@@ -0,0 +1,176 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Transport は ETag を使った条件付きリクエストで GET レスポンスをディスクにキャッシュします
+type Transport struct {
+	Dir     string            // Directory where responses are stored
+	Base    http.RoundTripper // Underlying transport (http.DefaultTransport when nil)
+	Offline bool              // Serve only cached responses and never touch the network
+}
+
+// ErrNotCached is returned in offline mode for requests without a cached response
+var ErrNotCached = errors.New("response is not available in the offline cache")
+
+// Struct to hold a cached response
+type entry struct {
+	URL    string      `json:"url"`
+	ETag   string      `json:"etag"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// DefaultDir はキャッシュディレクトリの既定値を返します
+func DefaultDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gh-pric")
+}
+
+// RoundTrip はキャッシュ済みの ETag を付けてリクエストを送信し、304 の場合はキャッシュを返します
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		if t.Offline {
+			return nil, fmt.Errorf("%w: %s %s", ErrNotCached, req.Method, req.URL)
+		}
+		return t.base().RoundTrip(req)
+	}
+
+	path := t.entryPath(req)
+	cached := load(path)
+
+	if t.Offline {
+		if cached == nil {
+			return nil, fmt.Errorf("%w: %s", ErrNotCached, req.URL)
+		}
+		return cached.response(req, cached.Header.Clone()), nil
+	}
+
+	if cached != nil && cached.ETag != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := t.base().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unchanged: serve the stored body, keeping the fresh rate limit headers
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		header := cached.Header.Clone()
+		for key, values := range resp.Header {
+			header[key] = values
+		}
+		return cached.response(req, header), nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	// Failing to write the cache must not fail the request
+	_ = save(path, &entry{
+		URL:    req.URL.String(),
+		ETag:   etag,
+		Header: resp.Header,
+		Body:   body,
+	})
+
+	return resp, nil
+}
+
+// 下位のトランスポートを返します
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// リクエストに対応するキャッシュファイルのパスを返します
+func (t *Transport) entryPath(req *http.Request) string {
+	// Include the credentials so different accounts never share responses
+	h := sha256.New()
+	io.WriteString(h, req.URL.String())
+	io.WriteString(h, "\n"+req.Header.Get("Accept"))
+	io.WriteString(h, "\n"+req.Header.Get("Authorization"))
+	return filepath.Join(t.Dir, hex.EncodeToString(h.Sum(nil))+".json")
+}
+
+// キャッシュファイルを読み込みます（存在しない場合は nil）
+func load(path string) *entry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil
+	}
+	return &e
+}
+
+// キャッシュファイルを書き込みます
+func save(path string, e *entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	// Write a temporary file and rename it, so concurrent requests for the same URL never see half an entry
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".entry-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// キャッシュからレスポンスを組み立てます
+func (e *entry) response(req *http.Request, header http.Header) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
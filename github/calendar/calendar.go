@@ -0,0 +1,420 @@
+package calendar
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// Upper bound of the repetitions walked per recurring event, against endless rules
+const maxSteps = 100000
+
+// Load は iCalendar（.ics）ファイルまたは URL（https:// や webcal://）を読み込み、
+// 期間に重なる予定を開始時刻の順に返します
+// 終日の予定、キャンセルされた予定、「空き時間」として登録された予定は含みません
+// 繰り返しの予定は DAILY、WEEKLY、MONTHLY、YEARLY の RRULE（INTERVAL、COUNT、UNTIL、BYDAY）と EXDATE に従って展開します
+// タイムゾーンのない日時と解釈できない TZID の日時は loc の時刻として扱います
+func Load(ctx context.Context, source string, dateRange model.DateRange, loc *time.Location) ([]model.Meeting, error) {
+	var r io.Reader
+	if strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "webcal://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.Replace(source, "webcal://", "https://", 1), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		r = resp.Body
+	} else {
+		file, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	events, err := parse(r, loc)
+	if err != nil {
+		return nil, err
+	}
+	return meetings(events, dateRange), nil
+}
+
+// A property line: NAME;PARAM=VALUE:value
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// A VEVENT with the properties needed to place it in time
+type event struct {
+	uid          string
+	summary      string
+	start        time.Time
+	duration     time.Duration
+	allDay       bool
+	skip         bool // Cancelled, or marked as free time
+	rrule        map[string]string
+	exdates      map[int64]bool
+	recurrenceID time.Time // Start of the occurrence this event replaces (zero = none)
+}
+
+// parse reads the VEVENTs of the calendar
+func parse(r io.Reader, loc *time.Location) ([]event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []event
+	var current *event
+	var end time.Time
+	var hasDuration bool
+	for _, line := range lines {
+		prop, ok := parseProperty(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case prop.name == "BEGIN" && prop.value == "VEVENT":
+			current = &event{exdates: make(map[int64]bool)}
+			end, hasDuration = time.Time{}, false
+		case prop.name == "END" && prop.value == "VEVENT" && current != nil:
+			if !hasDuration && !end.IsZero() {
+				current.duration = end.Sub(current.start)
+			}
+			if current.allDay && current.duration == 0 {
+				current.duration = 24 * time.Hour
+			}
+			if !current.start.IsZero() {
+				events = append(events, *current)
+			}
+			current = nil
+		case current == nil:
+			// Properties of the calendar, time zones and alarms
+		case prop.name == "UID":
+			current.uid = prop.value
+		case prop.name == "SUMMARY":
+			current.summary = unescapeText(prop.value)
+		case prop.name == "DTSTART":
+			current.start, current.allDay = parseDateTime(prop, loc)
+		case prop.name == "DTEND":
+			end, _ = parseDateTime(prop, loc)
+		case prop.name == "DURATION":
+			if d, ok := parseDuration(prop.value); ok {
+				current.duration = d
+				hasDuration = true
+			}
+		case prop.name == "STATUS" && prop.value == "CANCELLED",
+			prop.name == "TRANSP" && prop.value == "TRANSPARENT":
+			current.skip = true
+		case prop.name == "RRULE":
+			current.rrule = make(map[string]string)
+			for _, part := range strings.Split(prop.value, ";") {
+				if key, value, ok := strings.Cut(part, "="); ok {
+					current.rrule[strings.ToUpper(key)] = value
+				}
+			}
+		case prop.name == "EXDATE":
+			for _, value := range strings.Split(prop.value, ",") {
+				t, _ := parseDateTime(property{params: prop.params, value: value}, loc)
+				current.exdates[t.Unix()] = true
+			}
+		case prop.name == "RECURRENCE-ID":
+			current.recurrenceID, _ = parseDateTime(prop, loc)
+		}
+	}
+	return events, nil
+}
+
+// unfold joins the continuation lines (starting with a space or a tab) to the line before
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseProperty splits a content line into its name, parameters and value
+func parseProperty(line string) (property, bool) {
+	// The value starts at the first colon outside a quoted parameter value
+	quoted := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return property{}, false
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	prop := property{name: strings.ToUpper(parts[0]), params: make(map[string]string), value: line[colon+1:]}
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return prop, true
+}
+
+// parseDateTime reads a DATE or DATE-TIME value; the second result reports a DATE (all-day) value
+func parseDateTime(prop property, loc *time.Location) (time.Time, bool) {
+	value := strings.TrimSpace(prop.value)
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			loc = tz
+		}
+	}
+	if prop.params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return t, true
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return t.In(loc), false
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, false
+}
+
+// Duration values such as PT1H30M or P1D
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseDuration reads a DURATION value
+func parseDuration(value string) (time.Duration, bool) {
+	m := durationPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return 0, false
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+2] != "" {
+			n, _ := strconv.Atoi(m[i+2])
+			d += time.Duration(n) * unit
+		}
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, true
+}
+
+// Escaped characters of TEXT values
+var textUnescaper = strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+// unescapeText decodes a TEXT value
+func unescapeText(value string) string {
+	return textUnescaper.Replace(value)
+}
+
+// meetings expands the events into the occurrences that overlap the period
+func meetings(events []event, dateRange model.DateRange) []model.Meeting {
+	// Occurrences moved or changed individually are replaced by their own VEVENT
+	overridden := make(map[string]bool)
+	for _, e := range events {
+		if !e.recurrenceID.IsZero() {
+			overridden[fmt.Sprintf("%s/%d", e.uid, e.recurrenceID.Unix())] = true
+		}
+	}
+
+	var result []model.Meeting
+	for _, e := range events {
+		if e.allDay || e.skip || e.duration <= 0 {
+			continue
+		}
+		for _, start := range occurrences(e, dateRange.StartDate.Add(-e.duration), dateRange.EndDate) {
+			if e.recurrenceID.IsZero() && e.rrule != nil && overridden[fmt.Sprintf("%s/%d", e.uid, start.Unix())] {
+				continue
+			}
+			end := start.Add(e.duration)
+			if end.After(dateRange.StartDate) && !start.After(dateRange.EndDate) {
+				result = append(result, model.Meeting{Summary: e.summary, Start: start, End: end})
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Start.Before(result[j].Start) })
+	return result
+}
+
+// Weekdays of BYDAY values
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// occurrences returns the start times of the event between from and limit
+// COUNT is applied to every occurrence since the first, including those before from
+func occurrences(e event, from, limit time.Time) []time.Time {
+	if e.rrule == nil {
+		return []time.Time{e.start}
+	}
+
+	interval, _ := strconv.Atoi(e.rrule["INTERVAL"])
+	if interval < 1 {
+		interval = 1
+	}
+	count, _ := strconv.Atoi(e.rrule["COUNT"])
+	if until := e.rrule["UNTIL"]; until != "" {
+		if t, allDay := parseDateTime(property{value: until}, e.start.Location()); !t.IsZero() {
+			if allDay {
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			if t.Before(limit) {
+				limit = t
+			}
+		}
+	}
+
+	var byDay []string
+	if e.rrule["BYDAY"] != "" {
+		byDay = strings.Split(e.rrule["BYDAY"], ",")
+	}
+
+	var starts []time.Time
+	generated := 0
+	add := func(t time.Time) bool {
+		if t.Before(e.start) {
+			return true
+		}
+		if t.After(limit) || (count > 0 && generated >= count) {
+			return false
+		}
+		generated++
+		if t.After(from) && !e.exdates[t.Unix()] {
+			starts = append(starts, t)
+		}
+		return true
+	}
+
+	for step := 0; step < maxSteps; step++ {
+		var candidates []time.Time
+		switch e.rrule["FREQ"] {
+		case "DAILY":
+			day := e.start.AddDate(0, 0, step*interval)
+			if len(byDay) == 0 || hasWeekday(byDay, day.Weekday()) {
+				candidates = []time.Time{day}
+			}
+		case "WEEKLY":
+			week := e.start.AddDate(0, 0, step*7*interval)
+			if len(byDay) == 0 {
+				candidates = []time.Time{week}
+				break
+			}
+			// Days of the week (starting on Monday) that contains week
+			monday := week.AddDate(0, 0, -((int(week.Weekday()) + 6) % 7))
+			for offset := 0; offset < 7; offset++ {
+				if day := monday.AddDate(0, 0, offset); hasWeekday(byDay, day.Weekday()) {
+					candidates = append(candidates, day)
+				}
+			}
+		case "MONTHLY":
+			month := time.Date(e.start.Year(), e.start.Month()+time.Month(step*interval), 1,
+				e.start.Hour(), e.start.Minute(), e.start.Second(), 0, e.start.Location())
+			if len(byDay) == 0 {
+				// Months without the day of the month (e.g. the 31st) are skipped
+				day := month.AddDate(0, 0, e.start.Day()-1)
+				if day.Month() == month.Month() {
+					candidates = []time.Time{day}
+				}
+			}
+			for _, d := range byDay {
+				if day, ok := nthWeekday(month, d); ok {
+					candidates = append(candidates, day)
+				}
+			}
+			sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+		case "YEARLY":
+			candidates = []time.Time{e.start.AddDate(step*interval, 0, 0)}
+		default:
+			// Unsupported frequencies keep the first occurrence only
+			return []time.Time{e.start}
+		}
+
+		for _, t := range candidates {
+			if !add(t) {
+				return starts
+			}
+		}
+		if len(candidates) > 0 && candidates[0].After(limit) {
+			return starts
+		}
+	}
+	return starts
+}
+
+// hasWeekday reports whether the BYDAY values include the weekday
+func hasWeekday(byDay []string, weekday time.Weekday) bool {
+	for _, d := range byDay {
+		if w, ok := weekdays[strings.ToUpper(d)]; ok && w == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// nthWeekday resolves a monthly BYDAY value such as 2TU or -1FR in the month of first
+func nthWeekday(first time.Time, value string) (time.Time, bool) {
+	value = strings.ToUpper(value)
+	if len(value) < 2 {
+		return time.Time{}, false
+	}
+	weekday, ok := weekdays[value[len(value)-2:]]
+	if !ok {
+		return time.Time{}, false
+	}
+	n := 1
+	if prefix := value[:len(value)-2]; prefix != "" {
+		var err error
+		if n, err = strconv.Atoi(prefix); err != nil || n == 0 {
+			return time.Time{}, false
+		}
+	}
+
+	if n > 0 {
+		day := first.AddDate(0, 0, (int(weekday)-int(first.Weekday())+7)%7+7*(n-1))
+		return day, day.Month() == first.Month()
+	}
+	last := first.AddDate(0, 1, -1)
+	day := last.AddDate(0, 0, -((int(last.Weekday())-int(weekday)+7)%7)+7*(n+1))
+	return day, day.Month() == first.Month()
+}
@@ -0,0 +1,77 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// FetchChecks はPRのヘッドコミットのステータスとチェック結果を取得し、ひとつの状態にまとめます
+func (c *Client) FetchChecks(ctx context.Context, item *model.Item) error {
+	repoPath := getRepoPathFromURL(item.Repository)
+	if repoPath == "" {
+		return fmt.Errorf("Failed to extract repository path: %s", item.Repository)
+	}
+	if item.HeadSHA == "" {
+		return nil
+	}
+
+	// Commit statuses (legacy CI integrations)
+	var status struct {
+		State      string `json:"state"`
+		TotalCount int    `json:"total_count"`
+	}
+	err := c.get(ctx, fmt.Sprintf("repos/%s/commits/%s/status", repoPath, item.HeadSHA), &status)
+	if err != nil {
+		return fmt.Errorf("Failed to retrieve commit status: %w", err)
+	}
+
+	// Check runs (GitHub Actions and GitHub Apps)
+	var checkRuns struct {
+		CheckRuns []struct {
+			Status     string `json:"status"`
+			Conclusion string `json:"conclusion"`
+		} `json:"check_runs"`
+	}
+	err = c.get(ctx, fmt.Sprintf("repos/%s/commits/%s/check-runs?per_page=100", repoPath, item.HeadSHA), &checkRuns)
+	if err != nil {
+		return fmt.Errorf("Failed to retrieve check runs: %w", err)
+	}
+
+	var states []string
+	if status.TotalCount > 0 {
+		states = append(states, status.State)
+	}
+	for _, run := range checkRuns.CheckRuns {
+		switch {
+		case run.Status != "completed":
+			states = append(states, "pending")
+		case run.Conclusion == "success" || run.Conclusion == "neutral" || run.Conclusion == "skipped":
+			states = append(states, "success")
+		default:
+			states = append(states, "failure")
+		}
+	}
+
+	item.ChecksState = combineCheckStates(states)
+	return nil
+}
+
+// 複数のチェック状態をまとめます（失敗 > 実行中 > 成功の順に優先）
+func combineCheckStates(states []string) string {
+	combined := ""
+	for _, state := range states {
+		switch state {
+		case "failure", "error":
+			return "failure"
+		case "pending":
+			combined = "pending"
+		case "success":
+			if combined == "" {
+				combined = "success"
+			}
+		}
+	}
+	return combined
+}
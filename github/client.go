@@ -3,38 +3,118 @@ package github
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
+	"git.pepabo.com/yukyan/gh-pric/github/cache"
 	"git.pepabo.com/yukyan/gh-pric/github/model"
 	"github.com/cli/go-gh/v2/pkg/api"
 )
 
 // Client は GitHub API を操作するためのクライアント
 type Client struct {
-	client *api.RESTClient
+	client  *api.RESTClient
+	graphql *api.GraphQLClient
+
+	mu           sync.Mutex
+	privateRepos map[string]repoVisibility // Visibility of repositories looked up so far
+	hooks        *hookSet                  // Hooks called around requests and retries
+	searches     chan struct{}             // Slots of the search requests in flight (nil = no limit)
+	offline      bool                      // Whether every request is served from the cache
+
+	// MaxRetries is the number of attempts made for each API request (values below 1 mean a single attempt)
+	MaxRetries int
+
+	// MaxComments caps the comments (including review comments) fetched per item (0 = unlimited)
+	MaxComments int
+
+	// RetryWait is the wait before the first retry, doubled on every further attempt
+	RetryWait time.Duration
+}
+
+// ClientOptions はクライアント作成時の設定です
+type ClientOptions struct {
+	// CacheDir enables the on-disk response cache in the given directory (empty = disabled)
+	CacheDir string
+
+	// Offline serves every request from the cache in CacheDir without touching the network
+	Offline bool
+
+	// Trace logs every API request, its status, rate limit state and retries to this writer (nil = disabled)
+	Trace io.Writer
+
+	// Host is the GitHub hostname, e.g. a GitHub Enterprise Server (empty = the gh default host)
+	Host string
+
+	// OnRateLimit receives the remaining quota of the API resource after every response from the network (nil = disabled)
+	OnRateLimit func(resource string, remaining int)
+
+	// Transport sends the requests that reach the network (nil = http.DefaultTransport)
+	// Tests and embedders can serve recorded responses through it instead of calling the API
+	Transport http.RoundTripper
+
+	// Hooks are called around every request that reaches the network and before retries, after Trace and OnRateLimit
+	Hooks []Hooks
 }
 
 // NewClient は新しいGitHubクライアントを作成します
-func NewClient() (*Client, error) {
-	client, err := api.DefaultRESTClient()
+func NewClient(opts ClientOptions) (*Client, error) {
+	hooks := &hookSet{}
+	if opts.Trace != nil {
+		hooks.add(traceHooks(opts.Trace))
+	}
+	if opts.OnRateLimit != nil {
+		hooks.add(rateLimitHooks(opts.OnRateLimit))
+	}
+	for _, h := range opts.Hooks {
+		hooks.add(h)
+	}
+
+	base := opts.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	// Requests are paced to the quota of the token, shared with every other client of the same host
+	var transport http.RoundTripper = &throttleTransport{base: base, throttle: throttleFor(opts.Host)}
+	transport = &hookTransport{base: transport, hooks: hooks}
+	if opts.CacheDir != "" {
+		// Hooks run below the cache so that only requests reaching the network are seen
+		transport = &cache.Transport{Dir: opts.CacheDir, Base: transport, Offline: opts.Offline}
+	}
+
+	client, err := api.NewRESTClient(api.ClientOptions{Host: opts.Host, Transport: transport})
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize GitHub client: %w", err)
 	}
+
+	graphql, err := api.NewGraphQLClient(api.ClientOptions{Host: opts.Host, Transport: transport})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize GitHub GraphQL client: %w", err)
+	}
 	
 	return &Client{
-		client: client,
+		client:       client,
+		graphql:      graphql,
+		privateRepos: make(map[string]repoVisibility),
+		hooks:        hooks,
+		searches:     make(chan struct{}, maxConcurrentSearches),
+		offline:      opts.CacheDir != "" && opts.Offline,
+		MaxRetries:   DefaultMaxRetries,
+		RetryWait:    DefaultRetryWait,
 	}, nil
 }
 
 // GetUsername は現在認証されているユーザー名を取得します
-func (c *Client) GetUsername() (string, error) {
+func (c *Client) GetUsername(ctx context.Context) (string, error) {
 	userInfo := struct {
 		Login string `json:"login"`
 	}{}
 	
-	err := c.client.Get("user", &userInfo)
+	err := c.get(ctx, "user", &userInfo)
 	if err != nil {
 		return "", fmt.Errorf("failed to retrieve user information: %w", err)
 	}
@@ -43,248 +123,17 @@ func (c *Client) GetUsername() (string, error) {
 }
 
 // FetchIssues はGitHub APIからIssueを取得します
-func (c *Client) FetchIssues(ctx context.Context, username, involvement string, dateRange model.DateRange) ([]model.Item, error) {
-	// Query parameters for filtering by date range
-	startDateStr := dateRange.StartDate.Format("2006-01-02")
-	
-	// Construct appropriate query parameters based on involvement
-	var query string
-	switch involvement {
-	case "created":
-		query = fmt.Sprintf("search/issues?q=is:issue+author:%s+created:>=%s&per_page=100", 
-			username, startDateStr)
-	case "assigned":
-		query = fmt.Sprintf("search/issues?q=is:issue+assignee:%s+created:>=%s&per_page=100", 
-			username, startDateStr)
-	case "commented":
-		query = fmt.Sprintf("search/issues?q=is:issue+commenter:%s+created:>=%s&per_page=100", 
-			username, startDateStr)
-	default:
-		query = fmt.Sprintf("search/issues?q=is:issue+involves:%s+created:>=%s&per_page=100", 
-			username, startDateStr)
-	}
-	
-	items := []model.Item{}
-	page := 1
-	hasMore := true
-
-	for hasMore {
-		var response struct {
-			Items []struct {
-				URL           string    `json:"html_url"`
-				Number        int       `json:"number"`
-				Title         string    `json:"title"`
-				State         string    `json:"state"`
-				CreatedAt     time.Time `json:"created_at"`
-				UpdatedAt     time.Time `json:"updated_at"`
-				RepositoryURL string    `json:"repository_url"`
-				User          struct {
-					Login string `json:"login"`
-				} `json:"user"`
-				Assignees []struct {
-					Login string `json:"login"`
-				} `json:"assignees"`
-				Labels []struct {
-					Name string `json:"name"`
-				} `json:"labels"`
-			} `json:"items"`
-		}
-		
-		pageQuery := fmt.Sprintf("%s&page=%d", query, page)
-		
-		// Add retry functionality
-		var err error
-		maxRetries := 3
-		for retryCount := 0; retryCount < maxRetries; retryCount++ {
-			err = c.client.Get(pageQuery, &response)
-			if err == nil {
-				break
-			}
-			
-			// Wait before retrying
-			time.Sleep(2 * time.Second)
-		}
-		
-		if err != nil {
-			return nil, fmt.Errorf("Failed to retrieve Issues: %w", err)
-		}
-		
-		// Exit if the response is empty
-		if len(response.Items) == 0 {
-			hasMore = false
-			continue
-		}
-
-		for _, issue := range response.Items {
-			// Skip items outside the date range
-			if issue.CreatedAt.After(dateRange.EndDate) || issue.CreatedAt.Before(dateRange.StartDate) {
-				continue
-			}
-
-			// Extract repository name
-			repoURL := issue.RepositoryURL
-			repoParts := strings.Split(repoURL, "/")
-			repoName := ""
-			if len(repoParts) >= 2 {
-				repoName = fmt.Sprintf("%s/%s", repoParts[len(repoParts)-2], repoParts[len(repoParts)-1])
-			}
-
-			// Extract assignees
-			assignees := make([]string, len(issue.Assignees))
-			for i, a := range issue.Assignees {
-				assignees[i] = a.Login
-			}
-
-			// Extract labels
-			labels := make([]string, len(issue.Labels))
-			for i, l := range issue.Labels {
-				labels[i] = l.Name
-			}
-
-			item := model.Item{
-				Type:       "Issue",
-				Number:     issue.Number,
-				Title:      issue.Title,
-				URL:        issue.URL,
-				State:      issue.State,
-				CreatedAt:  issue.CreatedAt,
-				UpdatedAt:  issue.UpdatedAt,
-				Author:     issue.User.Login,
-				Assignees:  assignees,
-				Labels:     labels,
-				Repository: repoName,
-			}
-			items = append(items, item)
-		}
-
-		// Consider Rate Limit
-		time.Sleep(1 * time.Second)
-		page++
-		
-		// Exit if a certain number has been retrieved (optional)
-		if page > 10 {
-			hasMore = false
-		}
-	}
-
-	return items, nil
+// 2番目の戻り値はページ上限により結果が打ち切られたかどうかを示します
+func (c *Client) FetchIssues(ctx context.Context, opts FetchOptions) ([]model.Item, bool, error) {
+	qualifiers := fmt.Sprintf("is:issue+%s:%s", getInvolvementQuery(opts.Involvement), opts.Username)
+	return c.searchRange(ctx, qualifiers, "Issue", opts)
 }
 
 // FetchPRs はGitHub APIからPRを取得します
-func (c *Client) FetchPRs(ctx context.Context, username, involvement string, dateRange model.DateRange) ([]model.Item, error) {
-	// Query parameters for filtering by date range
-	startDateStr := dateRange.StartDate.Format("2006-01-02")
-	
-	query := fmt.Sprintf("search/issues?q=is:pr+%s:%s+created:>=%s&per_page=100", 
-		getInvolvementQuery(involvement), username, startDateStr)
-	
-	items := []model.Item{}
-	page := 1
-	hasMore := true
-
-	for hasMore {
-		var response struct {
-			Items []struct {
-				URL           string    `json:"html_url"`
-				Number        int       `json:"number"`
-				Title         string    `json:"title"`
-				State         string    `json:"state"`
-				CreatedAt     time.Time `json:"created_at"`
-				UpdatedAt     time.Time `json:"updated_at"`
-				RepositoryURL string    `json:"repository_url"`
-				User          struct {
-					Login string `json:"login"`
-				} `json:"user"`
-				Assignees []struct {
-					Login string `json:"login"`
-				} `json:"assignees"`
-				Labels []struct {
-					Name string `json:"name"`
-				} `json:"labels"`
-				PullRequest struct {
-					URL string `json:"url"`
-				} `json:"pull_request"`
-			} `json:"items"`
-		}
-		
-		pageQuery := fmt.Sprintf("%s&page=%d", query, page)
-		
-		// Add retry functionality
-		var err error
-		maxRetries := 3
-		for retryCount := 0; retryCount < maxRetries; retryCount++ {
-			err = c.client.Get(pageQuery, &response)
-			if err == nil {
-				break
-			}
-			
-			// Wait before retrying
-			time.Sleep(2 * time.Second)
-		}
-		
-		if err != nil {
-			return nil, fmt.Errorf("Failed to retrieve PRs: %w", err)
-		}
-		
-		// Exit if the response is empty
-		if len(response.Items) == 0 {
-			hasMore = false
-			continue
-		}
-
-		for _, pr := range response.Items {
-			// Skip items outside the date range
-			if pr.CreatedAt.After(dateRange.EndDate) || pr.CreatedAt.Before(dateRange.StartDate) {
-				continue
-			}
-
-			// Extract repository name
-			repoURL := pr.RepositoryURL
-			repoParts := strings.Split(repoURL, "/")
-			repoName := ""
-			if len(repoParts) >= 2 {
-				repoName = fmt.Sprintf("%s/%s", repoParts[len(repoParts)-2], repoParts[len(repoParts)-1])
-			}
-
-			// Extract assignees
-			assignees := make([]string, len(pr.Assignees))
-			for i, a := range pr.Assignees {
-				assignees[i] = a.Login
-			}
-
-			// Extract labels
-			labels := make([]string, len(pr.Labels))
-			for i, l := range pr.Labels {
-				labels[i] = l.Name
-			}
-
-			item := model.Item{
-				Type:       "PR",
-				Number:     pr.Number,
-				Title:      pr.Title,
-				URL:        pr.URL,
-				State:      pr.State,
-				CreatedAt:  pr.CreatedAt,
-				UpdatedAt:  pr.UpdatedAt,
-				Author:     pr.User.Login,
-				Assignees:  assignees,
-				Labels:     labels,
-				Repository: repoName,
-			}
-			items = append(items, item)
-		}
-
-		// Consider Rate Limit
-		time.Sleep(1 * time.Second)
-		page++
-		
-		// Exit if a certain number has been retrieved (optional)
-		if page > 10 {
-			hasMore = false
-		}
-	}
-
-	return items, nil
+// 2番目の戻り値はページ上限により結果が打ち切られたかどうかを示します
+func (c *Client) FetchPRs(ctx context.Context, opts FetchOptions) ([]model.Item, bool, error) {
+	qualifiers := fmt.Sprintf("is:pr+%s:%s", getInvolvementQuery(opts.Involvement), opts.Username)
+	return c.searchRange(ctx, qualifiers, "PR", opts)
 }
 
 // FetchIssueDetails はIssueの詳細情報（本文やコメント）を取得します
@@ -302,18 +151,7 @@ func (c *Client) FetchIssueDetails(ctx context.Context, item *model.Item) error
 	
 	issueURL := fmt.Sprintf("repos/%s/issues/%d", repoPath, item.Number)
 	
-	// Use retry functionality
-	var err error
-	maxRetries := 3
-	for retryCount := 0; retryCount < maxRetries; retryCount++ {
-		err = c.client.Get(issueURL, &issueDetail)
-		if err == nil {
-			break
-		}
-		
-		// Wait before retrying
-		time.Sleep(2 * time.Second)
-	}
+	err := c.get(ctx, issueURL, &issueDetail)
 	
 	if err != nil {
 		return fmt.Errorf("Failed to retrieve Issue details: %w", err)
@@ -335,29 +173,45 @@ func (c *Client) FetchPRDetails(ctx context.Context, item *model.Item) error {
 	
 	// Retrieve PR details (PR can also be retrieved from the Issue endpoint)
 	var prDetail struct {
-		Body string `json:"body"`
+		Body         string    `json:"body"`
+		Additions    int       `json:"additions"`
+		Deletions    int       `json:"deletions"`
+		ChangedFiles int       `json:"changed_files"`
+		Merged       bool      `json:"merged"`
+		MergedAt     time.Time `json:"merged_at"`
+		Head         struct {
+			SHA string `json:"sha"`
+			Ref string `json:"ref"`
+		} `json:"head"`
+		Base struct {
+			Repo struct {
+				Private bool `json:"private"`
+			} `json:"repo"`
+		} `json:"base"`
 	}
 	
 	prURL := fmt.Sprintf("repos/%s/pulls/%d", repoPath, item.Number)
 	
-	// Use retry functionality
-	var err error
-	maxRetries := 3
-	for retryCount := 0; retryCount < maxRetries; retryCount++ {
-		err = c.client.Get(prURL, &prDetail)
-		if err == nil {
-			break
-		}
-		
-		// Wait before retrying
-		time.Sleep(2 * time.Second)
-	}
+	err := c.get(ctx, prURL, &prDetail)
 	
 	if err != nil {
 		return fmt.Errorf("Failed to retrieve PR details: %w", err)
 	}
 	
 	item.Body = prDetail.Body
+	item.Additions = prDetail.Additions
+	item.Deletions = prDetail.Deletions
+	item.ChangedFiles = prDetail.ChangedFiles
+	item.HeadSHA = prDetail.Head.SHA
+	item.HeadRef = prDetail.Head.Ref
+	// The search result does not say whether the repository is private
+	item.Private = prDetail.Base.Repo.Private
+
+	// The search API reports merged PRs as closed
+	if prDetail.Merged {
+		item.State = "merged"
+		item.MergedAt = prDetail.MergedAt
+	}
 	
 	// Retrieve comments
 	issueCommentsURL := fmt.Sprintf("repos/%s/issues/%d/comments", repoPath, item.Number)
@@ -368,7 +222,14 @@ func (c *Client) FetchPRDetails(ctx context.Context, item *model.Item) error {
 	
 	// Also retrieve PR review comments
 	reviewCommentsURL := fmt.Sprintf("repos/%s/pulls/%d/comments", repoPath, item.Number)
-	return c.FetchReviewComments(ctx, item, reviewCommentsURL)
+	err = c.FetchReviewComments(ctx, item, reviewCommentsURL)
+	if err != nil {
+		return err
+	}
+
+	// Retrieve submitted reviews
+	reviewsURL := fmt.Sprintf("repos/%s/pulls/%d/reviews?per_page=100", repoPath, item.Number)
+	return c.FetchReviews(ctx, item, reviewsURL)
 }
 
 // FetchComments はコメントを取得します
@@ -376,39 +237,35 @@ func (c *Client) FetchComments(ctx context.Context, item *model.Item, commentsUR
 	var comments []struct {
 		User struct {
 			Login string `json:"login"`
+			Type  string `json:"type"`
 		} `json:"user"`
-		Body      string    `json:"body"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
+		Body      string         `json:"body"`
+		CreatedAt time.Time      `json:"created_at"`
+		UpdatedAt time.Time      `json:"updated_at"`
+		Reactions reactionRollup `json:"reactions"`
 	}
 	
-	// Use retry functionality
-	var err error
-	maxRetries := 3
-	for retryCount := 0; retryCount < maxRetries; retryCount++ {
-		err = c.client.Get(commentsURL, &comments)
-		if err == nil {
-			break
+	// Add comments to the Item struct, page by page
+	err := c.getPages(ctx, withPerPage(commentsURL), &comments, func() bool {
+		for _, comment := range comments {
+			if c.commentLimitReached(item) {
+				return false
+			}
+			item.Comments = append(item.Comments, model.Comment{
+				Author:      comment.User.Login,
+				AuthorIsBot: isBot(comment.User.Login, comment.User.Type),
+				Body:        comment.Body,
+				CreatedAt:   comment.CreatedAt,
+				UpdatedAt:   comment.UpdatedAt,
+				Reactions:   comment.Reactions.toModel(),
+			})
 		}
-		
-		// Wait before retrying
-		time.Sleep(2 * time.Second)
-	}
-	
+		return !c.commentLimitReached(item)
+	})
 	if err != nil {
 		return fmt.Errorf("Failed to retrieve comments: %w", err)
 	}
 	
-	// Add comments to the Item struct
-	for _, c := range comments {
-		item.Comments = append(item.Comments, model.Comment{
-			Author:    c.User.Login,
-			Body:      c.Body,
-			CreatedAt: c.CreatedAt,
-			UpdatedAt: c.UpdatedAt,
-		})
-	}
-	
 	return nil
 }
 
@@ -417,39 +274,118 @@ func (c *Client) FetchReviewComments(ctx context.Context, item *model.Item, revi
 	var reviewComments []struct {
 		User struct {
 			Login string `json:"login"`
+			Type  string `json:"type"`
 		} `json:"user"`
-		Body      string    `json:"body"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
+		Body         string         `json:"body"`
+		CreatedAt    time.Time      `json:"created_at"`
+		UpdatedAt    time.Time      `json:"updated_at"`
+		Reactions    reactionRollup `json:"reactions"`
+		Path         string         `json:"path"`
+		Line         int            `json:"line"`
+		OriginalLine int            `json:"original_line"`
+		DiffHunk     string         `json:"diff_hunk"`
 	}
 	
-	// Use retry functionality
-	var err error
-	maxRetries := 3
-	for retryCount := 0; retryCount < maxRetries; retryCount++ {
-		err = c.client.Get(reviewCommentsURL, &reviewComments)
-		if err == nil {
-			break
+	// Add review comments to the Item struct, page by page
+	err := c.getPages(ctx, withPerPage(reviewCommentsURL), &reviewComments, func() bool {
+		for _, rc := range reviewComments {
+			if c.commentLimitReached(item) {
+				return false
+			}
+			// Comments on outdated diffs no longer have a current line
+			line := rc.Line
+			if line == 0 {
+				line = rc.OriginalLine
+			}
+			item.Comments = append(item.Comments, model.Comment{
+				Author:      rc.User.Login,
+				AuthorIsBot: isBot(rc.User.Login, rc.User.Type),
+				Body:        rc.Body,
+				CreatedAt:   rc.CreatedAt,
+				UpdatedAt:   rc.UpdatedAt,
+				Reactions:   rc.Reactions.toModel(),
+				Path:        rc.Path,
+				Line:        line,
+				DiffHunk:    rc.DiffHunk,
+			})
 		}
-		
-		// Wait before retrying
-		time.Sleep(2 * time.Second)
-	}
-	
+		return !c.commentLimitReached(item)
+	})
 	if err != nil {
 		return fmt.Errorf("Failed to retrieve review comments: %w", err)
 	}
 	
-	// Add review comments to the Item struct
-	for _, rc := range reviewComments {
-		item.Comments = append(item.Comments, model.Comment{
-			Author:    rc.User.Login,
-			Body:      rc.Body,
-			CreatedAt: rc.CreatedAt,
-			UpdatedAt: rc.UpdatedAt,
+	return nil
+}
+
+// FetchReviews はPRに提出されたレビューとその状態を取得します
+func (c *Client) FetchReviews(ctx context.Context, item *model.Item, reviewsURL string) error {
+	var reviews []struct {
+		User struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		State       string    `json:"state"`
+		Body        string    `json:"body"`
+		SubmittedAt time.Time `json:"submitted_at"`
+	}
+
+	err := c.get(ctx, reviewsURL, &reviews)
+	if err != nil {
+		return fmt.Errorf("Failed to retrieve reviews: %w", err)
+	}
+
+	// Add reviews to the Item struct
+	for _, r := range reviews {
+		// Pending reviews have not been submitted yet
+		if r.State == "PENDING" {
+			continue
+		}
+		item.Reviews = append(item.Reviews, model.Review{
+			Author:      r.User.Login,
+			State:       r.State,
+			Body:        r.Body,
+			SubmittedAt: r.SubmittedAt,
 		})
 	}
-	
+
+	return nil
+}
+
+// FetchPRCommits はPRに含まれるコミットを取得します
+func (c *Client) FetchPRCommits(ctx context.Context, item *model.Item) error {
+	repoPath := getRepoPathFromURL(item.Repository)
+	if repoPath == "" {
+		return fmt.Errorf("Failed to extract repository path: %s", item.Repository)
+	}
+
+	var commits []struct {
+		SHA    string `json:"sha"`
+		URL    string `json:"html_url"`
+		Commit struct {
+			Message string `json:"message"`
+			Author  struct {
+				Date time.Time `json:"date"`
+			} `json:"author"`
+		} `json:"commit"`
+	}
+
+	commitsURL := fmt.Sprintf("repos/%s/pulls/%d/commits?per_page=100", repoPath, item.Number)
+	err := c.get(ctx, commitsURL, &commits)
+	if err != nil {
+		return fmt.Errorf("Failed to retrieve PR commits: %w", err)
+	}
+
+	// Add commits to the Item struct
+	for _, pc := range commits {
+		item.Commits = append(item.Commits, model.Commit{
+			SHA:        pc.SHA,
+			Message:    pc.Commit.Message,
+			URL:        pc.URL,
+			Repository: item.Repository,
+			AuthoredAt: pc.Commit.Author.Date,
+		})
+	}
+
 	return nil
 }
 
@@ -474,6 +410,50 @@ func FilterIgnoredUserComments(items []model.Item, ignoreUsers []string) {
 	}
 }
 
+// FilterOnlyUserComments は主な関わりがコメントであるアイテムについて、指定したユーザーのコメントのみを残します
+func FilterOnlyUserComments(items []model.Item, username string) {
+	for i := range items {
+		// Keep the whole discussion on items you created or were assigned to
+		if len(items[i].Involvements) == 0 || items[i].Involvements[0] != "commented" {
+			continue
+		}
+
+		var filteredComments []model.Comment
+		for _, comment := range items[i].Comments {
+			if comment.Author == username {
+				filteredComments = append(filteredComments, comment)
+			}
+		}
+		items[i].Comments = filteredComments
+	}
+}
+
+// FilterBots はボットが作成したアイテムとボットのコメントを除外します
+func FilterBots(items []model.Item) []model.Item {
+	var filteredItems []model.Item
+	for _, item := range items {
+		// Dependency updates are kept because the output collapses them into a summary line
+		if item.AuthorIsBot && !item.IsDependencyUpdate() {
+			continue
+		}
+
+		var filteredComments []model.Comment
+		for _, comment := range item.Comments {
+			if !comment.AuthorIsBot {
+				filteredComments = append(filteredComments, comment)
+			}
+		}
+		item.Comments = filteredComments
+		filteredItems = append(filteredItems, item)
+	}
+	return filteredItems
+}
+
+// isBot はユーザーがボットかどうかを判定します
+func isBot(login, userType string) bool {
+	return userType == "Bot" || strings.HasSuffix(login, "[bot]")
+}
+
 // GitHubクエリのインボルブメントタイプを取得します
 func getInvolvementQuery(involvement string) string {
 	switch involvement {
@@ -485,6 +465,8 @@ func getInvolvementQuery(involvement string) string {
 		return "reviewed-by"
 	case "commented":
 		return "commenter"
+	case "mentioned":
+		return "mentions"
 	default:
 		return "involves"
 	}
@@ -0,0 +1,81 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// FetchCommits は期間内にユーザーが作成したコミットを取得します
+// 2番目の戻り値はページ上限により結果が打ち切られたかどうかを示します
+func (c *Client) FetchCommits(ctx context.Context, opts FetchOptions) ([]model.Commit, bool, error) {
+	query := fmt.Sprintf("search/commits?q=author:%s+author-date:%s..%s%s&sort=author-date&order=asc&per_page=%d",
+		opts.Username, formatSearchTime(opts.DateRange.StartDate), formatSearchTime(opts.DateRange.EndDate), opts.scopeQualifiers(), searchPerPage)
+
+	commits := []model.Commit{}
+	page := 1
+	truncated := false
+
+	for {
+		var response struct {
+			TotalCount int `json:"total_count"`
+			Items      []struct {
+				SHA    string `json:"sha"`
+				URL    string `json:"html_url"`
+				Commit struct {
+					Message string `json:"message"`
+					Author  struct {
+						Date time.Time `json:"date"`
+					} `json:"author"`
+				} `json:"commit"`
+				Repository struct {
+					FullName string `json:"full_name"`
+					Private  bool   `json:"private"`
+				} `json:"repository"`
+			} `json:"items"`
+		}
+
+		err := c.search(ctx, fmt.Sprintf("%s&page=%d", query, page), &response)
+		if err != nil {
+			return nil, false, fmt.Errorf("Failed to retrieve commits: %w", err)
+		}
+
+		// Exit if the response is empty
+		if len(response.Items) == 0 {
+			break
+		}
+
+		for _, result := range response.Items {
+			// Enforce the repository filters again on the results
+			if !opts.repoAllowed(result.Repository.FullName) || !opts.visibilityAllowed(result.Repository.Private) {
+				continue
+			}
+			commits = append(commits, model.Commit{
+				SHA:        result.SHA,
+				Message:    result.Commit.Message,
+				URL:        result.URL,
+				Repository: result.Repository.FullName,
+				Private:    result.Repository.Private,
+				AuthoredAt: result.Commit.Author.Date,
+			})
+		}
+
+		// Exit once every result reported by the search has been read
+		fetched := page * searchPerPage
+		if fetched >= response.TotalCount {
+			break
+		}
+
+		// Exit if the page limit or the search result limit has been reached
+		if (opts.MaxPages > 0 && page >= opts.MaxPages) || fetched >= searchResultLimit {
+			truncated = true
+			break
+		}
+
+		page++
+	}
+
+	return commits, truncated, nil
+}
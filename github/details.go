@@ -0,0 +1,318 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// Items whose details are requested in one GraphQL query
+// Larger batches risk the query timing out on items with long discussions
+const detailBatchSize = 50
+
+// Query for the bodies, comments and reviews of Issues and PRs by node ID
+// Connections are read up to the size of one REST page; items with more are fetched over REST instead
+const detailsQuery = `
+query($ids: [ID!]!) {
+  nodes(ids: $ids) {
+    ... on Issue {
+      id
+      repository { isPrivate }
+      body
+      comments(first: 100) { ...comments }
+    }
+    ... on PullRequest {
+      id
+      repository { isPrivate }
+      body
+      additions
+      deletions
+      changedFiles
+      merged
+      mergedAt
+      headRefOid
+      headRefName
+      comments(first: 100) { ...comments }
+      reviews(first: 100) {
+        nodes { author { __typename login } state body submittedAt }
+      }
+      reviewThreads(first: 50) {
+        pageInfo { hasNextPage }
+        nodes {
+          comments(first: 50) {
+            pageInfo { hasNextPage }
+            nodes { ...comment path line originalLine diffHunk }
+          }
+        }
+      }
+    }
+  }
+}
+
+fragment comments on IssueCommentConnection {
+  pageInfo { hasNextPage }
+  nodes { ...comment }
+}
+
+fragment comment on Comment {
+  author { __typename login }
+  body
+  createdAt
+  updatedAt
+  ... on Reactable { reactionGroups { content reactors { totalCount } } }
+}`
+
+// Struct for the author of a comment or review in the GraphQL response (nil for deleted accounts)
+type graphqlActor struct {
+	Typename string `json:"__typename"`
+	Login    string `json:"login"`
+}
+
+// Struct for a comment in the GraphQL response
+type graphqlComment struct {
+	Author         *graphqlActor `json:"author"`
+	Body           string        `json:"body"`
+	CreatedAt      time.Time     `json:"createdAt"`
+	UpdatedAt      time.Time     `json:"updatedAt"`
+	ReactionGroups []struct {
+		Content  string `json:"content"`
+		Reactors struct {
+			TotalCount int `json:"totalCount"`
+		} `json:"reactors"`
+	} `json:"reactionGroups"`
+	Path         string `json:"path"`
+	Line         int    `json:"line"`
+	OriginalLine int    `json:"originalLine"`
+	DiffHunk     string `json:"diffHunk"`
+}
+
+// Struct for a page of comments in the GraphQL response
+type graphqlComments struct {
+	PageInfo struct {
+		HasNextPage bool `json:"hasNextPage"`
+	} `json:"pageInfo"`
+	Nodes []graphqlComment `json:"nodes"`
+}
+
+// Struct for an Issue or PR in the GraphQL response
+type detailNode struct {
+	ID         string `json:"id"`
+	Repository struct {
+		IsPrivate bool `json:"isPrivate"`
+	} `json:"repository"`
+	Body         string          `json:"body"`
+	Additions    int             `json:"additions"`
+	Deletions    int             `json:"deletions"`
+	ChangedFiles int             `json:"changedFiles"`
+	Merged       bool            `json:"merged"`
+	MergedAt     time.Time       `json:"mergedAt"`
+	HeadRefOid   string          `json:"headRefOid"`
+	HeadRefName  string          `json:"headRefName"`
+	Comments     graphqlComments `json:"comments"`
+	Reviews      struct {
+		Nodes []struct {
+			Author      *graphqlActor `json:"author"`
+			State       string        `json:"state"`
+			Body        string        `json:"body"`
+			SubmittedAt time.Time     `json:"submittedAt"`
+		} `json:"nodes"`
+	} `json:"reviews"`
+	ReviewThreads struct {
+		PageInfo struct {
+			HasNextPage bool `json:"hasNextPage"`
+		} `json:"pageInfo"`
+		Nodes []struct {
+			Comments graphqlComments `json:"comments"`
+		} `json:"nodes"`
+	} `json:"reviewThreads"`
+}
+
+// FetchDetailsBatch は FetchIssueDetails と FetchPRDetails が取得する本文・コメント・レビューを、
+// GraphQL のノード ID で最大 detailBatchSize 件ずつまとめて取得します
+// 戻り値は各項目を埋められたかどうかです。ノード ID がない項目や、コメントが 1 回のクエリに収まらない項目は埋められません
+// 途中でエラーになった場合は、それまでに埋めた結果をエラーと一緒に返します
+func (c *Client) FetchDetailsBatch(ctx context.Context, items []*model.Item) ([]bool, error) {
+	filled := make([]bool, len(items))
+	// Queries are POST requests, which the offline cache cannot serve
+	if c.offline {
+		return filled, nil
+	}
+
+	// Items without a node ID, e.g. read back from a saved report, are left to the REST requests
+	var batch []int
+	for i, item := range items {
+		if item.NodeID != "" && (item.Type == "Issue" || item.Type == "PR") {
+			batch = append(batch, i)
+		}
+	}
+
+	for start := 0; start < len(batch); start += detailBatchSize {
+		end := start + detailBatchSize
+		if end > len(batch) {
+			end = len(batch)
+		}
+		ids := make([]string, 0, end-start)
+		for _, i := range batch[start:end] {
+			ids = append(ids, items[i].NodeID)
+		}
+
+		var response struct {
+			Nodes []*detailNode `json:"nodes"`
+		}
+		err := c.withRetry(ctx, func() error {
+			return c.graphql.DoWithContext(ctx, detailsQuery, map[string]interface{}{"ids": ids}, &response)
+		})
+		// Nodes that could not be resolved come back as null next to the others, with an error for each
+		var graphqlErr *api.GraphQLError
+		if err != nil && !errors.As(err, &graphqlErr) {
+			return filled, fmt.Errorf("Failed to retrieve details: %w", err)
+		}
+
+		for k, i := range batch[start:end] {
+			if k < len(response.Nodes) && response.Nodes[k] != nil && response.Nodes[k].ID == items[i].NodeID {
+				// The search result does not say whether the repository is private
+				items[i].Private = response.Nodes[k].Repository.IsPrivate
+				filled[i] = c.applyDetails(items[i], response.Nodes[k])
+			}
+		}
+	}
+
+	return filled, nil
+}
+
+// applyDetails fills the details of node into item, the same way the REST requests would
+// Nothing is changed and false is returned when some comments did not fit in the query
+func (c *Client) applyDetails(item *model.Item, node *detailNode) bool {
+	detailed := *item
+	detailed.Comments = append([]model.Comment(nil), item.Comments...)
+
+	for _, comment := range node.Comments.Nodes {
+		if c.commentLimitReached(&detailed) {
+			break
+		}
+		detailed.Comments = append(detailed.Comments, comment.toModel())
+	}
+	// Longer discussions are paged through over REST, unless MaxComments has been reached anyway
+	if node.Comments.PageInfo.HasNextPage && !c.commentLimitReached(&detailed) {
+		return false
+	}
+
+	if item.Type == "PR" {
+		detailed.Additions = node.Additions
+		detailed.Deletions = node.Deletions
+		detailed.ChangedFiles = node.ChangedFiles
+		detailed.HeadSHA = node.HeadRefOid
+		detailed.HeadRef = node.HeadRefName
+		// The search API reports merged PRs as closed
+		if node.Merged {
+			detailed.State = "merged"
+			detailed.MergedAt = node.MergedAt
+		}
+
+		// Review comments are grouped by thread here; the REST API lists them in the order they were written
+		truncated := node.ReviewThreads.PageInfo.HasNextPage
+		var reviewComments []model.Comment
+		for _, thread := range node.ReviewThreads.Nodes {
+			truncated = truncated || thread.Comments.PageInfo.HasNextPage
+			for _, comment := range thread.Comments.Nodes {
+				reviewComments = append(reviewComments, comment.toModel())
+			}
+		}
+		sort.SliceStable(reviewComments, func(a, b int) bool {
+			return reviewComments[a].CreatedAt.Before(reviewComments[b].CreatedAt)
+		})
+		for _, comment := range reviewComments {
+			if c.commentLimitReached(&detailed) {
+				break
+			}
+			detailed.Comments = append(detailed.Comments, comment)
+		}
+		if truncated && !c.commentLimitReached(&detailed) {
+			return false
+		}
+
+		for _, r := range node.Reviews.Nodes {
+			// Pending reviews have not been submitted yet
+			if r.State == "PENDING" {
+				continue
+			}
+			detailed.Reviews = append(detailed.Reviews, model.Review{
+				Author:      loginOf(r.Author),
+				State:       r.State,
+				Body:        r.Body,
+				SubmittedAt: r.SubmittedAt,
+			})
+		}
+	}
+
+	detailed.Body = node.Body
+	*item = detailed
+	return true
+}
+
+// toModel はコメントをモデルに変換します
+func (gc graphqlComment) toModel() model.Comment {
+	login, userType := loginOf(gc.Author), ""
+	if gc.Author != nil {
+		userType = gc.Author.Typename
+		// GraphQL leaves out the "[bot]" suffix the REST API shows
+		if userType == "Bot" && !strings.HasSuffix(login, "[bot]") {
+			login += "[bot]"
+		}
+	}
+
+	// Comments on outdated diffs no longer have a current line
+	line := gc.Line
+	if line == 0 {
+		line = gc.OriginalLine
+	}
+
+	var reactions model.Reactions
+	for _, group := range gc.ReactionGroups {
+		count := group.Reactors.TotalCount
+		switch group.Content {
+		case "THUMBS_UP":
+			reactions.ThumbsUp = count
+		case "THUMBS_DOWN":
+			reactions.ThumbsDown = count
+		case "LAUGH":
+			reactions.Laugh = count
+		case "HOORAY":
+			reactions.Hooray = count
+		case "CONFUSED":
+			reactions.Confused = count
+		case "HEART":
+			reactions.Heart = count
+		case "ROCKET":
+			reactions.Rocket = count
+		case "EYES":
+			reactions.Eyes = count
+		}
+	}
+
+	return model.Comment{
+		Author:      login,
+		AuthorIsBot: isBot(login, userType),
+		Body:        gc.Body,
+		CreatedAt:   gc.CreatedAt,
+		UpdatedAt:   gc.UpdatedAt,
+		Reactions:   reactions,
+		Path:        gc.Path,
+		Line:        line,
+		DiffHunk:    gc.DiffHunk,
+	}
+}
+
+// loginOf returns the login of a GraphQL actor ("ghost" for deleted accounts, as in the REST API)
+func loginOf(actor *graphqlActor) string {
+	if actor == nil {
+		return "ghost"
+	}
+	return actor.Login
+}
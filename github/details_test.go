@@ -0,0 +1,147 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+func TestFetchDetailsBatch(t *testing.T) {
+	client, transport := newReplayClient(t, "details_batch")
+
+	items := []*model.Item{
+		{Type: "Issue", Repository: "octo-org/api", Number: 40, NodeID: "I_kwDOAAABc84AAAAB"},
+		{Type: "PR", Repository: "octo-org/api", Number: 12, NodeID: "PR_kwDOAAABc84AAAAB", State: "closed"},
+		{Type: "PR", Repository: "octo-org/web", Number: 7, NodeID: "PR_kwDOAAABc84AAAAC", State: "open"},
+		{Type: "Issue", Repository: "octo-org/web", Number: 41, NodeID: "I_kwDOAAABc84AAAAD"},
+		{Type: "Issue", Repository: "octo-org/web", Number: 42}, // Read back from a saved report, without a node ID
+	}
+	filled, err := client.FetchDetailsBatch(context.Background(), items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The PR with more comments than one query returns and the node that could not be resolved are left to REST
+	want := []bool{true, true, false, false, false}
+	if fmt.Sprint(filled) != fmt.Sprint(want) {
+		t.Errorf("filled = %v, want %v", filled, want)
+	}
+	if len(transport.requests) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(transport.requests))
+	}
+	if ids := requestedIDs(t, transport.requests[0]); len(ids) != 4 {
+		t.Errorf("requested %v, want the 4 items with a node ID", ids)
+	}
+
+	issue := items[0]
+	if issue.Body != "Exports of more than 10k rows time out." || !issue.Private {
+		t.Errorf("issue body %q, private %v", issue.Body, issue.Private)
+	}
+	if len(issue.Comments) != 2 {
+		t.Fatalf("issue has %d comments, want 2", len(issue.Comments))
+	}
+	if c := issue.Comments[0]; c.Author != "octocat" || c.Reactions.ThumbsUp != 2 || c.Reactions.Eyes != 1 {
+		t.Errorf("first comment = %+v", c)
+	}
+	// GraphQL leaves out the suffix REST adds to bots
+	if c := issue.Comments[1]; c.Author != "renovate[bot]" || !c.AuthorIsBot {
+		t.Errorf("bot comment by %q (bot %v), want renovate[bot]", c.Author, c.AuthorIsBot)
+	}
+
+	pr := items[1]
+	if pr.State != "merged" || pr.MergedAt.IsZero() || pr.Additions != 120 || pr.Deletions != 14 || pr.ChangedFiles != 5 {
+		t.Errorf("PR = state %s, merged at %s, +%d -%d in %d files", pr.State, pr.MergedAt, pr.Additions, pr.Deletions, pr.ChangedFiles)
+	}
+	if pr.HeadRef != "feature/pagination" || pr.HeadSHA != "6dcb09b5b57875f334f61aebed695e2e4193db5e" {
+		t.Errorf("head = %s at %s", pr.HeadRef, pr.HeadSHA)
+	}
+	if len(pr.Reviews) != 1 || pr.Reviews[0].Author != "hubot" || pr.Reviews[0].State != "APPROVED" {
+		t.Errorf("reviews = %+v, want only the submitted approval", pr.Reviews)
+	}
+	// Review comments are listed in the order they were written, as REST lists them
+	if len(pr.Comments) != 2 {
+		t.Fatalf("PR has %d comments, want 2", len(pr.Comments))
+	}
+	if c := pr.Comments[0]; c.Author != "ghost" || c.Line != 7 {
+		t.Errorf("first review comment = %+v, want the deleted user's on line 7", c)
+	}
+	if c := pr.Comments[1]; c.Author != "hubot" || c.Line != 42 || c.Path != "api/items.go" {
+		t.Errorf("second review comment = %+v, want hubot's on the original line 42", c)
+	}
+
+	// Items that were not filled are left as the search found them
+	if items[2].Body != "" || len(items[2].Comments) != 0 || items[2].State != "open" {
+		t.Errorf("unfilled PR was changed: %+v", items[2])
+	}
+}
+
+func TestFetchDetailsBatchChunks(t *testing.T) {
+	client, transport := newReplayClient(t, "details_chunks")
+
+	items := make([]*model.Item, detailBatchSize+1)
+	for i := range items {
+		items[i] = &model.Item{Type: "Issue", Repository: "octo-org/api", Number: i + 1, NodeID: fmt.Sprintf("I_%d", i+1)}
+	}
+	filled, err := client.FetchDetailsBatch(context.Background(), items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, ok := range filled {
+		if ok {
+			t.Errorf("item %d filled from an empty response", i)
+		}
+	}
+
+	if len(transport.requests) != 2 {
+		t.Fatalf("sent %d queries, want 2", len(transport.requests))
+	}
+	if ids := requestedIDs(t, transport.requests[0]); len(ids) != detailBatchSize {
+		t.Errorf("first query asked for %d items, want %d", len(ids), detailBatchSize)
+	}
+	if ids := requestedIDs(t, transport.requests[1]); len(ids) != 1 || ids[0] != "I_51" {
+		t.Errorf("second query asked for %v, want [I_51]", ids)
+	}
+}
+
+func TestFetchDetailsBatchFailure(t *testing.T) {
+	client, _ := newReplayClient(t, "details_unavailable")
+
+	items := []*model.Item{{Type: "Issue", Repository: "octo-org/api", Number: 40, NodeID: "I_kwDOAAABc84AAAAB"}}
+	filled, err := client.FetchDetailsBatch(context.Background(), items)
+	if err == nil {
+		t.Fatal("no error for a failed query")
+	}
+	if len(filled) != 1 || filled[0] {
+		t.Errorf("filled = %v, want [false] so the item is fetched over REST", filled)
+	}
+}
+
+func TestFetchDetailsBatchOffline(t *testing.T) {
+	client, transport := newReplayClient(t, "details_chunks")
+	client.offline = true
+
+	filled, err := client.FetchDetailsBatch(context.Background(), []*model.Item{{Type: "Issue", NodeID: "I_1"}})
+	if err != nil || filled[0] {
+		t.Errorf("got %v, %v; want nothing filled and no error", filled, err)
+	}
+	if len(transport.requests) != 0 {
+		t.Errorf("sent %d queries offline", len(transport.requests))
+	}
+}
+
+// requestedIDs returns the node IDs a details query asked for
+func requestedIDs(t *testing.T, req recordedRequest) []string {
+	t.Helper()
+	var body struct {
+		Variables struct {
+			IDs []string `json:"ids"`
+		} `json:"variables"`
+	}
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		t.Fatalf("query body: %v", err)
+	}
+	return body.Variables.IDs
+}
@@ -0,0 +1,103 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// ErrNotFound は対象が存在しないか、見る権限がないことを表します（HTTP 404）
+var ErrNotFound = errors.New("not found")
+
+// ErrAuth は認証に失敗したか、トークンの権限が足りないことを表します（HTTP 401 と、レート制限以外の 403）
+var ErrAuth = errors.New("authentication failed")
+
+// ErrRateLimited は再試行してもレート制限が解けなかったことを表します
+type ErrRateLimited struct {
+	ResetAt time.Time // When requests are accepted again (zero when GitHub did not say)
+	Err     error     // Error of the last attempt
+}
+
+func (e *ErrRateLimited) Error() string {
+	if e.ResetAt.IsZero() {
+		return fmt.Sprintf("rate limited: %v", e.Err)
+	}
+	return fmt.Sprintf("rate limited until %s: %v", e.ResetAt.Format("15:04:05"), e.Err)
+}
+
+func (e *ErrRateLimited) Unwrap() error {
+	return e.Err
+}
+
+// ItemRef は取得に失敗した項目とその原因です
+type ItemRef struct {
+	Type       string // "Issue" or "PR"
+	Repository string
+	Number     int
+	Err        error
+}
+
+// ErrPartial は一部の項目の詳細を取得できなかったことを表します
+// 項目そのものは検索結果の内容でレポートに含まれています
+type ErrPartial struct {
+	Failed []ItemRef
+}
+
+func (e *ErrPartial) Error() string {
+	if len(e.Failed) == 1 {
+		failed := e.Failed[0]
+		return fmt.Sprintf("failed to retrieve details for %s %s#%d: %v", failed.Type, failed.Repository, failed.Number, failed.Err)
+	}
+	return fmt.Sprintf("failed to retrieve details for %d items", len(e.Failed))
+}
+
+// apiError は API のエラーに ErrNotFound や ErrAuth を付けたものです
+type apiError struct {
+	kind error
+	err  error
+}
+
+func (e *apiError) Error() string {
+	return e.err.Error()
+}
+
+func (e *apiError) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *apiError) Unwrap() error {
+	return e.err
+}
+
+// classifyError は API のエラーを原因に応じた型に変換します（api.HTTPError は errors.As で引き続き取り出せます）
+func classifyError(err error) error {
+	var httpErr *api.HTTPError
+	if !errors.As(err, &httpErr) {
+		return err
+	}
+
+	switch {
+	case httpErr.StatusCode == http.StatusTooManyRequests || (httpErr.StatusCode == http.StatusForbidden && isRateLimited(httpErr)):
+		return &ErrRateLimited{ResetAt: rateLimitReset(httpErr.Headers), Err: err}
+	case httpErr.StatusCode == http.StatusUnauthorized || httpErr.StatusCode == http.StatusForbidden:
+		return &apiError{kind: ErrAuth, err: err}
+	case httpErr.StatusCode == http.StatusNotFound:
+		return &apiError{kind: ErrNotFound, err: err}
+	}
+	return err
+}
+
+// rateLimitReset は Retry-After または X-RateLimit-Reset からレート制限が解ける時刻を返します
+func rateLimitReset(header http.Header) time.Time {
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		return time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		return time.Unix(reset, 0)
+	}
+	return time.Time{}
+}
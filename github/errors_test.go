@@ -0,0 +1,100 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		repo        string
+		notFound    bool
+		auth        bool
+		rateLimited bool
+		resetAt     time.Time // Expected ResetAt of a rate limit (zero = not checked)
+	}{
+		{repo: "missing", notFound: true},
+		{repo: "bad-token", auth: true},
+		{repo: "saml", auth: true},
+		{repo: "quota", rateLimited: true, resetAt: time.Unix(1709290800, 0)},
+		{repo: "secondary", rateLimited: true},
+		{repo: "unprocessable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.repo, func(t *testing.T) {
+			client, _ := newReplayClient(t, "errors")
+
+			var response struct{}
+			err := client.get(context.Background(), "repos/octo-org/"+tt.repo, &response)
+			if err == nil {
+				t.Fatal("no error")
+			}
+			if got := errors.Is(err, ErrNotFound); got != tt.notFound {
+				t.Errorf("errors.Is(err, ErrNotFound) = %v, want %v (%v)", got, tt.notFound, err)
+			}
+			if got := errors.Is(err, ErrAuth); got != tt.auth {
+				t.Errorf("errors.Is(err, ErrAuth) = %v, want %v (%v)", got, tt.auth, err)
+			}
+			var rateLimited *ErrRateLimited
+			if got := errors.As(err, &rateLimited); got != tt.rateLimited {
+				t.Fatalf("errors.As(err, *ErrRateLimited) = %v, want %v (%v)", got, tt.rateLimited, err)
+			}
+			if rateLimited != nil && !tt.resetAt.IsZero() && !rateLimited.ResetAt.Equal(tt.resetAt) {
+				t.Errorf("ResetAt = %s, want %s", rateLimited.ResetAt, tt.resetAt)
+			}
+
+			// The HTTP error stays available to callers that need the status code
+			var httpErr *api.HTTPError
+			if !errors.As(err, &httpErr) {
+				t.Errorf("the *api.HTTPError is no longer reachable from %v", err)
+			}
+		})
+	}
+}
+
+func TestClassifyErrorRetryAfter(t *testing.T) {
+	client, _ := newReplayClient(t, "errors")
+
+	var response struct{}
+	before := time.Now()
+	err := client.get(context.Background(), "repos/octo-org/secondary", &response)
+	var rateLimited *ErrRateLimited
+	if !errors.As(err, &rateLimited) {
+		t.Fatalf("got %v, want *ErrRateLimited", err)
+	}
+	// Retry-After: 60 counts from when the response arrived
+	if wait := rateLimited.ResetAt.Sub(before); wait < 59*time.Second || wait > 61*time.Second {
+		t.Errorf("ResetAt is %s after the request, want about 60s", wait)
+	}
+}
+
+func TestRetryReportsLongRateLimit(t *testing.T) {
+	client, transport := newReplayClient(t, "errors")
+	client.MaxRetries = 3
+
+	// An hour-long wait is reported right away instead of sleeping through it
+	var response struct{}
+	start := time.Now()
+	err := client.get(context.Background(), "repos/octo-org/long-wait", &response)
+	var rateLimited *ErrRateLimited
+	if !errors.As(err, &rateLimited) {
+		t.Fatalf("got %v, want *ErrRateLimited", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("waited %s before reporting the rate limit", elapsed)
+	}
+	if len(transport.requests) != 1 {
+		t.Errorf("sent %d requests, want 1", len(transport.requests))
+	}
+}
+
+func TestClassifyErrorLeavesOtherErrors(t *testing.T) {
+	err := errors.New("connection reset by peer")
+	if got := classifyError(err); got != err {
+		t.Errorf("classifyError(%v) = %v, want the error unchanged", err, got)
+	}
+}
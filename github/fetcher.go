@@ -0,0 +1,100 @@
+package github
+
+import (
+	"context"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// FetchOptions は取得するユーザー・期間と、その取得に適用するフィルタや制限です
+// 同じ Client を条件の異なる取得に使えるように、条件は Client ではなく取得ごとに渡します
+type FetchOptions struct {
+	// Username is the user whose activity is fetched
+	Username string
+
+	// Involvement is how the user is involved in the searched Issues and PRs:
+	// "created", "assigned", "commented", "reviewed" or "mentioned" (ignored by the other fetches)
+	Involvement string
+
+	// DateRange is the period to fetch
+	DateRange model.DateRange
+
+	// DateFields are the dates of Issues and PRs that must fall in the period, "created" and/or "closed"
+	// (empty = "created", plus "closed" when ClosedInRange is set)
+	DateFields []string
+
+	// ClosedInRange also includes items closed during the period even if they were created earlier
+	ClosedInRange bool
+
+	// UpdatedSince restricts searches to items updated at or after this time (zero = no restriction)
+	UpdatedSince time.Time
+
+	// Repos restricts all searches to these repositories (owner/name)
+	Repos []string
+
+	// ExcludeRepos drops repositories matching these patterns (owner/name, glob supported)
+	ExcludeRepos []string
+
+	// Orgs restricts all searches to repositories owned by these organizations or users
+	Orgs []string
+
+	// Visibility restricts results to "public" or "private" repositories (empty or "all" = both)
+	Visibility string
+
+	// ResolveVisibility looks up whether the repository of every search result is private even without a Visibility filter,
+	// for callers that must know it for every item (the repository of a search result is not looked up otherwise)
+	ResolveVisibility bool
+
+	// MaxPages limits the search result pages fetched per query (0 = unlimited)
+	MaxPages int
+}
+
+// dateFields returns the dates of Issues and PRs searched in the period
+func (opts FetchOptions) dateFields() []string {
+	if len(opts.DateFields) > 0 {
+		return opts.DateFields
+	}
+	if opts.ClosedInRange {
+		return []string{"created", "closed"}
+	}
+	return []string{"created"}
+}
+
+// Fetcher はレポートの収集で GitHub から取得するものをまとめたインターフェースです
+// *Client が実装しています。テストや組み込み先では、記録した応答を返す実装に差し替えられます
+type Fetcher interface {
+	// GetUsername returns the login of the authenticated user
+	GetUsername(ctx context.Context) (string, error)
+
+	// Searches; the boolean reports whether the results were truncated
+	FetchIssues(ctx context.Context, opts FetchOptions) ([]model.Item, bool, error)
+	FetchPRs(ctx context.Context, opts FetchOptions) ([]model.Item, bool, error)
+	FetchCommits(ctx context.Context, opts FetchOptions) ([]model.Commit, bool, error)
+
+	// Details filled into an item found by a search
+	FetchIssueDetails(ctx context.Context, item *model.Item) error
+	FetchPRDetails(ctx context.Context, item *model.Item) error
+	FetchPRCommits(ctx context.Context, item *model.Item) error
+	FetchChecks(ctx context.Context, item *model.Item) error
+	FetchTimeline(ctx context.Context, item *model.Item, dateRange model.DateRange) error
+	FetchProjectItems(ctx context.Context, item *model.Item) error
+
+	// Activity outside Issues and PRs
+	FetchCreatedRepos(ctx context.Context, opts FetchOptions) ([]model.Repository, error)
+	FetchWorkflowRuns(ctx context.Context, repo string, opts FetchOptions) ([]model.WorkflowRun, error)
+	FetchGists(ctx context.Context, opts FetchOptions) ([]model.Item, error)
+}
+
+// BatchFetcher は多数の項目の詳細をまとめて取得できる Fetcher です
+// pric.FetchItems は Fetcher がこれを実装していれば先に使い、埋められなかった項目だけを 1 件ずつ取得します
+type BatchFetcher interface {
+	// FetchDetailsBatch fills what FetchIssueDetails and FetchPRDetails would into as many items as it can
+	// and reports which ones it filled
+	FetchDetailsBatch(ctx context.Context, items []*model.Item) ([]bool, error)
+}
+
+var (
+	_ Fetcher      = (*Client)(nil)
+	_ BatchFetcher = (*Client)(nil)
+)
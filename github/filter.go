@@ -0,0 +1,130 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// scopeQualifiers はリポジトリ指定などの検索修飾子を返します（先頭に "+" 付き）
+func (opts FetchOptions) scopeQualifiers() string {
+	var qualifiers strings.Builder
+	for _, repo := range opts.Repos {
+		fmt.Fprintf(&qualifiers, "+repo:%s", repo)
+	}
+	for _, org := range opts.Orgs {
+		fmt.Fprintf(&qualifiers, "+org:%s", org)
+	}
+	if opts.Visibility == "public" || opts.Visibility == "private" {
+		fmt.Fprintf(&qualifiers, "+is:%s", opts.Visibility)
+	}
+	for _, pattern := range opts.ExcludeRepos {
+		// Glob patterns cannot be expressed in a query and are only applied to the results
+		if !strings.ContainsAny(pattern, "*?[") {
+			fmt.Fprintf(&qualifiers, "+-repo:%s", pattern)
+		}
+	}
+	return qualifiers.String()
+}
+
+// repoAllowed はリポジトリがフィルタ条件を満たすかどうかを返します
+func (opts FetchOptions) repoAllowed(repo string) bool {
+	for _, pattern := range opts.ExcludeRepos {
+		if matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(repo)); matched {
+			return false
+		}
+	}
+
+	if len(opts.Orgs) > 0 {
+		owner, _, _ := strings.Cut(repo, "/")
+		found := false
+		for _, org := range opts.Orgs {
+			if strings.EqualFold(org, owner) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(opts.Repos) == 0 {
+		return true
+	}
+	for _, r := range opts.Repos {
+		if strings.EqualFold(r, repo) {
+			return true
+		}
+	}
+	return false
+}
+
+// visibilityAllowed はリポジトリの公開範囲がフィルタ条件を満たすかどうかを返します
+func (opts FetchOptions) visibilityAllowed(private bool) bool {
+	switch opts.Visibility {
+	case "public":
+		return !private
+	case "private":
+		return private
+	default:
+		return true
+	}
+}
+
+// repoVisibility is the result of looking up whether a repository is private
+type repoVisibility struct {
+	private bool
+	err     error // Why the visibility is unknown (nil = known)
+}
+
+// isPrivateRepo はリポジトリが非公開かどうかを取得します（結果は失敗も含めてリポジトリごとに記憶します）
+// 削除されたリポジトリや SSO で保護された組織のリポジトリなど、取得できない場合はエラーを返します
+func (c *Client) isPrivateRepo(ctx context.Context, repo string) (bool, error) {
+	c.mu.Lock()
+	visibility, ok := c.privateRepos[repo]
+	c.mu.Unlock()
+	if ok {
+		return visibility.private, visibility.err
+	}
+
+	var repoInfo struct {
+		Private bool `json:"private"`
+	}
+	err := c.get(ctx, fmt.Sprintf("repos/%s", repo), &repoInfo)
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	if err != nil {
+		err = fmt.Errorf("Failed to retrieve repository %s: %w", repo, err)
+	}
+
+	c.mu.Lock()
+	c.privateRepos[repo] = repoVisibility{private: repoInfo.Private, err: err}
+	c.mu.Unlock()
+	return repoInfo.Private, err
+}
+
+// resultVisibility は検索結果のリポジトリが非公開かどうかを返します
+// --visibility の検索修飾子（is:public / is:private）で結果はすでに絞られているので、確認できなかったリポジトリは修飾子どおりとみなします
+// ResolveVisibility だけが有効な場合、確認できなかったリポジトリは名前を伏せられるように非公開とみなします
+func (c *Client) resultVisibility(ctx context.Context, repo string, opts FetchOptions) (bool, error) {
+	filtered := opts.Visibility == "public" || opts.Visibility == "private"
+	if !filtered && !opts.ResolveVisibility {
+		// Nothing depends on it; the details fill it in where the API reports it
+		return false, nil
+	}
+
+	private, err := c.isPrivateRepo(ctx, repo)
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	if err != nil {
+		if filtered {
+			return opts.Visibility == "private", nil
+		}
+		return true, nil
+	}
+	return private, nil
+}
@@ -0,0 +1,76 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// FetchGists は期間内に作成または更新された Gist を取得します
+// 他のユーザーについては公開 Gist のみが取得できます
+func (c *Client) FetchGists(ctx context.Context, opts FetchOptions) ([]model.Item, error) {
+	var gists []struct {
+		URL         string    `json:"html_url"`
+		Description string    `json:"description"`
+		Public      bool      `json:"public"`
+		CreatedAt   time.Time `json:"created_at"`
+		UpdatedAt   time.Time `json:"updated_at"`
+		Owner       struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+		Files map[string]struct {
+			Filename string `json:"filename"`
+		} `json:"files"`
+	}
+
+	var items []model.Item
+	gistsURL := fmt.Sprintf("users/%s/gists?since=%s&per_page=100", opts.Username, formatSearchTime(opts.DateRange.StartDate))
+	err := c.getPages(ctx, gistsURL, &gists, func() bool {
+		for _, gist := range gists {
+			// since only bounds the update date from below, and later updates hide earlier ones
+			if gist.CreatedAt.After(opts.DateRange.EndDate) || (gist.CreatedAt.Before(opts.DateRange.StartDate) && gist.UpdatedAt.After(opts.DateRange.EndDate)) {
+				continue
+			}
+			if !opts.visibilityAllowed(!gist.Public) {
+				continue
+			}
+
+			var files []string
+			for _, file := range gist.Files {
+				files = append(files, file.Filename)
+			}
+			sort.Strings(files)
+
+			title := gist.Description
+			if title == "" && len(files) > 0 {
+				title = files[0]
+			}
+			state := "public"
+			if !gist.Public {
+				state = "secret"
+			}
+
+			items = append(items, model.Item{
+				Type:         "Gist",
+				Title:        title,
+				URL:          gist.URL,
+				State:        state,
+				CreatedAt:    gist.CreatedAt,
+				UpdatedAt:    gist.UpdatedAt,
+				Author:       gist.Owner.Login,
+				Private:      !gist.Public,
+				Involvements: []string{"created"},
+				Files:        files,
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to retrieve gists: %w", err)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,200 @@
+package googleauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Token endpoints of Google
+const (
+	googleTokenURL = "https://oauth2.googleapis.com/token"
+	gceTokenURL    = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// Struct to hold a credentials file (a service account key or the application default credentials of gcloud)
+type credentials struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// AccessToken はアプリケーションのデフォルト認証情報と同じ順序で scope のアクセストークンを取得します
+// GOOGLE_APPLICATION_CREDENTIALS のファイル、gcloud auth application-default login のファイル、
+// Compute Engine などのメタデータサーバーの順に探します
+// gcloud のユーザー認証情報とメタデータサーバーのトークンのスコープは、ログイン時やインスタンスの設定で決まります
+func AccessToken(ctx context.Context, client *http.Client, scope string) (string, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		if candidate := gcloudCredentialsPath(); candidate != "" {
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+			}
+		}
+	}
+
+	if path == "" {
+		token, err := metadataAccessToken(ctx, client)
+		if err != nil {
+			return "", fmt.Errorf("no Google Cloud credentials found (set GOOGLE_APPLICATION_CREDENTIALS or run gcloud auth application-default login): %w", err)
+		}
+		return token, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var creds credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+
+	form := url.Values{}
+	tokenURL := googleTokenURL
+	switch creds.Type {
+	case "service_account":
+		assertion, err := serviceAccountAssertion(creds, scope, time.Now())
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", path, err)
+		}
+		if creds.TokenURI != "" {
+			tokenURL = creds.TokenURI
+		}
+		form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+		form.Set("assertion", assertion)
+	case "authorized_user":
+		form.Set("grant_type", "refresh_token")
+		form.Set("client_id", creds.ClientID)
+		form.Set("client_secret", creds.ClientSecret)
+		form.Set("refresh_token", creds.RefreshToken)
+	default:
+		return "", fmt.Errorf("%s: unsupported credentials type %q", path, creds.Type)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return requestAccessToken(client, req)
+}
+
+// メタデータサーバーからインスタンスのサービスアカウントのトークンを取得する
+func metadataAccessToken(ctx context.Context, client *http.Client) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gceTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	return requestAccessToken(client, req)
+}
+
+// トークンのリクエストを送り、access_token を返す
+func requestAccessToken(client *http.Client, req *http.Request) (string, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode/100 != 2 {
+		message := strings.TrimSpace(string(body))
+		if len(message) > 300 {
+			message = message[:300] + "..."
+		}
+		return "", fmt.Errorf("Google OAuth returned %s: %s", resp.Status, message)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("the token response has no access token")
+	}
+	return token.AccessToken, nil
+}
+
+// サービスアカウントの秘密鍵で署名した JWT（トークン交換用）を作る
+func serviceAccountAssertion(creds credentials, scope string, now time.Time) (string, error) {
+	block, _ := pem.Decode([]byte(creds.PrivateKey))
+	if block == nil {
+		return "", errors.New("the private key is not PEM encoded")
+	}
+	var key *rsa.PrivateKey
+	if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return "", errors.New("the private key is not an RSA key")
+		}
+		key = rsaKey
+	} else if rsaKey, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		key = rsaKey
+	} else {
+		return "", fmt.Errorf("invalid private key: %w", err)
+	}
+
+	aud := creds.TokenURI
+	if aud == "" {
+		aud = googleTokenURL
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   creds.ClientEmail,
+		"scope": scope,
+		"aud":   aud,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// gcloud auth application-default login が書き出す認証情報ファイルのパスを返す
+func gcloudCredentialsPath() string {
+	if dir := os.Getenv("CLOUDSDK_CONFIG"); dir != "" {
+		return filepath.Join(dir, "application_default_credentials.json")
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "gcloud", "application_default_credentials.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gcloud", "application_default_credentials.json")
+}
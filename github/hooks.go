@@ -0,0 +1,95 @@
+package github
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Hooks は API リクエストの送信前・レスポンスの受信後・再試行の前に呼ばれる関数です
+// ログやメトリクス、独自のキャッシュを組み込むのに使います。使わない関数は nil のままにします
+// レスポンスキャッシュ（ClientOptions.CacheDir）から返されたリクエストでは呼ばれません
+type Hooks struct {
+	// BeforeRequest is called before a request is sent to the network
+	// Returning a response serves the request with it instead; returning an error fails the request
+	BeforeRequest func(req *http.Request) (*http.Response, error)
+
+	// AfterResponse is called with the response or the error of every request and how long it took,
+	// including any wait for the rate limit quota
+	AfterResponse func(req *http.Request, resp *http.Response, err error, elapsed time.Duration)
+
+	// OnRetry is called before waiting to make the given attempt (2 or more) of a failed request
+	OnRetry func(attempt, maxAttempts int, wait time.Duration, err error)
+}
+
+// hookSet is the list of hooks shared by a client and its transport
+type hookSet struct {
+	mu    sync.RWMutex
+	hooks []Hooks
+}
+
+// add appends hooks called after the ones added before
+func (s *hookSet) add(hooks Hooks) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, hooks)
+}
+
+// list returns the hooks in the order they were added
+func (s *hookSet) list() []Hooks {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hooks
+}
+
+// AddHooks はクライアントにフックを追加します（先に追加されたものから順に呼ばれます）
+func (c *Client) AddHooks(hooks Hooks) {
+	c.hooks.add(hooks)
+}
+
+// retrying calls the OnRetry hooks
+func (c *Client) retrying(attempt, maxAttempts int, wait time.Duration, err error) {
+	for _, hooks := range c.hooks.list() {
+		if hooks.OnRetry != nil {
+			hooks.OnRetry(attempt, maxAttempts, wait, err)
+		}
+	}
+}
+
+// hookTransport はネットワークに送るリクエストの前後でフックを呼び出します
+type hookTransport struct {
+	base  http.RoundTripper
+	hooks *hookSet
+}
+
+// RoundTrip はリクエストを送信し、その前後にフックを呼び出します
+func (t *hookTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	hooks := t.hooks.list()
+
+	start := time.Now()
+	var resp *http.Response
+	var err error
+	for _, h := range hooks {
+		if h.BeforeRequest == nil {
+			continue
+		}
+		if resp, err = h.BeforeRequest(req); resp != nil || err != nil {
+			break
+		}
+	}
+	if resp == nil && err == nil {
+		resp, err = base.RoundTrip(req)
+	}
+	elapsed := time.Since(start)
+
+	for _, h := range hooks {
+		if h.AfterResponse != nil {
+			h.AfterResponse(req, resp, err, elapsed)
+		}
+	}
+	return resp, err
+}
@@ -0,0 +1,276 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// Item type of Jira issues in the report
+const ItemType = "Jira"
+
+// Involvement of issues whose status the user changed
+const Transitioned = "transitioned"
+
+// Issues fetched per search request
+const pageSize = 50
+
+// Layout of Jira timestamps (e.g. 2024-03-01T09:30:00.000+0900)
+const timeLayout = "2006-01-02T15:04:05.000-0700"
+
+// Client は Jira（Cloud または Data Center）から、認証したユーザーが期間中に
+// ステータスを変更したりコメントしたりした課題を取得するクライアントです
+type Client struct {
+	BaseURL    string       // Site URL (e.g. https://example.atlassian.net)
+	User       string       // Email address (Cloud) or username for basic auth; empty = Token is a personal access token
+	Token      string       // API token (Cloud) or personal access token (Data Center)
+	HTTPClient *http.Client // HTTP client (a client with a 1 minute timeout when nil)
+}
+
+// Jira user as returned by the API; Cloud identifies users by accountId, Data Center by name
+type user struct {
+	AccountID   string `json:"accountId"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// same reports whether u is the same user as other
+func (u user) same(other user) bool {
+	if u.AccountID != "" {
+		return u.AccountID == other.AccountID
+	}
+	return u.Name != "" && u.Name == other.Name
+}
+
+// Issue as returned by the search API with the changelog expanded
+type issue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary     string   `json:"summary"`
+		Description string   `json:"description"`
+		Created     string   `json:"created"`
+		Updated     string   `json:"updated"`
+		Labels      []string `json:"labels"`
+		Reporter    *user    `json:"reporter"`
+		Assignee    *user    `json:"assignee"`
+		Status      struct {
+			Name           string `json:"name"`
+			StatusCategory struct {
+				Key string `json:"key"`
+			} `json:"statusCategory"`
+		} `json:"status"`
+		Project struct {
+			Key string `json:"key"`
+		} `json:"project"`
+		Comment struct {
+			Comments []struct {
+				Author  user   `json:"author"`
+				Body    string `json:"body"`
+				Created string `json:"created"`
+				Updated string `json:"updated"`
+			} `json:"comments"`
+		} `json:"comment"`
+	} `json:"fields"`
+	Changelog struct {
+		Histories []struct {
+			Author  user   `json:"author"`
+			Created string `json:"created"`
+			Items   []struct {
+				Field      string `json:"field"`
+				FromString string `json:"fromString"`
+				ToString   string `json:"toString"`
+			} `json:"items"`
+		} `json:"histories"`
+	} `json:"changelog"`
+}
+
+// FetchActivity は期間中に自分がステータスを変更した課題とコメントした課題を、レポートのアイテムとして返します
+// 課題は Type が "Jira"、Repository がプロジェクトキー、Number がキーの番号になります
+func (c *Client) FetchActivity(ctx context.Context, dateRange model.DateRange) ([]model.Item, error) {
+	var me user
+	if err := c.get(ctx, "myself", &me); err != nil {
+		return nil, fmt.Errorf("failed to read the Jira user: %w", err)
+	}
+
+	// Commenting watches the issue by default, so watched issues cover the comments.
+	// The JQL dates are days in the time zone of the Jira user; the exact period is checked below
+	from := dateRange.StartDate.Format("2006-01-02")
+	to := dateRange.EndDate.AddDate(0, 0, 1).Format("2006-01-02")
+	jql := fmt.Sprintf(`updated >= "%s" AND (status CHANGED BY currentUser() DURING ("%s", "%s") OR watcher = currentUser() OR assignee = currentUser() OR reporter = currentUser()) ORDER BY updated DESC`,
+		from, from, to)
+
+	var items []model.Item
+	query := url.Values{}
+	query.Set("jql", jql)
+	query.Set("fields", "summary,description,created,updated,labels,reporter,assignee,status,project,comment")
+	query.Set("expand", "changelog")
+	query.Set("maxResults", strconv.Itoa(pageSize))
+	for startAt := 0; ; startAt += pageSize {
+		// Jira Cloud pages its search with a token; Data Center only has the offset-based search
+		path := "search?"
+		if c.isCloud() {
+			path = "search/jql?"
+		} else {
+			query.Set("startAt", strconv.Itoa(startAt))
+		}
+		var page struct {
+			Total         int     `json:"total"`
+			Issues        []issue `json:"issues"`
+			NextPageToken string  `json:"nextPageToken"`
+		}
+		if err := c.get(ctx, path+query.Encode(), &page); err != nil {
+			return nil, fmt.Errorf("failed to search Jira issues: %w", err)
+		}
+		for _, is := range page.Issues {
+			if item, ok := c.item(is, me, dateRange); ok {
+				items = append(items, item)
+			}
+		}
+		if c.isCloud() {
+			if page.NextPageToken == "" {
+				return items, nil
+			}
+			query.Set("nextPageToken", page.NextPageToken)
+			continue
+		}
+		if len(page.Issues) < pageSize || startAt+len(page.Issues) >= page.Total {
+			return items, nil
+		}
+	}
+}
+
+// isCloud reports whether the site is Jira Cloud
+func (c *Client) isCloud() bool {
+	u, err := url.Parse(c.BaseURL)
+	return err == nil && strings.HasSuffix(u.Hostname(), ".atlassian.net")
+}
+
+// 課題をアイテムに変換する（期間中に自分のステータス変更もコメントもなければ false）
+func (c *Client) item(is issue, me user, dateRange model.DateRange) (model.Item, bool) {
+	inRange := func(t time.Time) bool {
+		return !t.Before(dateRange.StartDate) && !t.After(dateRange.EndDate)
+	}
+
+	item := model.Item{
+		Type:       ItemType,
+		Title:      is.Fields.Summary,
+		URL:        strings.TrimSuffix(c.BaseURL, "/") + "/browse/" + is.Key,
+		State:      "open",
+		CreatedAt:  parseTime(is.Fields.Created),
+		UpdatedAt:  parseTime(is.Fields.Updated),
+		Labels:     is.Fields.Labels,
+		Repository: is.Fields.Project.Key,
+		Body:       is.Fields.Description,
+	}
+	if _, number, ok := strings.Cut(is.Key, "-"); ok {
+		item.Number, _ = strconv.Atoi(number)
+	}
+	if is.Fields.Status.StatusCategory.Key == "done" {
+		item.State = "closed"
+	}
+	if is.Fields.Reporter != nil {
+		item.Author = is.Fields.Reporter.DisplayName
+	}
+	if is.Fields.Assignee != nil {
+		item.Assignees = []string{is.Fields.Assignee.DisplayName}
+	}
+
+	for _, history := range is.Changelog.Histories {
+		createdAt := parseTime(history.Created)
+		if !inRange(createdAt) {
+			continue
+		}
+		for _, change := range history.Items {
+			if change.Field != "status" {
+				continue
+			}
+			item.Events = append(item.Events, model.Event{
+				Type:      Transitioned,
+				Actor:     history.Author.DisplayName,
+				Detail:    change.FromString + " → " + change.ToString,
+				CreatedAt: createdAt,
+			})
+			if history.Author.same(me) && !item.HasInvolvement(Transitioned) {
+				item.Involvements = append(item.Involvements, Transitioned)
+			}
+			if item.State == "closed" && !item.ClosedAt.After(createdAt) {
+				item.ClosedAt = createdAt
+			}
+		}
+	}
+
+	for _, comment := range is.Fields.Comment.Comments {
+		createdAt := parseTime(comment.Created)
+		if !inRange(createdAt) {
+			continue
+		}
+		item.Comments = append(item.Comments, model.Comment{
+			Author:    comment.Author.DisplayName,
+			Body:      comment.Body,
+			CreatedAt: createdAt,
+			UpdatedAt: parseTime(comment.Updated),
+		})
+		if comment.Author.same(me) && !item.HasInvolvement("commented") {
+			item.Involvements = append(item.Involvements, "commented")
+		}
+	}
+
+	return item, len(item.Involvements) > 0
+}
+
+// REST API（v2）から JSON を読み込む
+func (c *Client) get(ctx context.Context, path string, response interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.BaseURL, "/")+"/rest/api/2/"+path, nil)
+	if err != nil {
+		return err
+	}
+	if c.User != "" {
+		req.SetBasicAuth(c.User, c.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := c.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: time.Minute}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			ErrorMessages []string `json:"errorMessages"`
+		}
+		json.Unmarshal(body, &apiErr)
+		if len(apiErr.ErrorMessages) > 0 {
+			return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.Join(apiErr.ErrorMessages, "; "))
+		}
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, response)
+}
+
+// Jira の日時を解析する（解析できなければゼロ値）
+func parseTime(s string) time.Time {
+	t, err := time.Parse(timeLayout, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,139 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// Defaults for the OpenAI-compatible API
+const (
+	DefaultEndpoint = "https://api.openai.com/v1"
+	DefaultModel    = "gpt-4o-mini"
+
+	maxPromptItems = 200 // Items listed in the prompt at most
+	maxBodyLength  = 300 // Characters of each body included in the prompt
+)
+
+// Instructions given to the model as the system message
+const systemPrompt = `You summarize a developer's GitHub activity for a weekly report.
+Write 3 to 6 sentences of plain prose in the language most of the titles are written in.
+Mention the main themes, what was shipped (merged PRs), what is still in progress and notable reviews or discussions.
+Do not invent anything that is not in the list, and do not use headings or bullet points.`
+
+// Client は OpenAI 互換の Chat Completions API でレポートの要約を生成するクライアントです
+type Client struct {
+	Endpoint   string       // Base URL of the API (e.g. https://api.openai.com/v1)
+	APIKey     string       // Sent as a Bearer token (may be empty for local servers)
+	Model      string       // Model name
+	HTTPClient *http.Client // HTTP client (a client with a 2 minute timeout when nil)
+}
+
+// Summarize はレポートのアイテムとコミットを要約した文章を返します
+func (c *Client) Summarize(ctx context.Context, report model.Report) (string, error) {
+	request := map[string]interface{}{
+		"model": c.Model,
+		"messages": []map[string]string{
+			{"role": "system", "content": systemPrompt},
+			{"role": "user", "content": prompt(report)},
+		},
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(c.Endpoint, "/")+"/chat/completions", bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 2 * time.Minute}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	json.Unmarshal(body, &response)
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, response.Error.Message)
+	}
+	if len(response.Choices) == 0 || strings.TrimSpace(response.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("the response contains no summary")
+	}
+	return strings.TrimSpace(response.Choices[0].Message.Content), nil
+}
+
+// レポートの内容をモデルに渡す一覧にする
+func prompt(report model.Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Activity of %s from %s to %s:\n\n", report.Username,
+		report.DateRange.StartDate.Format("2006-01-02"),
+		report.DateRange.EndDate.Format("2006-01-02"))
+
+	for i, item := range report.Items {
+		if i == maxPromptItems {
+			fmt.Fprintf(&b, "- ...and %d more items\n", len(report.Items)-maxPromptItems)
+			break
+		}
+		fmt.Fprintf(&b, "- [%s %s] %s#%d %s (involvement: %s)\n", item.Type, item.State, item.Repository, item.Number,
+			item.Title, strings.Join(item.Involvements, ", "))
+		if body := strings.Join(strings.Fields(item.Body), " "); body != "" {
+			if runes := []rune(body); len(runes) > maxBodyLength {
+				body = string(runes[:maxBodyLength]) + "..."
+			}
+			fmt.Fprintf(&b, "  %s\n", body)
+		}
+	}
+
+	if len(report.Commits) > 0 {
+		fmt.Fprintf(&b, "\nCommits: %d", len(report.Commits))
+		repos := make(map[string]int)
+		var names []string
+		for _, commit := range report.Commits {
+			if repos[commit.Repository] == 0 {
+				names = append(names, commit.Repository)
+			}
+			repos[commit.Repository]++
+		}
+		for i, name := range names {
+			if i == 0 {
+				b.WriteString(" (")
+			} else {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "%s: %d", name, repos[name])
+		}
+		b.WriteString(")\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,104 @@
+package model
+
+import "time"
+
+// In returns a copy of the report with every time converted to loc, so dates are displayed in that time zone
+func (r Report) In(loc *time.Location) Report {
+	r.DateRange = DateRange{StartDate: r.DateRange.StartDate.In(loc), EndDate: r.DateRange.EndDate.In(loc)}
+
+	items := make([]Item, len(r.Items))
+	for i, item := range r.Items {
+		items[i] = item.In(loc)
+	}
+	r.Items = items
+
+	r.Commits = commitsIn(r.Commits, loc)
+
+	repos := make([]Repository, len(r.Repositories))
+	for i, repo := range r.Repositories {
+		repo.CreatedAt = repo.CreatedAt.In(loc)
+		repos[i] = repo
+	}
+	r.Repositories = repos
+
+	runs := make([]WorkflowRun, len(r.WorkflowRuns))
+	for i, run := range r.WorkflowRuns {
+		run.CreatedAt = run.CreatedAt.In(loc)
+		runs[i] = run
+	}
+	r.WorkflowRuns = runs
+
+	// nil stands for a report without a calendar, so it is kept as is
+	if r.Meetings != nil {
+		meetings := make([]Meeting, len(r.Meetings))
+		for i, meeting := range r.Meetings {
+			meeting.Start = meeting.Start.In(loc)
+			meeting.End = meeting.End.In(loc)
+			meetings[i] = meeting
+		}
+		r.Meetings = meetings
+	}
+
+	if r.Previous != nil {
+		previous := r.Previous.In(loc)
+		r.Previous = &previous
+	}
+	return r
+}
+
+// In returns a copy of the item with every time converted to loc
+func (i Item) In(loc *time.Location) Item {
+	i.CreatedAt = i.CreatedAt.In(loc)
+	i.UpdatedAt = i.UpdatedAt.In(loc)
+	i.ClosedAt = i.ClosedAt.In(loc)
+	i.MergedAt = i.MergedAt.In(loc)
+
+	comments := make([]Comment, len(i.Comments))
+	for j, comment := range i.Comments {
+		comment.CreatedAt = comment.CreatedAt.In(loc)
+		comment.UpdatedAt = comment.UpdatedAt.In(loc)
+		comments[j] = comment
+	}
+	i.Comments = comments
+
+	reviews := make([]Review, len(i.Reviews))
+	for j, review := range i.Reviews {
+		review.SubmittedAt = review.SubmittedAt.In(loc)
+		reviews[j] = review
+	}
+	i.Reviews = reviews
+
+	requests := make([]ReviewRequest, len(i.ReviewRequests))
+	for j, request := range i.ReviewRequests {
+		request.RequestedAt = request.RequestedAt.In(loc)
+		requests[j] = request
+	}
+	i.ReviewRequests = requests
+
+	events := make([]Event, len(i.Events))
+	for j, event := range i.Events {
+		event.CreatedAt = event.CreatedAt.In(loc)
+		events[j] = event
+	}
+	i.Events = events
+
+	references := make([]Reference, len(i.ReferencedBy))
+	for j, ref := range i.ReferencedBy {
+		ref.ReferencedAt = ref.ReferencedAt.In(loc)
+		references[j] = ref
+	}
+	i.ReferencedBy = references
+
+	i.Commits = commitsIn(i.Commits, loc)
+	return i
+}
+
+// commitsIn returns a copy of the commits with the author dates converted to loc
+func commitsIn(commits []Commit, loc *time.Location) []Commit {
+	converted := make([]Commit, len(commits))
+	for i, commit := range commits {
+		commit.AuthoredAt = commit.AuthoredAt.In(loc)
+		converted[i] = commit
+	}
+	return converted
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -10,28 +12,216 @@ type DateRange struct {
 	EndDate   time.Time
 }
 
+// Struct to hold everything needed to render a report
+type Report struct {
+	Username     string        // User the report is generated for
+	DateRange    DateRange     // Period covered by the report
+	Items        []Item        // Collected PRs and Issues
+	Commits      []Commit      // Commits authored during the period
+	Repositories []Repository  // Repositories created during the period
+	WorkflowRuns []WorkflowRun // GitHub Actions runs triggered during the period (--include-ci only)
+	Warnings     []string      // Notices about incomplete or truncated data
+	Partial      bool          // Whether the run was interrupted before all data was fetched
+	Previous     *Report       // Report of the equally long period just before DateRange (--compare-previous only)
+	Overview     string        // Natural-language summary generated by an LLM (--summarize only)
+	Meetings     []Meeting     // Calendar events during the period (nil without --calendar)
+}
+
+// Struct to hold a calendar event
+type Meeting struct {
+	Summary string    // Title of the event
+	Start   time.Time // Start time
+	End     time.Time // End time
+}
+
+// Struct to hold the reports of several users
+type TeamReport struct {
+	DateRange DateRange // Period covered by the report
+	Members   []Report  // One report per user
+}
+
 // Struct to hold information about PRs and Issues
 type Item struct {
-	Type        string    // "PR" or "Issue"
-	Number      int       // PR number or Issue number
-	Title       string    // Title
-	URL         string    // URL
-	State       string    // State (open, closed, merged)
-	CreatedAt   time.Time // Creation date
-	UpdatedAt   time.Time // Update date
-	Author      string    // Author
-	Assignees   []string  // Assignees
-	Labels      []string  // Labels
-	Repository  string    // Repository name
-	Involvement string    // Involvement type (created, assigned, commented)
-	Body        string    // Body
-	Comments    []Comment // Comments
+	Type           string            // "PR", "Issue" or "Gist"
+	Number         int               // PR number or Issue number (0 for Gists)
+	Title          string            // Title
+	URL            string            // URL
+	State          string            // State (open, closed, merged)
+	CreatedAt      time.Time         // Creation date
+	UpdatedAt      time.Time         // Update date
+	ClosedAt       time.Time         // Close date (closed and merged items only)
+	MergedAt       time.Time         // Merge date (merged PRs only)
+	Author         string            // Author
+	AuthorIsBot    bool              // Whether the author is a bot account
+	Assignees      []string          // Assignees
+	Labels         []string          // Labels
+	LabelColors    map[string]string // Label name -> hex color without "#"
+	Repository     string            // Repository name
+	Private        bool              // Whether the repository is private (false when not known: no --visibility or --anonymize, and no details)
+	Involvements   []string          // Involvement types (created, assigned, commented, reviewed, mentioned)
+	Body           string            // Body
+	Reactions      Reactions         // Reactions on the item
+	Projects       []ProjectItem     // Projects v2 the item belongs to
+	Comments       []Comment         // Comments
+	Reviews        []Review          // Submitted reviews (PRs only)
+	ReviewRequests []ReviewRequest   // Review requests, including those before the period (PRs only)
+	Events         []Event           // Timeline events during the period
+	ReferencedBy   []Reference       // Issues and PRs that referenced the item during the period
+	Additions      int               // Added lines (PRs only)
+	Deletions      int               // Deleted lines (PRs only)
+	ChangedFiles   int               // Number of changed files (PRs only)
+	Commits        []Commit          // Commits contained in the PR (PRs you created only)
+	HeadSHA        string            // Head commit SHA (PRs only)
+	HeadRef        string            // Head branch name (PRs only)
+	ChecksState    string            // Combined CI state of the head commit: success, failure, pending (PRs you created only)
+	Files          []string          // File names (Gists only)
+	NodeID         string            // GraphQL node ID (Issues and PRs found by a search)
 }
 
 // Struct to hold comment information
 type Comment struct {
-	Author    string    // Comment author
-	Body      string    // Comment body
-	CreatedAt time.Time // Date of posting
-	UpdatedAt time.Time // Update date
-} 
+	Author      string    // Comment author
+	AuthorIsBot bool      // Whether the author is a bot account
+	Body        string    // Comment body
+	CreatedAt   time.Time // Date of posting
+	UpdatedAt   time.Time // Update date
+	Reactions   Reactions // Reactions on the comment
+	Path        string    // File the comment is attached to (review comments only)
+	Line        int       // Line in the file (review comments only)
+	DiffHunk    string    // Diff context of the commented line (review comments only)
+}
+
+// Struct to hold a submitted PR review
+type Review struct {
+	Author      string    // Reviewer
+	State       string    // APPROVED, CHANGES_REQUESTED, COMMENTED or DISMISSED
+	Body        string    // Review summary comment
+	SubmittedAt time.Time // Date of submission
+}
+
+// Struct to hold a review request on a PR
+type ReviewRequest struct {
+	Reviewer    string    // Requested reviewer (user login or team slug)
+	RequestedAt time.Time // Date of the request
+}
+
+// Struct to hold a timeline event of an item
+type Event struct {
+	Type      string    // labeled, unlabeled, assigned, unassigned, closed, reopened, merged
+	Actor     string    // User who triggered the event
+	Detail    string    // Label name, assignee, or referencing item depending on the type
+	CreatedAt time.Time // Date of the event
+}
+
+// Struct to hold an Issue or PR that referenced an item
+type Reference struct {
+	Type         string    // "PR" or "Issue"
+	Number       int       // PR number or Issue number
+	Title        string    // Title
+	URL          string    // URL
+	Repository   string    // Repository name (owner/name)
+	Actor        string    // User who wrote the reference
+	ReferencedAt time.Time // Date of the reference
+}
+
+// Struct to hold a repository created by the user
+type Repository struct {
+	Name        string    // Repository name (owner/name)
+	URL         string    // URL
+	Description string    // Description
+	Private     bool      // Whether the repository is private
+	Fork        bool      // Whether the repository is a fork
+	CreatedAt   time.Time // Creation date
+}
+
+// Struct to hold a GitHub Actions workflow run
+type WorkflowRun struct {
+	Name       string    // Workflow name
+	Number     int       // Run number within the workflow
+	Repository string    // Repository name (owner/name)
+	Event      string    // Triggering event (push, workflow_dispatch, ...)
+	Status     string    // queued, in_progress or completed
+	Conclusion string    // success, failure, cancelled, ... (completed runs only)
+	Branch     string    // Head branch
+	URL        string    // URL
+	CreatedAt  time.Time // Date of the run
+}
+
+// Struct to hold information about a commit
+type Commit struct {
+	SHA        string    // Commit SHA
+	Message    string    // Commit message
+	URL        string    // URL
+	Repository string    // Repository name
+	Private    bool      // Whether the repository is private
+	AuthoredAt time.Time // Author date
+}
+
+// HasInvolvement reports whether the item has the given involvement type
+func (i Item) HasInvolvement(involvement string) bool {
+	for _, v := range i.Involvements {
+		if v == involvement {
+			return true
+		}
+	}
+	return false
+}
+
+// Authors and branch prefixes of automated dependency update PRs
+var (
+	dependencyBotAuthors   = []string{"dependabot[bot]", "dependabot-preview[bot]", "renovate[bot]", "renovate-bot"}
+	dependencyBranchPrefix = []string{"dependabot/", "renovate/"}
+)
+
+// IsDependencyUpdate reports whether the item is a PR opened by Dependabot or Renovate
+func (i Item) IsDependencyUpdate() bool {
+	if i.Type != "PR" {
+		return false
+	}
+	for _, author := range dependencyBotAuthors {
+		if strings.EqualFold(i.Author, author) {
+			return true
+		}
+	}
+	for _, prefix := range dependencyBranchPrefix {
+		if strings.HasPrefix(i.HeadRef, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Key returns an identifier unique to the item across repositories
+func (i Item) Key() string {
+	// Gists belong to no repository and have no number
+	if i.Type == "Gist" {
+		return i.URL
+	}
+	return fmt.Sprintf("%s#%d", i.Repository, i.Number)
+}
+
+// Struct to hold the Projects v2 entry of an item
+type ProjectItem struct {
+	Project   string            // Project title
+	URL       string            // Project URL
+	Status    string            // Value of the "Status" field
+	Iteration string            // Title of the iteration the item is assigned to
+	Fields    map[string]string // All single select, iteration and text field values by field name
+}
+
+// Struct to hold reaction counts on an item or comment
+type Reactions struct {
+	ThumbsUp   int // 👍
+	ThumbsDown int // 👎
+	Laugh      int // 😄
+	Hooray     int // 🎉
+	Confused   int // 😕
+	Heart      int // ❤️
+	Rocket     int // 🚀
+	Eyes       int // 👀
+}
+
+// Total returns the total number of reactions
+func (r Reactions) Total() int {
+	return r.ThumbsUp + r.ThumbsDown + r.Laugh + r.Hooray + r.Confused + r.Heart + r.Rocket + r.Eyes
+}
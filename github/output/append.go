@@ -0,0 +1,93 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// 期間の Markdown レポートを日付つきの節としてジャーナルファイルの末尾に追記する
+// 新しいファイルにはレポートのタイトルを最初に書き、各節の見出しは 1 段下げます
+func appendJournal(filename string, dateRange model.DateRange, render func(io.Writer) error) error {
+	existing, err := os.ReadFile(filename)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	period := fmt.Sprintf("%s to %s", dateRange.StartDate.Format("2006-01-02"), dateRange.EndDate.Format("2006-01-02"))
+	heading := "## " + period
+	// The same period twice would make the journal count everything twice
+	for _, line := range strings.Split(string(existing), "\n") {
+		if line == heading {
+			return fmt.Errorf("%s already has a section for %s", filename, period)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := render(&buf); err != nil {
+		return err
+	}
+	title, body := splitReportTitle(buf.String())
+
+	var section strings.Builder
+	switch {
+	case len(bytes.TrimSpace(existing)) == 0:
+		if title != "" {
+			section.WriteString(title + "\n\n")
+		}
+	case !bytes.HasSuffix(existing, []byte("\n")):
+		// Keep a blank line between the previous section and the new heading
+		section.WriteString("\n\n")
+	case !bytes.HasSuffix(existing, []byte("\n\n")):
+		section.WriteString("\n")
+	}
+	section.WriteString(heading + "\n\n")
+	section.WriteString(demoteHeadings(strings.TrimLeft(body, "\n")))
+
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.WriteString(file, section.String())
+	return err
+}
+
+// レポートの先頭のタイトル行と Period 行を本文から切り離す（期間は節の見出しが表すため）
+func splitReportTitle(report string) (string, string) {
+	if !strings.HasPrefix(report, "# ") {
+		return "", report
+	}
+	title, body, _ := strings.Cut(report, "\n")
+	if strings.HasPrefix(body, "Period: ") {
+		_, body, _ = strings.Cut(body, "\n")
+	}
+	// A partial run is noted in its own section, not in the title of the whole journal
+	if strings.Contains(title, " (partial)") {
+		title = strings.Replace(title, " (partial)", "", 1)
+		body = "_Partial report (the run was interrupted)._\n\n" + body
+	}
+	return title, body
+}
+
+// Markdown の見出しを 1 段下げる（コードブロックの中は変更しない）
+func demoteHeadings(markdown string) string {
+	lines := strings.Split(markdown, "\n")
+	inCode := false
+	for i, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+			continue
+		}
+		if !inCode && strings.HasPrefix(line, "#") && strings.HasPrefix(strings.TrimLeft(line, "#"), " ") {
+			lines[i] = "#" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,158 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"unicode/utf8"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// Steps taken in order to shrink a report that exceeds the token budget
+var budgetSteps = []string{
+	"kept only the 3 latest comments per item and dropped timeline events",
+	"shortened bodies and comments and dropped reviews, PR commits and references",
+	"dropped items authored by bots",
+	"listed items on one line each without details",
+}
+
+// writeWithinBudget はトークン数の見積もりが opts.MaxTokens に収まるまで詳細を段階的に減らして書き出します
+// すべての手順を適用しても収まらない場合は、更新日の古いアイテムから省略します
+func writeWithinBudget(file io.Writer, reports []model.Report, opts Options, render func(io.Writer, []model.Report, Options) error) error {
+	var buf bytes.Buffer
+	for step := 0; ; step++ {
+		reduced, reducedOpts := reduceDetail(reports, opts, step)
+		if step > 0 {
+			note := fmt.Sprintf("Detail was reduced to fit --max-tokens %d: %s", opts.MaxTokens, budgetSteps[0])
+			for _, description := range budgetSteps[1:min(step, len(budgetSteps))] {
+				note += "; " + description
+			}
+			for i := range reduced {
+				reduced[i].Warnings = append(reduced[i].Warnings, note)
+			}
+		}
+
+		// Past the last step, drop the least recently updated items
+		if step > len(budgetSteps) {
+			dropOldestItems(reduced, step-len(budgetSteps))
+		}
+
+		buf.Reset()
+		if err := render(&buf, reduced, reducedOpts); err != nil {
+			return err
+		}
+		if estimateTokens(buf.Bytes()) <= opts.MaxTokens || !hasItems(reduced) {
+			break
+		}
+	}
+	_, err := file.Write(buf.Bytes())
+	return err
+}
+
+// reduceDetail は step 番目までの手順を適用したレポートのコピーを返します
+func reduceDetail(reports []model.Report, opts Options, step int) ([]model.Report, Options) {
+	reduced := make([]model.Report, len(reports))
+	for i, report := range reports {
+		report.Warnings = append([]string(nil), report.Warnings...)
+		items := make([]model.Item, 0, len(report.Items))
+		for _, item := range report.Items {
+			if step >= 1 {
+				if len(item.Comments) > 3 {
+					item.Comments = item.Comments[len(item.Comments)-3:]
+				}
+				item.Events = nil
+			}
+			if step >= 2 {
+				item.Body = truncateRunes(item.Body, 100)
+				comments := make([]model.Comment, len(item.Comments))
+				for j, comment := range item.Comments {
+					comment.Body = truncateRunes(comment.Body, 100)
+					comment.DiffHunk = ""
+					comments[j] = comment
+				}
+				item.Comments = comments
+				item.Reviews = nil
+				item.Commits = nil
+				item.ReferencedBy = nil
+			}
+			if step >= 3 && item.AuthorIsBot && !item.IsDependencyUpdate() {
+				continue
+			}
+			items = append(items, item)
+		}
+		report.Items = items
+		reduced[i] = report
+	}
+
+	if step >= 4 {
+		opts.SummaryOnly = true
+	}
+	return reduced, opts
+}
+
+// dropOldestItems は各レポートから更新日の古いアイテムを round 回分（1回につき約2割）省略します
+func dropOldestItems(reports []model.Report, round int) {
+	for i := range reports {
+		items := reports[i].Items
+		keep := len(items)
+		for r := 0; r < round; r++ {
+			keep = keep * 4 / 5
+		}
+		if keep == len(items) {
+			continue
+		}
+
+		// Keep the most recently updated items in the order they were sorted in
+		newest := append([]model.Item(nil), items...)
+		sort.SliceStable(newest, func(a, b int) bool {
+			return newest[a].UpdatedAt.After(newest[b].UpdatedAt)
+		})
+		kept := make(map[string]bool, keep)
+		for _, item := range newest[:keep] {
+			kept[item.Key()] = true
+		}
+		var remaining []model.Item
+		for _, item := range items {
+			if kept[item.Key()] {
+				remaining = append(remaining, item)
+			}
+		}
+		reports[i].Warnings = append(reports[i].Warnings, fmt.Sprintf("%d least recently updated items were omitted to fit the token budget", len(items)-len(remaining)))
+		reports[i].Items = remaining
+	}
+}
+
+// hasItems はいずれかのレポートにアイテムが残っているかどうかを返す
+func hasItems(reports []model.Report) bool {
+	for _, report := range reports {
+		if len(report.Items) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// estimateTokens はテキストのトークン数を概算する（ASCII は4文字で1トークン、それ以外は1文字1トークン）
+func estimateTokens(text []byte) int {
+	ascii, other := 0, 0
+	for len(text) > 0 {
+		r, size := utf8.DecodeRune(text)
+		text = text[size:]
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// truncateRunes は文字列を最大 n 文字に切り詰める（切り詰めた場合は "..." を付ける）
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "..."
+}
@@ -0,0 +1,190 @@
+package output
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// Confluence のストレージ形式（XHTML）で出力
+func writeConfluenceFormat(file io.Writer, report model.Report, opts Options) error {
+	title := "GitHub Activity Report"
+	if report.Partial {
+		title += " (partial)"
+	}
+	fmt.Fprintf(file, "<h1>%s - %s</h1>\n", title, html.EscapeString(report.Username))
+	fmt.Fprintf(file, "<p>Period: %s to %s</p>\n",
+		report.DateRange.StartDate.Format("2006-01-02"),
+		report.DateRange.EndDate.Format("2006-01-02"))
+
+	writeConfluenceBody(file, report, 2, opts)
+	return nil
+}
+
+// チームレポートを Confluence のストレージ形式で出力
+func writeTeamConfluenceFormat(file io.Writer, team model.TeamReport, opts Options) error {
+	usernames := make([]string, len(team.Members))
+	for i, member := range team.Members {
+		usernames[i] = member.Username
+	}
+
+	fmt.Fprintf(file, "<h1>GitHub Team Activity Report - %s</h1>\n", html.EscapeString(strings.Join(usernames, ", ")))
+	fmt.Fprintf(file, "<p>Period: %s to %s</p>\n",
+		team.DateRange.StartDate.Format("2006-01-02"),
+		team.DateRange.EndDate.Format("2006-01-02"))
+
+	// Team-level summary, one row per user
+	fmt.Fprintf(file, "<h2>Team Summary</h2>\n")
+	fmt.Fprintf(file, "<table><tbody>\n")
+	fmt.Fprintf(file, "<tr><th>User</th><th>Items</th><th>PRs</th><th>Merged PRs</th><th>Issues</th><th>Commits</th></tr>\n")
+	for _, member := range team.Members {
+		c := countItems(member.Items)
+		fmt.Fprintf(file, "<tr><td>%s</td><td>%d</td><td>%d</td><td>%d</td><td>%d</td><td>%d</td></tr>\n",
+			html.EscapeString(member.Username), len(member.Items), c.prs, c.merged, c.issues, len(member.Commits))
+	}
+	fmt.Fprintf(file, "</tbody></table>\n")
+
+	// Per-user sections
+	for _, member := range team.Members {
+		if member.Partial {
+			fmt.Fprintf(file, "<h2>%s (partial)</h2>\n", html.EscapeString(member.Username))
+		} else {
+			fmt.Fprintf(file, "<h2>%s</h2>\n", html.EscapeString(member.Username))
+		}
+		writeConfluenceBody(file, member, 3, opts)
+	}
+	return nil
+}
+
+// レポート本文を Confluence のストレージ形式で書き出す
+func writeConfluenceBody(file io.Writer, report model.Report, level int, opts Options) {
+	items := report.Items
+
+	// Warnings about incomplete data are shown in a warning panel
+	if len(report.Warnings) > 0 {
+		fmt.Fprintf(file, "<ac:structured-macro ac:name=\"warning\"><ac:rich-text-body><ul>\n")
+		for _, warning := range report.Warnings {
+			fmt.Fprintf(file, "<li>%s</li>\n", html.EscapeString(warning))
+		}
+		fmt.Fprintf(file, "</ul></ac:rich-text-body></ac:structured-macro>\n")
+	}
+
+	// Generated summary (--summarize)
+	if report.Overview != "" {
+		fmt.Fprintf(file, "<h%d>Overview</h%d>\n<p>%s</p>\n", level, level, confluenceText(report.Overview))
+	}
+
+	// Summary
+	counts := countItems(items)
+	fmt.Fprintf(file, "<h%d>Summary</h%d>\n<ul>\n", level, level)
+	fmt.Fprintf(file, "<li>Total items: %d</li>\n", len(items))
+	fmt.Fprintf(file, "<li>Number of PRs: %d</li>\n", counts.prs)
+	fmt.Fprintf(file, "<li>Number of merged PRs: %d</li>\n", counts.merged)
+	fmt.Fprintf(file, "<li>Number of Issues: %d</li>\n", counts.issues)
+	if counts.gists > 0 {
+		fmt.Fprintf(file, "<li>Number of Gists: %d</li>\n", counts.gists)
+	}
+	if counts.jira > 0 {
+		fmt.Fprintf(file, "<li>Number of Jira issues: %d</li>\n", counts.jira)
+	}
+	fmt.Fprintf(file, "<li>Number of commits: %d</li>\n", len(report.Commits))
+	for _, section := range involvementSections {
+		count := 0
+		for _, item := range items {
+			if item.HasInvolvement(section.involvement) {
+				count++
+			}
+		}
+		if section.optional && count == 0 {
+			continue
+		}
+		fmt.Fprintf(file, "<li>%s items: %d</li>\n", section.label, count)
+	}
+	fmt.Fprintf(file, "</ul>\n")
+
+	// Each item is listed once, under the section of its primary involvement
+	fmt.Fprintf(file, "<h%d>Item Details</h%d>\n", level, level)
+	for _, section := range involvementSections {
+		var sectionItems []model.Item
+		for _, item := range items {
+			if len(item.Involvements) > 0 && item.Involvements[0] == section.involvement {
+				sectionItems = append(sectionItems, item)
+			}
+		}
+		if len(sectionItems) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(file, "<h%d>%s Items</h%d>\n", level+1, section.label, level+1)
+		for _, item := range sectionItems {
+			writeConfluenceItem(file, item, opts)
+		}
+	}
+
+	// Commits, each prefixed with its repository
+	if len(report.Commits) > 0 {
+		fmt.Fprintf(file, "<h%d>Commits</h%d>\n<ul>\n", level, level)
+		for _, commit := range report.Commits {
+			fmt.Fprintf(file, "<li>%s <a href=\"%s\"><code>%s</code></a> %s (%s)</li>\n",
+				html.EscapeString(commit.Repository),
+				html.EscapeString(commit.URL),
+				shortSHA(commit.SHA),
+				html.EscapeString(commitSubject(commit.Message)),
+				commit.AuthoredAt.Format("2006-01-02"))
+		}
+		fmt.Fprintf(file, "</ul>\n")
+	}
+}
+
+// アイテムの詳細を Confluence のストレージ形式で書き出す（コメントは展開マクロに格納）
+func writeConfluenceItem(file io.Writer, item model.Item, opts Options) {
+	title := fmt.Sprintf("[%s #%d] %s", item.Type, item.Number, item.Title)
+	if item.Type == "Gist" {
+		title = "[Gist] " + item.Title
+	}
+	fmt.Fprintf(file, "<p><a href=\"%s\">%s</a></p>\n<ul>\n", html.EscapeString(item.URL), html.EscapeString(title))
+	if item.Repository != "" {
+		fmt.Fprintf(file, "<li>Repository: %s</li>\n", html.EscapeString(item.Repository))
+	}
+	fmt.Fprintf(file, "<li>State: %s</li>\n", html.EscapeString(item.State))
+	if len(item.Involvements) > 1 {
+		fmt.Fprintf(file, "<li>Involvement: %s</li>\n", strings.Join(item.Involvements, ", "))
+	}
+	fmt.Fprintf(file, "<li>Created on: %s</li>\n", item.CreatedAt.Format("2006-01-02"))
+	fmt.Fprintf(file, "<li>Updated on: %s</li>\n", item.UpdatedAt.Format("2006-01-02"))
+	if !item.ClosedAt.IsZero() {
+		fmt.Fprintf(file, "<li>Closed on: %s</li>\n", item.ClosedAt.Format("2006-01-02"))
+	}
+	if len(item.Labels) > 0 {
+		fmt.Fprintf(file, "<li>Labels: %s</li>\n", html.EscapeString(strings.Join(item.Labels, ", ")))
+	}
+	fmt.Fprintf(file, "</ul>\n")
+
+	// Bodies are truncated like in the markdown report
+	if item.Body != "" {
+		body := item.Body
+		if !opts.Full && len(body) > 300 {
+			body = body[:300] + "..."
+		}
+		fmt.Fprintf(file, "<p>%s</p>\n", confluenceText(body))
+	}
+
+	if len(item.Comments) > 0 {
+		fmt.Fprintf(file, "<ac:structured-macro ac:name=\"expand\"><ac:parameter ac:name=\"title\">Comments (%d)</ac:parameter><ac:rich-text-body>\n", len(item.Comments))
+		for _, comment := range item.Comments {
+			fmt.Fprintf(file, "<p><strong>%s</strong> (%s)<br />%s</p>\n",
+				html.EscapeString(comment.Author),
+				comment.CreatedAt.Format("2006-01-02"),
+				confluenceText(comment.Body))
+		}
+		fmt.Fprintf(file, "</ac:rich-text-body></ac:structured-macro>\n")
+	}
+}
+
+// テキストをエスケープし、改行を <br /> に変換する
+func confluenceText(s string) string {
+	return strings.ReplaceAll(html.EscapeString(s), "\n", "<br />")
+}
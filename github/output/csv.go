@@ -0,0 +1,60 @@
+package output
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// Columns of the CSV output, one row per item
+var csvHeader = []string{"type", "repository", "number", "title", "state", "involvement", "created", "updated", "url"}
+
+// CSV形式で出力（チームレポートでは先頭にユーザー列を追加）
+func writeCSVFormat(file io.Writer, reports []model.Report, withUser bool) error {
+	w := csv.NewWriter(file)
+
+	header := csvHeader
+	if withUser {
+		header = append([]string{"user"}, header...)
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, report := range reports {
+		for _, item := range report.Items {
+			row := []string{
+				item.Type,
+				item.Repository,
+				strconv.Itoa(item.Number),
+				item.Title,
+				item.State,
+				strings.Join(item.Involvements, ";"),
+				formatCSVTime(item.CreatedAt),
+				formatCSVTime(item.UpdatedAt),
+				item.URL,
+			}
+			if withUser {
+				row = append([]string{report.Username}, row...)
+			}
+			if err := w.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// 日時を表計算ソフトで扱いやすい形式に変換します（未設定の場合は空文字列）
+func formatCSVTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,62 @@
+package output
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// 種類と状態を表す絵文字を返す（例: "🔀 🟣"）
+func itemEmoji(itemType, state string) string {
+	typeIcon := "📄"
+	switch itemType {
+	case "PR":
+		typeIcon = "🔀"
+	case "Issue":
+		typeIcon = "📝"
+	}
+
+	switch state {
+	case "open":
+		return typeIcon + " 🟢"
+	case "merged":
+		return typeIcon + " 🟣"
+	case "closed":
+		return typeIcon + " 🔴"
+	default:
+		return typeIcon
+	}
+}
+
+// Colored squares used as label chips with the color they stand for
+var labelChips = []struct {
+	chip    string
+	r, g, b int
+}{
+	{"🟥", 0xd7, 0x3a, 0x4a},
+	{"🟧", 0xf6, 0x8c, 0x32},
+	{"🟨", 0xfb, 0xca, 0x04},
+	{"🟩", 0x0e, 0x8a, 0x16},
+	{"🟦", 0x00, 0x75, 0xca},
+	{"🟪", 0x84, 0x4e, 0xd8},
+	{"🟫", 0x8b, 0x5a, 0x2b},
+	{"⬛", 0x20, 0x20, 0x20},
+	{"⬜", 0xee, 0xee, 0xee},
+}
+
+// ラベルの色に最も近い色の四角い絵文字を付けたラベル名を返す（色が不明な場合はラベル名のみ）
+func labelWithChip(name, color string) string {
+	value, err := strconv.ParseUint(color, 16, 32)
+	if err != nil || len(color) != 6 {
+		return name
+	}
+	r, g, b := int(value>>16&0xff), int(value>>8&0xff), int(value&0xff)
+
+	best, bestDistance := "", -1
+	for _, c := range labelChips {
+		distance := (r-c.r)*(r-c.r) + (g-c.g)*(g-c.g) + (b-c.b)*(b-c.b)
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = c.chip, distance
+		}
+	}
+	return fmt.Sprintf("%s %s", best, name)
+}
@@ -0,0 +1,76 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// Shades of the heatmap cells from least to most active
+var heatmapShades = []string{"░", "▒", "▓", "█"}
+
+// 日ごとの活動量（アイテムの作成・クローズ、コメント、レビュー）を曜日×週のグリッドで書き出す
+func writeHeatmap(file io.Writer, items []model.Item, dateRange model.DateRange) {
+	start := dateRange.StartDate
+	end := dateRange.EndDate
+
+	counts := make(map[string]int)
+	add := func(t time.Time) {
+		if !t.IsZero() && !t.Before(start) && !t.After(end) {
+			counts[t.Format("2006-01-02")]++
+		}
+	}
+	for _, item := range items {
+		add(item.CreatedAt)
+		add(item.ClosedAt)
+		for _, comment := range item.Comments {
+			add(comment.CreatedAt)
+		}
+		for _, review := range item.Reviews {
+			add(review.SubmittedAt)
+		}
+	}
+	max := 0
+	for _, count := range counts {
+		if count > max {
+			max = count
+		}
+	}
+
+	// Columns are weeks starting on Monday, rows are weekdays (midnight in the time zone of the period)
+	firstDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	firstMonday := firstDay.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	var weeks []time.Time
+	for week := firstMonday; !week.After(end); week = week.AddDate(0, 0, 7) {
+		weeks = append(weeks, week)
+	}
+
+	fmt.Fprintln(file, "Activity per day (items opened or closed, comments and reviews):")
+	fmt.Fprintln(file, "```")
+	header := "    "
+	for _, week := range weeks {
+		header += fmt.Sprintf(" %-6s", week.Format("01-02"))
+	}
+	fmt.Fprintln(file, strings.TrimRight(header, " "))
+	for weekday := 0; weekday < 7; weekday++ {
+		row := time.Weekday((weekday + 1) % 7).String()[:3] + " "
+		for _, week := range weeks {
+			day := week.AddDate(0, 0, weekday)
+			cell := ""
+			if !day.Before(firstDay) && !day.After(end) {
+				cell = "·"
+				if count := counts[day.Format("2006-01-02")]; count > 0 {
+					shade := heatmapShades[(count*len(heatmapShades)-1)/max]
+					cell = fmt.Sprintf("%s %d", shade, count)
+				}
+			}
+			row += fmt.Sprintf(" %-6s", cell)
+		}
+		fmt.Fprintln(file, strings.TrimRight(row, " "))
+	}
+	fmt.Fprintln(file, "```")
+	fmt.Fprintln(file, "")
+}
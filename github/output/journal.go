@@ -0,0 +1,169 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// 期間内の日ごとに、その日に動きのあったアイテムを書き出す
+// 予定（--calendar）があれば、各日の会議時間と GitHub の活動量を並べ、最後に期間の合計を書き出す
+func writeItemsByDay(file io.Writer, items []model.Item, meetings []model.Meeting, dateRange model.DateRange, heading string) {
+	var meetingTotal time.Duration
+	var meetingDays, quietDays int
+	for day := dateRange.StartDate; !day.After(dateRange.EndDate); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+
+		var lines []string
+		for _, item := range items {
+			activities := dayActivities(item, date)
+			if len(activities) == 0 {
+				continue
+			}
+			line := fmt.Sprintf("- [%s #%d] %s (%s): %s", item.Type, item.Number, item.Title, item.Repository, strings.Join(activities, ", "))
+			if item.Type == "Gist" {
+				line = fmt.Sprintf("- [Gist] %s: %s", item.Title, strings.Join(activities, ", "))
+			}
+			lines = append(lines, line)
+		}
+
+		todays, busy := dayMeetings(meetings, day)
+		meetingTotal += busy
+		if len(todays) > 0 {
+			meetingDays++
+			if len(lines) == 0 {
+				quietDays++
+			}
+		}
+		if len(lines) == 0 && len(todays) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(file, "%s %s (%s)\n\n", heading, date, day.Format("Mon"))
+		if meetings != nil {
+			writeDayMeetings(file, todays, busy, len(lines))
+			if len(lines) > 0 {
+				fmt.Fprintln(file, "")
+			}
+		}
+		for _, line := range lines {
+			fmt.Fprintln(file, line)
+		}
+		fmt.Fprintln(file, "")
+	}
+
+	if meetings != nil {
+		fmt.Fprintf(file, "Meetings in the period: %s on %s", formatHours(meetingTotal), plural(meetingDays, "day"))
+		if quietDays > 0 {
+			fmt.Fprintf(file, " (%d of them without GitHub activity)", quietDays)
+		}
+		fmt.Fprintf(file, "\n\n")
+	}
+}
+
+// 日の会議時間と GitHub の活動量を 1 行ずつ書き出す
+func writeDayMeetings(file io.Writer, meetings []model.Meeting, busy time.Duration, activities int) {
+	if len(meetings) == 0 {
+		fmt.Fprintf(file, "Meetings: none\n")
+	} else {
+		var parts []string
+		for _, meeting := range meetings {
+			parts = append(parts, fmt.Sprintf("%s %s (%s)", meeting.Start.Format("15:04"), meeting.Summary, formatHours(meeting.End.Sub(meeting.Start))))
+		}
+		fmt.Fprintf(file, "Meetings: %s (%s)\n", formatHours(busy), strings.Join(parts, ", "))
+	}
+	fmt.Fprintf(file, "GitHub activity: %s\n", plural(activities, "item"))
+}
+
+// 日に重なる予定と、重なりを除いた会議時間の合計を返す
+func dayMeetings(meetings []model.Meeting, day time.Time) ([]model.Meeting, time.Duration) {
+	dayEnd := day.AddDate(0, 0, 1)
+	var todays []model.Meeting
+	var busy time.Duration
+	var busyUntil time.Time
+	for _, meeting := range meetings {
+		start, end := meeting.Start.In(day.Location()), meeting.End.In(day.Location())
+		if !end.After(day) || !start.Before(dayEnd) {
+			continue
+		}
+		todays = append(todays, meeting)
+
+		// Meetings are sorted by start, so overlapping time is counted once
+		if start.Before(day) {
+			start = day
+		}
+		if end.After(dayEnd) {
+			end = dayEnd
+		}
+		if start.Before(busyUntil) {
+			start = busyUntil
+		}
+		if end.After(start) {
+			busy += end.Sub(start)
+			busyUntil = end
+		}
+	}
+	return todays, busy
+}
+
+// 時間を "1.5 h" や "45 min" の形で表す
+func formatHours(d time.Duration) string {
+	if d < time.Hour {
+		return fmt.Sprintf("%d min", int(d.Minutes()))
+	}
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", d.Hours()), ".0") + " h"
+}
+
+// アイテムに指定した日に起きたことを列挙する（何もなければ空）
+func dayActivities(item model.Item, date string) []string {
+	on := func(t time.Time) bool {
+		return !t.IsZero() && t.Format("2006-01-02") == date
+	}
+
+	var activities []string
+	if on(item.CreatedAt) {
+		activities = append(activities, "created")
+	}
+	if on(item.MergedAt) {
+		activities = append(activities, "merged")
+	} else if on(item.ClosedAt) {
+		activities = append(activities, "closed")
+	}
+
+	comments := 0
+	for _, comment := range item.Comments {
+		if on(comment.CreatedAt) {
+			comments++
+		}
+	}
+	if comments > 0 {
+		activities = append(activities, plural(comments, "comment"))
+	}
+
+	reviews := 0
+	for _, review := range item.Reviews {
+		if on(review.SubmittedAt) {
+			reviews++
+		}
+	}
+	if reviews > 0 {
+		activities = append(activities, plural(reviews, "review"))
+	}
+
+	// Only the last update is known, so it is mentioned when nothing more specific happened
+	if len(activities) == 0 && on(item.UpdatedAt) {
+		activities = append(activities, "updated")
+	}
+	return activities
+}
+
+// 件数と名詞を組み合わせる（2件以上は複数形）
+func plural(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
@@ -0,0 +1,65 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// JSONLWriter は取得したアイテムを1行1件の JSON としてすぐにファイルへ書き出します
+type JSONLWriter struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+	err     error // First write error, reported by Close
+}
+
+// Line written for each item, tagged with the user it belongs to
+type jsonlLine struct {
+	User string `json:"User"`
+	model.Item
+}
+
+// NewJSONLWriter は JSON Lines を書き出すファイルを作成します
+func NewJSONLWriter(filename string) (*JSONLWriter, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLWriter{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+// Write はアイテムを1行書き出します（複数の goroutine から呼び出せます）
+// 書き込みに失敗した場合、以降の書き込みは行わずエラーを Close で返します
+func (w *JSONLWriter) Write(username string, item model.Item) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = w.encoder.Encode(jsonlLine{User: username, Item: item})
+	}
+}
+
+// Close はファイルを閉じ、書き込み中に発生したエラーがあれば返します
+func (w *JSONLWriter) Close() error {
+	closeErr := w.file.Close()
+	if w.err != nil {
+		return w.err
+	}
+	return closeErr
+}
+
+// JSON Lines 形式で出力
+func writeJSONLFormat(w io.Writer, reports []model.Report) error {
+	encoder := json.NewEncoder(w)
+	for _, report := range reports {
+		for _, item := range report.Items {
+			if err := encoder.Encode(jsonlLine{User: report.Username, Item: item}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// ラベルごとのアイテム数を書き出す（allowlist が指定された場合はそのラベルだけを数える）
+// 数えるラベルを持たないアイテムは最後にまとめて数える
+func writeLabelBreakdown(file io.Writer, items []model.Item, allowlist []string) {
+	if len(items) == 0 {
+		return
+	}
+
+	allowed := make(map[string]string) // lower-case name -> name as configured
+	for _, label := range allowlist {
+		allowed[strings.ToLower(label)] = label
+	}
+
+	counts := make(map[string]int)
+	others := 0
+	for _, item := range items {
+		counted := false
+		seen := make(map[string]bool)
+		for _, label := range item.Labels {
+			if len(allowed) > 0 {
+				configured, ok := allowed[strings.ToLower(label)]
+				if !ok {
+					continue
+				}
+				label = configured
+			}
+			if seen[label] {
+				continue
+			}
+			seen[label] = true
+			counts[label]++
+			counted = true
+		}
+		if !counted {
+			others++
+		}
+	}
+
+	// Most frequent labels first
+	var labels []string
+	for label := range counts {
+		labels = append(labels, label)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if counts[labels[i]] != counts[labels[j]] {
+			return counts[labels[i]] > counts[labels[j]]
+		}
+		return labels[i] < labels[j]
+	})
+
+	fmt.Fprintln(file, "Items by label:")
+	for _, label := range labels {
+		fmt.Fprintf(file, "- %s: %d\n", label, counts[label])
+	}
+	if others > 0 {
+		if len(allowed) > 0 {
+			fmt.Fprintf(file, "- (other): %d\n", others)
+		} else {
+			fmt.Fprintf(file, "- (no label): %d\n", others)
+		}
+	}
+	fmt.Fprintln(file, "")
+}
@@ -0,0 +1,113 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// PR の作成からマージまでを Mermaid の gantt または timeline として書き出す
+func writeMermaidChart(file io.Writer, items []model.Item, dateRange model.DateRange, chart string) {
+	// Dependency updates would crowd out the PRs that matter
+	var prs []model.Item
+	for _, item := range items {
+		if item.Type == "PR" && !item.IsDependencyUpdate() {
+			prs = append(prs, item)
+		}
+	}
+	if len(prs) == 0 {
+		return
+	}
+	sort.SliceStable(prs, func(i, j int) bool {
+		return prs[i].CreatedAt.Before(prs[j].CreatedAt)
+	})
+
+	fmt.Fprintln(file, "```mermaid")
+	switch chart {
+	case "gantt":
+		writeMermaidGantt(file, prs, dateRange)
+	case "timeline":
+		writeMermaidTimeline(file, prs, dateRange)
+	}
+	fmt.Fprintln(file, "```")
+	fmt.Fprintln(file, "")
+}
+
+// PR ごとに作成日からマージ（クローズ）日までのバーを描く（未完了のものは期間の終わりまで）
+func writeMermaidGantt(file io.Writer, prs []model.Item, dateRange model.DateRange) {
+	fmt.Fprintln(file, "gantt")
+	fmt.Fprintln(file, "  title Pull requests")
+	fmt.Fprintln(file, "  dateFormat YYYY-MM-DD")
+
+	// One section per repository, in order of first appearance
+	byRepo := make(map[string][]model.Item)
+	var repos []string
+	for _, pr := range prs {
+		if _, ok := byRepo[pr.Repository]; !ok {
+			repos = append(repos, pr.Repository)
+		}
+		byRepo[pr.Repository] = append(byRepo[pr.Repository], pr)
+	}
+
+	for _, repo := range repos {
+		fmt.Fprintf(file, "  section %s\n", mermaidText(repo))
+		for _, pr := range byRepo[repo] {
+			end := dateRange.EndDate
+			status := "active"
+			switch {
+			case !pr.MergedAt.IsZero():
+				end, status = pr.MergedAt, "done"
+			case !pr.ClosedAt.IsZero():
+				end, status = pr.ClosedAt, "crit"
+			}
+			// Bars need at least one day to be visible
+			if end.Format("2006-01-02") == pr.CreatedAt.Format("2006-01-02") {
+				end = end.AddDate(0, 0, 1)
+			}
+			fmt.Fprintf(file, "  PR %d %s :%s, %s, %s\n",
+				pr.Number, mermaidText(pr.Title), status, pr.CreatedAt.Format("2006-01-02"), end.Format("2006-01-02"))
+		}
+	}
+}
+
+// 期間内に PR が作成・マージされた日を時系列に並べる
+func writeMermaidTimeline(file io.Writer, prs []model.Item, dateRange model.DateRange) {
+	fmt.Fprintln(file, "timeline")
+	fmt.Fprintln(file, "  title Pull requests")
+
+	inRange := func(t time.Time) bool {
+		return !t.IsZero() && !t.Before(dateRange.StartDate) && !t.After(dateRange.EndDate)
+	}
+	events := make(map[string][]string)
+	var dates []string
+	add := func(t time.Time, event string) {
+		date := t.Format("2006-01-02")
+		if _, ok := events[date]; !ok {
+			dates = append(dates, date)
+		}
+		events[date] = append(events[date], event)
+	}
+	for _, pr := range prs {
+		if inRange(pr.CreatedAt) {
+			add(pr.CreatedAt, fmt.Sprintf("Opened %s PR %d %s", mermaidText(pr.Repository), pr.Number, mermaidText(pr.Title)))
+		}
+		if inRange(pr.MergedAt) {
+			add(pr.MergedAt, fmt.Sprintf("Merged %s PR %d %s", mermaidText(pr.Repository), pr.Number, mermaidText(pr.Title)))
+		}
+	}
+	sort.Strings(dates)
+
+	for _, date := range dates {
+		fmt.Fprintf(file, "  %s : %s\n", date, strings.Join(events[date], " : "))
+	}
+}
+
+// Mermaid の構文と衝突する文字を取り除く
+func mermaidText(s string) string {
+	s = strings.NewReplacer(":", " ", ";", " ", "#", "").Replace(s)
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,103 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// 期間内の開発指標（マージまでの時間、レビューまでの時間、コメント数など）を書き出す
+func writeMetrics(file io.Writer, report model.Report, heading string) {
+	inRange := func(t time.Time) bool {
+		return !t.IsZero() && !t.Before(report.DateRange.StartDate) && !t.After(report.DateRange.EndDate)
+	}
+
+	var timeToMerge, timeToFirstReview, reviewTurnaround []time.Duration
+	comments, reviews := 0, 0
+	for _, item := range report.Items {
+		// Your PRs merged during the period: opened to merged, and opened to the first review by someone else
+		if item.Type == "PR" && item.HasInvolvement("created") {
+			if inRange(item.MergedAt) {
+				timeToMerge = append(timeToMerge, item.MergedAt.Sub(item.CreatedAt))
+			}
+			var first time.Time
+			for _, review := range item.Reviews {
+				if !strings.EqualFold(review.Author, report.Username) && (first.IsZero() || review.SubmittedAt.Before(first)) {
+					first = review.SubmittedAt
+				}
+			}
+			if inRange(first) {
+				timeToFirstReview = append(timeToFirstReview, first.Sub(item.CreatedAt))
+			}
+		}
+
+		// Review requests to you: requested to your first review after the request
+		for _, request := range item.ReviewRequests {
+			if !strings.EqualFold(request.Reviewer, report.Username) {
+				continue
+			}
+			var first time.Time
+			for _, review := range item.Reviews {
+				if strings.EqualFold(review.Author, report.Username) && !review.SubmittedAt.Before(request.RequestedAt) &&
+					(first.IsZero() || review.SubmittedAt.Before(first)) {
+					first = review.SubmittedAt
+				}
+			}
+			if inRange(first) {
+				reviewTurnaround = append(reviewTurnaround, first.Sub(request.RequestedAt))
+			}
+		}
+
+		for _, comment := range item.Comments {
+			if strings.EqualFold(comment.Author, report.Username) && inRange(comment.CreatedAt) {
+				comments++
+			}
+		}
+		for _, review := range item.Reviews {
+			if strings.EqualFold(review.Author, report.Username) && inRange(review.SubmittedAt) {
+				reviews++
+			}
+		}
+	}
+
+	fmt.Fprintf(file, "%s Metrics\n", heading)
+	fmt.Fprintf(file, "- Time to merge (your PRs): %s\n", formatDurations(timeToMerge))
+	fmt.Fprintf(file, "- Time to first review (your PRs): %s\n", formatDurations(timeToFirstReview))
+	fmt.Fprintf(file, "- Review turnaround (requested to your first review): %s\n", formatDurations(reviewTurnaround))
+	fmt.Fprintf(file, "- Reviews submitted: %d\n", reviews)
+	fmt.Fprintf(file, "- Comments written: %d\n", comments)
+	fmt.Fprintln(file, "")
+}
+
+// 所要時間の中央値と件数を整形する（データがない場合は n/a）
+func formatDurations(durations []time.Duration) string {
+	if len(durations) == 0 {
+		return "n/a"
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	median := durations[len(durations)/2]
+	if len(durations)%2 == 0 {
+		median = (durations[len(durations)/2-1] + durations[len(durations)/2]) / 2
+	}
+	return fmt.Sprintf("median %s over %d (max %s)", formatDuration(median), len(durations), formatDuration(durations[len(durations)-1]))
+}
+
+// 所要時間を "2d 3h" や "45m" のような短い形式にする
+func formatDuration(d time.Duration) string {
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
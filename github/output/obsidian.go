@@ -0,0 +1,270 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// Obsidian のデイリーノート向けの Markdown で出力
+// フロントマター、リポジトリやユーザーへの wiki リンク、ラベルの #タグ、コメントのコールアウトを使います
+func writeObsidianFormat(file io.Writer, report model.Report, opts Options) error {
+	writeObsidianFrontmatter(file, report.DateRange, []model.Report{report})
+
+	title := "GitHub Activity"
+	if report.Partial {
+		title += " (partial)"
+	}
+	fmt.Fprintf(file, "# %s - %s\n", title, obsidianLink(report.Username))
+	fmt.Fprintf(file, "Period: %s to %s\n\n",
+		report.DateRange.StartDate.Format("2006-01-02"),
+		report.DateRange.EndDate.Format("2006-01-02"))
+
+	writeObsidianBody(file, report, 2, opts)
+	return nil
+}
+
+// チームレポートを Obsidian のデイリーノート向けの Markdown で出力
+func writeTeamObsidianFormat(file io.Writer, team model.TeamReport, opts Options) error {
+	writeObsidianFrontmatter(file, team.DateRange, team.Members)
+
+	links := make([]string, len(team.Members))
+	for i, member := range team.Members {
+		links[i] = obsidianLink(member.Username)
+	}
+	fmt.Fprintf(file, "# GitHub Team Activity - %s\n", strings.Join(links, ", "))
+	fmt.Fprintf(file, "Period: %s to %s\n\n",
+		team.DateRange.StartDate.Format("2006-01-02"),
+		team.DateRange.EndDate.Format("2006-01-02"))
+
+	for _, member := range team.Members {
+		if member.Partial {
+			fmt.Fprintf(file, "## %s (partial)\n\n", obsidianLink(member.Username))
+		} else {
+			fmt.Fprintf(file, "## %s\n\n", obsidianLink(member.Username))
+		}
+		writeObsidianBody(file, member, 3, opts)
+	}
+	return nil
+}
+
+// ノートの日付・期間・タグをフロントマターとして書き出す（タグは github と全アイテムのラベル）
+func writeObsidianFrontmatter(file io.Writer, dateRange model.DateRange, reports []model.Report) {
+	tags := []string{"github"}
+	seen := map[string]bool{"github": true}
+	for _, report := range reports {
+		for _, item := range report.Items {
+			for _, label := range item.Labels {
+				if tag := obsidianTag(label); tag != "" && !seen[tag] {
+					seen[tag] = true
+					tags = append(tags, tag)
+				}
+			}
+		}
+	}
+
+	fmt.Fprintln(file, "---")
+	fmt.Fprintf(file, "date: %s\n", dateRange.EndDate.Format("2006-01-02"))
+	fmt.Fprintf(file, "period: %s to %s\n", dateRange.StartDate.Format("2006-01-02"), dateRange.EndDate.Format("2006-01-02"))
+	fmt.Fprintln(file, "tags:")
+	for _, tag := range tags {
+		fmt.Fprintf(file, "  - %s\n", tag)
+	}
+	fmt.Fprintln(file, "---")
+	fmt.Fprintln(file, "")
+}
+
+// レポート本文（警告、概要、サマリー、詳細、コミット）を書き出す
+func writeObsidianBody(file io.Writer, report model.Report, level int, opts Options) {
+	items := report.Items
+	heading := strings.Repeat("#", level)
+
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(file, "> [!warning]\n> %s\n\n", warning)
+	}
+	if report.Overview != "" {
+		fmt.Fprintf(file, "> [!summary] Overview\n%s\n\n", obsidianQuote(report.Overview))
+	}
+
+	counts := countItems(items)
+	fmt.Fprintf(file, "%s Summary\n", heading)
+	fmt.Fprintf(file, "- Total items: %d\n", len(items))
+	fmt.Fprintf(file, "- Number of PRs: %d\n", counts.prs)
+	fmt.Fprintf(file, "- Number of merged PRs: %d\n", counts.merged)
+	fmt.Fprintf(file, "- Number of Issues: %d\n", counts.issues)
+	fmt.Fprintf(file, "- Number of commits: %d\n\n", len(report.Commits))
+
+	// Each item is listed once, under the section of its primary involvement
+	for _, section := range involvementSections {
+		var sectionItems []model.Item
+		for _, item := range items {
+			if len(item.Involvements) > 0 && item.Involvements[0] == section.involvement {
+				sectionItems = append(sectionItems, item)
+			}
+		}
+		if len(sectionItems) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(file, "%s %s Items\n\n", heading, section.label)
+		for _, item := range sectionItems {
+			writeObsidianItem(file, item, level+1, opts)
+		}
+	}
+
+	if len(report.Commits) > 0 {
+		fmt.Fprintf(file, "%s Commits\n", heading)
+		for _, commit := range report.Commits {
+			fmt.Fprintf(file, "- %s [`%s`](%s) %s (%s)\n",
+				obsidianLink(commit.Repository),
+				shortSHA(commit.SHA),
+				commit.URL,
+				commitSubject(commit.Message),
+				commit.AuthoredAt.Format("2006-01-02"))
+		}
+		fmt.Fprintln(file, "")
+	}
+}
+
+// アイテムを見出しと属性の一覧で書き出し、コメントは折りたたみ可能なコールアウトにする
+func writeObsidianItem(file io.Writer, item model.Item, level int, opts Options) {
+	// Brackets in titles would otherwise end the link text or start a wiki-link
+	link := fmt.Sprintf("[%s](%s)", strings.NewReplacer("[", "\\[", "]", "\\]").Replace(item.Title), item.URL)
+	if item.Type == "Gist" {
+		fmt.Fprintf(file, "%s Gist: %s\n", strings.Repeat("#", level), link)
+	} else {
+		fmt.Fprintf(file, "%s %s #%d: %s\n", strings.Repeat("#", level), item.Type, item.Number, link)
+	}
+	if item.Repository != "" {
+		fmt.Fprintf(file, "- Repository: %s\n", obsidianLink(item.Repository))
+	}
+	if item.Author != "" {
+		fmt.Fprintf(file, "- Author: %s\n", obsidianLink(item.Author))
+	}
+	fmt.Fprintf(file, "- State: %s\n", item.State)
+	if len(item.Involvements) > 1 {
+		fmt.Fprintf(file, "- Involvement: %s\n", strings.Join(item.Involvements, ", "))
+	}
+	fmt.Fprintf(file, "- Created on: %s\n", item.CreatedAt.Format("2006-01-02"))
+	fmt.Fprintf(file, "- Updated on: %s\n", item.UpdatedAt.Format("2006-01-02"))
+	if !item.ClosedAt.IsZero() {
+		fmt.Fprintf(file, "- Closed on: %s\n", item.ClosedAt.Format("2006-01-02"))
+	}
+	if len(item.Labels) > 0 {
+		var tags []string
+		for _, label := range item.Labels {
+			if tag := obsidianTag(label); tag != "" {
+				tags = append(tags, "#"+tag)
+			}
+		}
+		fmt.Fprintf(file, "- Labels: %s\n", strings.Join(tags, " "))
+	}
+	fmt.Fprintln(file, "")
+
+	// Bodies are truncated like in the markdown report
+	if item.Body != "" {
+		body := item.Body
+		if !opts.Full && len(body) > 300 {
+			body = body[:300] + "..."
+		}
+		fmt.Fprintf(file, "%s\n\n", body)
+	}
+
+	maxComments := 5
+	if opts.Full {
+		maxComments = len(item.Comments)
+	}
+	for i, comment := range item.Comments {
+		if i == maxComments {
+			fmt.Fprintf(file, "(%d more comments not shown)\n\n", len(item.Comments)-maxComments)
+			break
+		}
+		body := comment.Body
+		if !opts.Full && len(body) > 200 {
+			body = body[:200] + "..."
+		}
+		fmt.Fprintf(file, "> [!quote]- %s (%s)\n%s\n\n", obsidianLink(comment.Author), comment.CreatedAt.Format("2006-01-02"), obsidianQuote(body))
+	}
+}
+
+// ユーザーやリポジトリへの wiki リンクを返す（リンクに使えない文字を含む名前はそのまま）
+func obsidianLink(name string) string {
+	if name == "" || strings.ContainsAny(name, "[]|#^") {
+		return name
+	}
+	return "[[" + name + "]]"
+}
+
+// テキストの各行を引用（コールアウトの本文）にする
+func obsidianQuote(s string) string {
+	return "> " + strings.ReplaceAll(s, "\n", "\n> ")
+}
+
+// ラベル名を Obsidian のタグとして使える文字列にする（空白は - に、使えない記号は取り除く）
+func obsidianTag(label string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(label) {
+		switch {
+		case unicode.IsSpace(r):
+			b.WriteRune('-')
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '/':
+			b.WriteRune(r)
+		}
+	}
+	tag := b.String()
+	// A tag needs at least one character that is not a number
+	if strings.IndexFunc(tag, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+		return ""
+	}
+	return tag
+}
+
+// Tokens of the Moment.js date format used by Obsidian daily notes, longest first
+var momentTokens = []struct {
+	token  string
+	layout string
+}{
+	{"YYYY", "2006"},
+	{"YY", "06"},
+	{"MMMM", "January"},
+	{"MMM", "Jan"},
+	{"MM", "01"},
+	{"M", "1"},
+	{"DD", "02"},
+	{"D", "2"},
+	{"dddd", "Monday"},
+	{"ddd", "Mon"},
+}
+
+// DailyNoteName は Obsidian のデイリーノートの書式（Moment.js 形式、例: "YYYY/MM/YYYY-MM-DD"）で日付を整形します
+// [ ] で囲んだ部分はそのまま出力します
+func DailyNoteName(t time.Time, format string) string {
+	var b strings.Builder
+	for len(format) > 0 {
+		if format[0] == '[' {
+			if end := strings.IndexByte(format, ']'); end > 0 {
+				b.WriteString(format[1:end])
+				format = format[end+1:]
+				continue
+			}
+		}
+		matched := false
+		for _, m := range momentTokens {
+			if strings.HasPrefix(format, m.token) {
+				b.WriteString(t.Format(m.layout))
+				format = format[len(m.token):]
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			b.WriteByte(format[0])
+			format = format[1:]
+		}
+	}
+	return b.String()
+}
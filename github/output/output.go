@@ -3,34 +3,153 @@ package output
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
 
 	"git.pepabo.com/yukyan/gh-pric/github/model"
 )
 
+// Involvement types in the order they appear in the markdown report
+var involvementSections = []struct {
+	involvement string
+	label       string
+	optional    bool // Left out of the summary counts when no item has it
+}{
+	{"created", "Created", false},
+	{"assigned", "Assigned", false},
+	{"commented", "Commented", false},
+	{"reviewed", "Reviewed", false},
+	{"mentioned", "Mentioned", false},
+	{"transitioned", "Transitioned", true}, // Jira issues whose status the user changed (--jira-url)
+}
+
+// Options は出力内容を調整する設定です
+type Options struct {
+	// GroupBy selects how markdown item details are grouped: "involvement" (default), "day" or "repo"
+	GroupBy string
+
+	// Sort orders items by "created", "updated", "repo" or "number" (empty = the order they were fetched in)
+	Sort string
+
+	// Order is "asc" or "desc" and applies to Sort
+	Order string
+
+	// Full disables the truncation of bodies and comments and shows every comment
+	Full bool
+
+	// Mermaid embeds a "gantt" or "timeline" chart of PRs at the top of the markdown report (empty = none)
+	Mermaid string
+
+	// SummaryOnly writes the summary and one line per item, without details, commits or other sections
+	SummaryOnly bool
+
+	// LabelAllowlist restricts the per-label breakdown in the summary to these labels (empty = every label)
+	LabelAllowlist []string
+
+	// Emoji prefixes markdown items with type and state icons (🔀 PR, 📝 Issue, 🟢 open, 🟣 merged, 🔴 closed)
+	Emoji bool
+
+	// LabelChips prefixes markdown labels with a colored square close to the label color
+	LabelChips bool
+
+	// MaxTokens progressively reduces the detail of the markdown report until its estimated token count fits (0 = no limit)
+	MaxTokens int
+
+	// Theme renders the markdown report with a named template instead of the default layout (empty = default)
+	Theme string
+
+	// ThemeDir is searched for <theme>.tmpl before the built-in themes
+	ThemeDir string
+
+	// Append adds the markdown report to the end of the file as a dated section instead of overwriting it
+	Append bool
+}
+
 // WriteResults は結果をファイルに出力します
-func WriteResults(items []model.Item, filename, username string, dateRange model.DateRange, format string) error {
+func WriteResults(report model.Report, filename, format string, opts Options) error {
+	formatter, err := lookupFormat(format)
+	if err != nil {
+		return err
+	}
+	report.Items = sortItems(report.Items, opts.Sort, opts.Order)
+
+	if fileFormatter, ok := formatter.(FileFormatter); ok {
+		return fileFormatter.WriteFile(filename, []model.Report{report}, opts)
+	}
+	if appender, ok := formatter.(Appender); ok && opts.Append {
+		return appender.AppendFile(filename, report.DateRange, func(w io.Writer) error {
+			return writeReport(w, report, formatter, opts)
+		})
+	}
+
 	file, err := os.Create(filename)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
+	return writeReport(file, report, formatter, opts)
+}
 
-	// Output based on format
-	switch format {
-	case "json":
-		return writeJSONFormat(file, items)
-	case "md":
-		return writeMarkdownFormat(file, items, username, dateRange)
-	default:
-		return fmt.Errorf("Unsupported output format: %s", format)
+// WriteReport は結果を w に出力します（FileFormatter の形式には使えません）
+func WriteReport(w io.Writer, report model.Report, format string, opts Options) error {
+	formatter, err := lookupFormat(format)
+	if err != nil {
+		return err
 	}
+	report.Items = sortItems(report.Items, opts.Sort, opts.Order)
+	return writeReport(w, report, formatter, opts)
+}
+
+// WriteTeamResults は複数ユーザーの結果をひとつのファイルに出力します
+func WriteTeamResults(team model.TeamReport, filename, format string, opts Options) error {
+	formatter, err := lookupFormat(format)
+	if err != nil {
+		return err
+	}
+	team.Members = sortMembers(team.Members, opts)
+
+	if fileFormatter, ok := formatter.(FileFormatter); ok {
+		return fileFormatter.WriteFile(filename, team.Members, opts)
+	}
+	if appender, ok := formatter.(Appender); ok && opts.Append {
+		return appender.AppendFile(filename, team.DateRange, func(w io.Writer) error {
+			return writeTeamReport(w, team, formatter, opts)
+		})
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return writeTeamReport(file, team, formatter, opts)
+}
+
+// WriteTeamReport は複数ユーザーの結果を w に出力します（FileFormatter の形式には使えません）
+func WriteTeamReport(w io.Writer, team model.TeamReport, format string, opts Options) error {
+	formatter, err := lookupFormat(format)
+	if err != nil {
+		return err
+	}
+	team.Members = sortMembers(team.Members, opts)
+	return writeTeamReport(w, team, formatter, opts)
+}
+
+// メンバーごとの項目を並べ替えたコピーを返す
+func sortMembers(members []model.Report, opts Options) []model.Report {
+	sorted := make([]model.Report, len(members))
+	for i, member := range members {
+		member.Items = sortItems(member.Items, opts.Sort, opts.Order)
+		sorted[i] = member
+	}
+	return sorted
 }
 
 // JSON形式で出力
-func writeJSONFormat(file *os.File, items []model.Item) error {
-	jsonData, err := json.MarshalIndent(items, "", "  ")
+func writeJSONFormat(file io.Writer, v interface{}) error {
+	jsonData, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
 		return err
 	}
@@ -39,131 +158,588 @@ func writeJSONFormat(file *os.File, items []model.Item) error {
 }
 
 // Markdown形式で出力
-func writeMarkdownFormat(file *os.File, items []model.Item, username string, dateRange model.DateRange) error {
+func writeMarkdownFormat(file io.Writer, report model.Report, opts Options) error {
+	if opts.Theme != "" {
+		return writeThemeFormat(file, report.DateRange, []model.Report{report}, opts)
+	}
+
 	// Header information
-	fmt.Fprintf(file, "# GitHub Activity Report - %s\n", username)
+	title := "GitHub Activity Report"
+	if report.Partial {
+		title += " (partial)"
+	}
+	fmt.Fprintf(file, "# %s - %s\n", title, report.Username)
 	fmt.Fprintf(file, "Period: %s to %s\n\n", 
-		dateRange.StartDate.Format("2006-01-02"), 
-		dateRange.EndDate.Format("2006-01-02"))
+		report.DateRange.StartDate.Format("2006-01-02"), 
+		report.DateRange.EndDate.Format("2006-01-02"))
 
-	// Create summary
-	fmt.Fprintf(file, "## Summary\n")
-	fmt.Fprintf(file, "- Total items: %d\n", len(items))
+	writeMarkdownBody(file, report, 2, opts)
+	return nil
+}
 
-	// Count by type
-	prCount := 0
-	issueCount := 0
+// Markdown 形式で出力（--max-tokens が指定されていれば予算内に収める）
+func writeMarkdownReport(file io.Writer, report model.Report, opts Options) error {
+	if opts.MaxTokens > 0 {
+		return writeWithinBudget(file, []model.Report{report}, opts, func(w io.Writer, reports []model.Report, opts Options) error {
+			return writeMarkdownFormat(w, reports[0], opts)
+		})
+	}
+	return writeMarkdownFormat(file, report, opts)
+}
+
+// チームレポートを Markdown 形式で出力（--max-tokens が指定されていれば予算内に収める）
+func writeTeamMarkdownReport(file io.Writer, team model.TeamReport, opts Options) error {
+	if opts.MaxTokens > 0 {
+		return writeWithinBudget(file, team.Members, opts, func(w io.Writer, reports []model.Report, opts Options) error {
+			team.Members = reports
+			return writeTeamMarkdownFormat(w, team, opts)
+		})
+	}
+	return writeTeamMarkdownFormat(file, team, opts)
+}
+
+// チームレポートをMarkdown形式で出力
+func writeTeamMarkdownFormat(file io.Writer, team model.TeamReport, opts Options) error {
+	if opts.Theme != "" {
+		return writeThemeFormat(file, team.DateRange, team.Members, opts)
+	}
+
+	usernames := make([]string, len(team.Members))
+	for i, member := range team.Members {
+		usernames[i] = member.Username
+	}
+
+	// Header information
+	fmt.Fprintf(file, "# GitHub Team Activity Report - %s\n", strings.Join(usernames, ", "))
+	fmt.Fprintf(file, "Period: %s to %s\n\n",
+		team.DateRange.StartDate.Format("2006-01-02"),
+		team.DateRange.EndDate.Format("2006-01-02"))
+
+	// Team-level summary, one row per user
+	fmt.Fprintf(file, "## Team Summary\n\n")
+	fmt.Fprintf(file, "| User | Items | PRs | Merged PRs | Issues | Commits |\n")
+	fmt.Fprintf(file, "|------|-------|-----|------------|--------|---------|\n")
+	var allItems []model.Item
+	var allCommits []model.Commit
+	for _, member := range team.Members {
+		c := countItems(member.Items)
+		fmt.Fprintf(file, "| %s | %d | %d | %d | %d | %d |\n",
+			member.Username, len(member.Items), c.prs, c.merged, c.issues, len(member.Commits))
+		allItems = append(allItems, member.Items...)
+		allCommits = append(allCommits, member.Commits...)
+	}
+
+	// Items and commits shared by several users are only counted once in the total
+	uniqueItems := make(map[string]model.Item)
+	for _, item := range allItems {
+		uniqueItems[item.Key()] = item
+	}
+	var teamItems []model.Item
+	for _, item := range uniqueItems {
+		teamItems = append(teamItems, item)
+	}
+	uniqueCommits := make(map[string]bool)
+	for _, commit := range allCommits {
+		uniqueCommits[commit.SHA] = true
+	}
+	c := countItems(teamItems)
+	fmt.Fprintf(file, "| **Team (unique)** | %d | %d | %d | %d | %d |\n\n",
+		len(teamItems), c.prs, c.merged, c.issues, len(uniqueCommits))
+
+	// Per-user sections
+	for _, member := range team.Members {
+		if member.Partial {
+			fmt.Fprintf(file, "## %s (partial)\n\n", member.Username)
+		} else {
+			fmt.Fprintf(file, "## %s\n\n", member.Username)
+		}
+		writeMarkdownBody(file, member, 3, opts)
+	}
+
+	return nil
+}
+
+// Struct to hold item counts by type
+type itemCounts struct {
+	prs    int
+	merged int
+	issues int
+	gists  int
+	jira   int
+
+	dependencyUpdates int
+}
+
+// アイテムを種類ごとに数える
+func countItems(items []model.Item) itemCounts {
+	var c itemCounts
 	for _, item := range items {
+		if item.IsDependencyUpdate() {
+			c.dependencyUpdates++
+		}
 		if item.Type == "PR" {
-			prCount++
+			c.prs++
+			if item.State == "merged" {
+				c.merged++
+			}
 		} else if item.Type == "Issue" {
-			issueCount++
+			c.issues++
+		} else if item.Type == "Gist" {
+			c.gists++
+		} else if item.Type == "Jira" {
+			c.jira++
 		}
 	}
-	fmt.Fprintf(file, "- Number of PRs: %d\n", prCount)
-	fmt.Fprintf(file, "- Number of Issues: %d\n\n", issueCount)
+	return c
+}
 
-	// Count by involvement type
-	created := 0
-	assigned := 0
-	commented := 0
-	reviewed := 0
-	for _, item := range items {
-		switch item.Involvement {
-		case "created":
-			created++
-		case "assigned":
-			assigned++
-		case "commented":
-			commented++
-		case "reviewed":
-			reviewed++
-		}
-	}
-	fmt.Fprintf(file, "- Created items: %d\n", created)
-	fmt.Fprintf(file, "- Assigned items: %d\n", assigned)
-	fmt.Fprintf(file, "- Commented items: %d\n", commented)
-	fmt.Fprintf(file, "- Reviewed items: %d\n\n", reviewed)
+// レポート本文（警告、サマリー、詳細、コミット）を指定した見出しレベルで書き出す
+func writeMarkdownBody(file io.Writer, report model.Report, level int, opts Options) {
+	items := report.Items
+	heading := strings.Repeat("#", level)
+	subheading := strings.Repeat("#", level+1)
 
-	// Detailed list of items
-	fmt.Fprintf(file, "## Item Details\n\n")
-	
-	// First, created items
-	if created > 0 {
-		fmt.Fprintf(file, "### Created Items\n\n")
+	// Warnings about incomplete data
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(file, "> **Warning:** %s\n", warning)
+	}
+	if len(report.Warnings) > 0 {
+		fmt.Fprintln(file, "")
+	}
+
+	// Generated summary (--summarize)
+	if report.Overview != "" {
+		fmt.Fprintf(file, "%s Overview\n%s\n\n", heading, report.Overview)
+	}
+
+	if opts.Mermaid != "" {
+		writeMermaidChart(file, items, report.DateRange, opts.Mermaid)
+	}
+
+	// Changes against the previous period are appended when it was fetched
+	var previous itemCounts
+	var previousItems, previousCommits int
+	if report.Previous != nil {
+		previous = countItems(report.Previous.Items)
+		previousItems = len(report.Previous.Items)
+		previousCommits = len(report.Previous.Commits)
+	}
+	trend := func(current, previousValue int) string {
+		if report.Previous == nil {
+			return ""
+		}
+		return " " + formatTrend(current, previousValue)
+	}
+
+	// Create summary
+	fmt.Fprintf(file, "%s Summary\n", heading)
+	if report.Previous != nil {
+		fmt.Fprintf(file, "- Previous period: %s to %s\n",
+			report.Previous.DateRange.StartDate.Format("2006-01-02"),
+			report.Previous.DateRange.EndDate.Format("2006-01-02"))
+	}
+	fmt.Fprintf(file, "- Total items: %d%s\n", len(items), trend(len(items), previousItems))
+
+	// Count by type
+	counts := countItems(items)
+	fmt.Fprintf(file, "- Number of PRs: %d%s\n", counts.prs, trend(counts.prs, previous.prs))
+	fmt.Fprintf(file, "- Number of merged PRs: %d%s\n", counts.merged, trend(counts.merged, previous.merged))
+	fmt.Fprintf(file, "- Number of Issues: %d%s\n", counts.issues, trend(counts.issues, previous.issues))
+	if counts.dependencyUpdates > 0 {
+		fmt.Fprintf(file, "- Number of dependency updates: %d\n", counts.dependencyUpdates)
+	}
+	if counts.gists > 0 {
+		fmt.Fprintf(file, "- Number of Gists: %d\n", counts.gists)
+	}
+	if counts.jira > 0 {
+		fmt.Fprintf(file, "- Number of Jira issues: %d\n", counts.jira)
+	}
+	fmt.Fprintf(file, "- Number of commits: %d%s\n", len(report.Commits), trend(len(report.Commits), previousCommits))
+	if len(report.Repositories) > 0 {
+		fmt.Fprintf(file, "- Number of new repositories: %d\n", len(report.Repositories))
+	}
+	if len(report.WorkflowRuns) > 0 {
+		fmt.Fprintf(file, "- Number of workflow runs: %d\n", len(report.WorkflowRuns))
+	}
+	fmt.Fprintln(file, "")
+
+	// Count by involvement type (an item counts once for each of its involvements)
+	for _, section := range involvementSections {
+		count := 0
 		for _, item := range items {
-			if item.Involvement == "created" {
-				writeItemDetails(file, item)
+			if item.HasInvolvement(section.involvement) {
+				count++
 			}
 		}
+		if section.optional && count == 0 {
+			continue
+		}
+		fmt.Fprintf(file, "- %s items: %d\n", section.label, count)
 	}
-	
-	// Assigned items
-	if assigned > 0 {
-		fmt.Fprintf(file, "### Assigned Items\n\n")
-		for _, item := range items {
-			if item.Involvement == "assigned" {
-				writeItemDetails(file, item)
+	fmt.Fprintln(file, "")
+
+	writeLabelBreakdown(file, items, opts.LabelAllowlist)
+	writeHeatmap(file, items, report.DateRange)
+	writeMetrics(file, report, heading)
+
+	// One line per item is enough for a quick glance
+	if opts.SummaryOnly {
+		writeItemLines(file, items, heading, opts)
+		return
+	}
+
+	// Detailed list of items
+	fmt.Fprintf(file, "%s Item Details\n\n", heading)
+
+	// Dependency updates are collapsed into one line per repository
+	var regularItems, dependencyUpdates []model.Item
+	for _, item := range items {
+		if item.IsDependencyUpdate() {
+			dependencyUpdates = append(dependencyUpdates, item)
+		} else {
+			regularItems = append(regularItems, item)
+		}
+	}
+
+	switch opts.GroupBy {
+	case "day":
+		writeItemsByDay(file, regularItems, report.Meetings, report.DateRange, subheading)
+	case "repo":
+		writeItemsByRepo(file, regularItems, subheading, opts)
+	default:
+		// Each item is listed once, under the section of its primary involvement
+		for _, section := range involvementSections {
+			var sectionItems []model.Item
+			for _, item := range regularItems {
+				if len(item.Involvements) > 0 && item.Involvements[0] == section.involvement {
+					sectionItems = append(sectionItems, item)
+				}
+			}
+			if len(sectionItems) == 0 {
+				continue
+			}
+
+			fmt.Fprintf(file, "%s %s Items\n\n", subheading, section.label)
+			for _, item := range sectionItems {
+				writeItemDetails(file, item, opts)
 			}
 		}
 	}
-	
-	// Commented items
-	if commented > 0 {
-		fmt.Fprintf(file, "### Commented Items\n\n")
-		for _, item := range items {
-			if item.Involvement == "commented" {
-				writeItemDetails(file, item)
+
+	writeDependencyUpdates(file, dependencyUpdates, subheading)
+	writeRepositories(file, report.Repositories, level)
+	writeWorkflowRuns(file, report.WorkflowRuns, level)
+	writeCommits(file, report.Commits, level)
+}
+
+// アイテムを1行ずつ書き出す
+func writeItemLines(file io.Writer, items []model.Item, heading string, opts Options) {
+	if len(items) == 0 {
+		return
+	}
+
+	fmt.Fprintf(file, "%s Items\n\n", heading)
+	for _, item := range items {
+		prefix := "- "
+		if opts.Emoji {
+			prefix += itemEmoji(item.Type, item.State) + " "
+		}
+		if item.Type == "Gist" {
+			fmt.Fprintf(file, "%s[Gist] %s (%s)\n", prefix, item.Title, item.URL)
+			continue
+		}
+		fmt.Fprintf(file, "%s[%s #%d] %s (%s, %s) %s\n", prefix, item.Type, item.Number, item.Title, item.Repository, item.State, item.URL)
+	}
+	fmt.Fprintln(file, "")
+}
+
+// アイテムをリポジトリごとにまとめ、件数と一緒に書き出す
+func writeItemsByRepo(file io.Writer, items []model.Item, heading string, opts Options) {
+	// Group items by repository; Gists belong to none and come last
+	byRepo := make(map[string][]model.Item)
+	var repos []string
+	for _, item := range items {
+		if _, ok := byRepo[item.Repository]; !ok {
+			repos = append(repos, item.Repository)
+		}
+		byRepo[item.Repository] = append(byRepo[item.Repository], item)
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		if repos[i] == "" || repos[j] == "" {
+			return repos[j] == ""
+		}
+		return repos[i] < repos[j]
+	})
+
+	for _, repo := range repos {
+		repoItems := byRepo[repo]
+		c := countItems(repoItems)
+		var parts []string
+		if c.prs > 0 {
+			parts = append(parts, fmt.Sprintf("%d PRs (%d merged)", c.prs, c.merged))
+		}
+		if c.issues > 0 {
+			parts = append(parts, fmt.Sprintf("%d Issues", c.issues))
+		}
+		if c.gists > 0 {
+			parts = append(parts, fmt.Sprintf("%d Gists", c.gists))
+		}
+
+		name := repo
+		if name == "" {
+			name = "Gists"
+		}
+		fmt.Fprintf(file, "%s %s (%s)\n\n", heading, name, strings.Join(parts, ", "))
+		for _, item := range repoItems {
+			writeItemDetails(file, item, opts)
+		}
+	}
+}
+
+// ワークフローの実行をリポジトリごとにまとめて書き出す
+func writeWorkflowRuns(file io.Writer, runs []model.WorkflowRun, level int) {
+	if len(runs) == 0 {
+		return
+	}
+
+	// Group runs by repository
+	byRepo := make(map[string][]model.WorkflowRun)
+	var repos []string
+	for _, run := range runs {
+		if _, ok := byRepo[run.Repository]; !ok {
+			repos = append(repos, run.Repository)
+		}
+		byRepo[run.Repository] = append(byRepo[run.Repository], run)
+	}
+	sort.Strings(repos)
+
+	fmt.Fprintf(file, "%s Workflow Runs\n\n", strings.Repeat("#", level))
+	for _, repo := range repos {
+		fmt.Fprintf(file, "%s %s (%d)\n\n", strings.Repeat("#", level+1), repo, len(byRepo[repo]))
+		for _, run := range byRepo[repo] {
+			result := run.Conclusion
+			if result == "" {
+				result = run.Status
 			}
+			fmt.Fprintf(file, "- [%s #%d](%s) %s on %s, %s (%s)\n",
+				run.Name, run.Number, run.URL, run.Event, run.Branch, result, run.CreatedAt.Format("2006-01-02"))
 		}
+		fmt.Fprintln(file, "")
 	}
-	
-	// Reviewed items
-	if reviewed > 0 {
-		fmt.Fprintf(file, "### Reviewed Items\n\n")
-		for _, item := range items {
-			if item.Involvement == "reviewed" {
-				writeItemDetails(file, item)
+}
+
+// 依存関係更新の PR をリポジトリごとに件数だけ書き出す
+func writeDependencyUpdates(file io.Writer, items []model.Item, heading string) {
+	if len(items) == 0 {
+		return
+	}
+
+	// Count updates per repository and state
+	byRepo := make(map[string]map[string]int)
+	var repos []string
+	for _, item := range items {
+		if _, ok := byRepo[item.Repository]; !ok {
+			byRepo[item.Repository] = make(map[string]int)
+			repos = append(repos, item.Repository)
+		}
+		byRepo[item.Repository][item.State]++
+	}
+	sort.Strings(repos)
+
+	fmt.Fprintf(file, "%s Dependency Updates\n\n", heading)
+	for _, repo := range repos {
+		total := 0
+		var parts []string
+		for _, state := range []string{"merged", "open", "closed"} {
+			if count := byRepo[repo][state]; count > 0 {
+				total += count
+				parts = append(parts, fmt.Sprintf("%s %d", state, count))
 			}
 		}
+		fmt.Fprintf(file, "- %s: %d dependency updates (%s)\n", repo, total, strings.Join(parts, ", "))
 	}
+	fmt.Fprintln(file, "")
+}
 
-	return nil
+// 期間内に作成したリポジトリを書き出す
+func writeRepositories(file io.Writer, repositories []model.Repository, level int) {
+	if len(repositories) == 0 {
+		return
+	}
+
+	fmt.Fprintf(file, "%s New repositories\n\n", strings.Repeat("#", level))
+	for _, repo := range repositories {
+		line := fmt.Sprintf("- [%s](%s) (%s)", repo.Name, repo.URL, repo.CreatedAt.Format("2006-01-02"))
+		if repo.Fork {
+			line += " (fork)"
+		}
+		if repo.Description != "" {
+			line += ": " + repo.Description
+		}
+		fmt.Fprintln(file, line)
+	}
+	fmt.Fprintln(file, "")
+}
+
+// コミットをリポジトリごとにまとめて書き出す
+func writeCommits(file io.Writer, commits []model.Commit, level int) {
+	if len(commits) == 0 {
+		return
+	}
+
+	// Group commits by repository
+	byRepo := make(map[string][]model.Commit)
+	var repos []string
+	for _, commit := range commits {
+		if _, ok := byRepo[commit.Repository]; !ok {
+			repos = append(repos, commit.Repository)
+		}
+		byRepo[commit.Repository] = append(byRepo[commit.Repository], commit)
+	}
+	sort.Strings(repos)
+
+	fmt.Fprintf(file, "%s Commits\n\n", strings.Repeat("#", level))
+	for _, repo := range repos {
+		fmt.Fprintf(file, "%s %s (%d)\n\n", strings.Repeat("#", level+1), repo, len(byRepo[repo]))
+		for _, commit := range byRepo[repo] {
+			fmt.Fprintf(file, "- [`%s`](%s) %s (%s)\n",
+				shortSHA(commit.SHA), commit.URL, commitSubject(commit.Message), commit.AuthoredAt.Format("2006-01-02"))
+		}
+		fmt.Fprintln(file, "")
+	}
 }
 
 // アイテムの詳細をファイルに書き出す
-func writeItemDetails(file *os.File, item model.Item) {
-	fmt.Fprintf(file, "- [%s #%d] %s\n", item.Type, item.Number, item.Title)
+func writeItemDetails(file io.Writer, item model.Item, opts Options) {
+	prefix := "- "
+	if opts.Emoji {
+		prefix += itemEmoji(item.Type, item.State) + " "
+	}
+	if item.Type == "Gist" {
+		fmt.Fprintf(file, "%s[Gist] %s\n", prefix, item.Title)
+	} else {
+		fmt.Fprintf(file, "%s[%s #%d] %s\n", prefix, item.Type, item.Number, item.Title)
+	}
 	fmt.Fprintf(file, "  - URL: %s\n", item.URL)
-	fmt.Fprintf(file, "  - Repository: %s\n", item.Repository)
+	if item.Repository != "" {
+		fmt.Fprintf(file, "  - Repository: %s\n", item.Repository)
+	}
 	fmt.Fprintf(file, "  - State: %s\n", item.State)
+	if len(item.Involvements) > 1 {
+		fmt.Fprintf(file, "  - Involvement: %s\n", strings.Join(item.Involvements, ", "))
+	}
 	fmt.Fprintf(file, "  - Created on: %s\n", item.CreatedAt.Format("2006-01-02"))
 	fmt.Fprintf(file, "  - Updated on: %s\n", item.UpdatedAt.Format("2006-01-02"))
+	if !item.ClosedAt.IsZero() {
+		fmt.Fprintf(file, "  - Closed on: %s\n", item.ClosedAt.Format("2006-01-02"))
+	}
+	if item.ChecksState != "" {
+		fmt.Fprintf(file, "  - Checks: %s\n", item.ChecksState)
+	}
+	if len(item.Files) > 0 {
+		fmt.Fprintf(file, "  - Files: %s\n", strings.Join(item.Files, ", "))
+	}
+	if item.Type == "PR" && item.ChangedFiles > 0 {
+		fmt.Fprintf(file, "  - Changes: +%d/-%d across %d files\n", item.Additions, item.Deletions, item.ChangedFiles)
+	}
 	
 	if len(item.Assignees) > 0 {
 		fmt.Fprintf(file, "  - Assignees: %s\n", strings.Join(item.Assignees, ", "))
 	}
 	
 	if len(item.Labels) > 0 {
-		fmt.Fprintf(file, "  - Labels: %s\n", strings.Join(item.Labels, ", "))
+		labels := item.Labels
+		if opts.LabelChips {
+			labels = make([]string, len(item.Labels))
+			for i, label := range item.Labels {
+				labels[i] = labelWithChip(label, item.LabelColors[label])
+			}
+		}
+		fmt.Fprintf(file, "  - Labels: %s\n", strings.Join(labels, ", "))
+	}
+
+	for _, project := range item.Projects {
+		var fields []string
+		if project.Status != "" {
+			fields = append(fields, "Status: "+project.Status)
+		}
+		if project.Iteration != "" {
+			fields = append(fields, "Iteration: "+project.Iteration)
+		}
+		if len(fields) > 0 {
+			fmt.Fprintf(file, "  - Project: %s (%s)\n", project.Project, strings.Join(fields, ", "))
+		} else {
+			fmt.Fprintf(file, "  - Project: %s\n", project.Project)
+		}
+	}
+
+	if item.Reactions.Total() > 0 {
+		fmt.Fprintf(file, "  - Reactions: %s\n", formatReactions(item.Reactions))
 	}
 
 	// Output the body
 	if item.Body != "" {
 		// If the body is long, truncate it appropriately
 		body := item.Body
-		if len(body) > 300 {
+		if !opts.Full && len(body) > 300 {
 			body = body[:300] + "..."
 		}
 		fmt.Fprintf(file, "  - Body:\n    %s\n", strings.ReplaceAll(body, "\n", "\n    "))
 	}
 	
+	// Output PR commits
+	if len(item.Commits) > 0 {
+		fmt.Fprintf(file, "  - Commits (%d):\n", len(item.Commits))
+		for _, commit := range item.Commits {
+			fmt.Fprintf(file, "    - `%s` %s\n", shortSHA(commit.SHA), commitSubject(commit.Message))
+		}
+	}
+
+	// Output reviews
+	if len(item.Reviews) > 0 {
+		fmt.Fprintf(file, "  - Reviews (%d):\n", len(item.Reviews))
+		for _, review := range item.Reviews {
+			fmt.Fprintf(file, "    - %s: %s (%s)\n",
+				review.Author,
+				review.State,
+				review.SubmittedAt.Format("2006-01-02"))
+		}
+	}
+
+	// Output items that referenced this one
+	if len(item.ReferencedBy) > 0 {
+		fmt.Fprintf(file, "  - Referenced by (%d):\n", len(item.ReferencedBy))
+		for _, ref := range item.ReferencedBy {
+			fmt.Fprintf(file, "    - [%s #%d] %s (%s, %s)\n",
+				ref.Type,
+				ref.Number,
+				ref.Title,
+				ref.Repository,
+				ref.ReferencedAt.Format("2006-01-02"))
+		}
+	}
+
+	// Output timeline events as a compact log
+	if len(item.Events) > 0 {
+		fmt.Fprintf(file, "  - Events (%d):\n", len(item.Events))
+		for _, event := range item.Events {
+			line := fmt.Sprintf("%s %s %s", event.CreatedAt.Format("2006-01-02"), event.Actor, event.Type)
+			if event.Detail != "" {
+				line += " " + event.Detail
+			}
+			fmt.Fprintf(file, "    - %s\n", line)
+		}
+	}
+
 	// Output comments
 	if len(item.Comments) > 0 {
 		fmt.Fprintf(file, "  - Comments (%d):\n", len(item.Comments))
 		
 		// Limit the number of comments displayed
 		maxComments := 5
+		if opts.Full {
+			maxComments = len(item.Comments)
+		}
 		if len(item.Comments) > maxComments {
 			fmt.Fprintf(file, "    (Only the first %d shown)\n", maxComments)
 		}
@@ -176,18 +752,103 @@ func writeItemDetails(file *os.File, item model.Item) {
 			
 			// If the comment body is long, truncate it appropriately
 			body := comment.Body
-			if len(body) > 200 {
+			if !opts.Full && len(body) > 200 {
 				body = body[:200] + "..."
 			}
 			
-			fmt.Fprintf(file, "    - %s (%s):\n      %s\n", 
+			reactions := ""
+			if comment.Reactions.Total() > 0 {
+				reactions = " [" + formatReactions(comment.Reactions) + "]"
+			}
+
+			fmt.Fprintf(file, "    - %s (%s)%s:\n      %s\n", 
 				comment.Author, 
 				comment.CreatedAt.Format("2006-01-02"),
+				reactions,
 				strings.ReplaceAll(body, "\n", "\n      "))
+			if comment.Path != "" {
+				writeReviewContext(file, comment, opts)
+			}
 			
 			count++
 		}
 	}
 	
 	fmt.Fprintln(file, "")
-} 
+}
+
+// 前の期間からの増減を矢印付きで整形する
+func formatTrend(current, previous int) string {
+	switch {
+	case current > previous:
+		return fmt.Sprintf("(▲%d vs previous period)", current-previous)
+	case current < previous:
+		return fmt.Sprintf("(▼%d vs previous period)", previous-current)
+	default:
+		return "(±0 vs previous period)"
+	}
+}
+
+// Lines of the diff hunk shown above a review comment
+const diffContextLines = 4
+
+// レビューコメントの対象ファイル・行と差分を小さなコードブロックとして書き出す
+func writeReviewContext(file io.Writer, comment model.Comment, opts Options) {
+	location := comment.Path
+	if comment.Line > 0 {
+		location = fmt.Sprintf("%s:%d", comment.Path, comment.Line)
+	}
+	fmt.Fprintf(file, "      `%s`\n", location)
+	if comment.DiffHunk == "" {
+		return
+	}
+
+	// The commented line is the last one of the hunk
+	lines := strings.Split(strings.TrimRight(comment.DiffHunk, "\n"), "\n")
+	if !opts.Full && len(lines) > diffContextLines {
+		lines = lines[len(lines)-diffContextLines:]
+	}
+	fmt.Fprintf(file, "      ```diff\n")
+	for _, line := range lines {
+		fmt.Fprintf(file, "      %s\n", line)
+	}
+	fmt.Fprintf(file, "      ```\n")
+}
+
+// コミットSHAの短縮形を返す
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// コミットメッセージの1行目を返す
+func commitSubject(message string) string {
+	return strings.SplitN(message, "\n", 2)[0]
+}
+
+// リアクションの件数を絵文字付きで整形する（0件のものは省略）
+func formatReactions(r model.Reactions) string {
+	counts := []struct {
+		emoji string
+		count int
+	}{
+		{"👍", r.ThumbsUp},
+		{"👎", r.ThumbsDown},
+		{"😄", r.Laugh},
+		{"🎉", r.Hooray},
+		{"😕", r.Confused},
+		{"❤️", r.Heart},
+		{"🚀", r.Rocket},
+		{"👀", r.Eyes},
+	}
+
+	var parts []string
+	for _, c := range counts {
+		if c.count > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", c.emoji, c.count))
+		}
+	}
+	return strings.Join(parts, " ")
+}
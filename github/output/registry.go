@@ -0,0 +1,283 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// Formatter はレポートを 1 つの出力形式で書き出します
+// Register で名前を付けて登録すると、WriteReport などの format にその名前を指定できるようになります
+type Formatter interface {
+	Format(w io.Writer, report *model.Report, opts Options) error
+}
+
+// TeamFormatter は複数ユーザーのレポートを独自のレイアウトで書き出す Formatter が実装します
+// 実装していない Formatter では、メンバーごとのレポートが空行を挟んで順に書き出されます
+type TeamFormatter interface {
+	FormatTeam(w io.Writer, team *model.TeamReport, opts Options) error
+}
+
+// ContentTyper は出力の MIME タイプを返す Formatter が実装します
+// gh pric serve は実装している形式だけを返し、--open はテキストの形式をエディタで開きます
+type ContentTyper interface {
+	ContentType() string
+}
+
+// FileFormatter は書き出し先のファイルを自分で扱う Formatter が実装します
+// WriteResults と WriteTeamResults はファイルを作り直さずに WriteFile を呼ぶので、既存のファイルに追記する形式（sqlite）に使います
+// io.Writer には書き出せないため、WriteReport と WriteTeamReport ではエラーになります
+type FileFormatter interface {
+	WriteFile(filename string, reports []model.Report, opts Options) error
+}
+
+// Appender は Options.Append で既存のファイルの末尾に追記できる Formatter が実装します
+// render は Format または FormatTeam が書き出す内容を w に書き出します
+type Appender interface {
+	AppendFile(filename string, dateRange model.DateRange, render func(w io.Writer) error) error
+}
+
+// Streamer は取得した項目を取得中にファイルへ書き出せる Formatter が実装します
+type Streamer interface {
+	NewStream(filename string) (ItemWriter, error)
+}
+
+// ItemWriter は Streamer が返す書き出し先です
+type ItemWriter interface {
+	// Write は項目を 1 件書き出します（複数の goroutine から呼び出せます）
+	Write(username string, item model.Item)
+	// Close は書き出しを終え、途中で発生したエラーがあれば返します
+	Close() error
+}
+
+// Checker は出力に必要なもの（外部コマンドなど）が揃っているかを、取得を始める前に確かめる Formatter が実装します
+type Checker interface {
+	Check() error
+}
+
+var (
+	formattersMu sync.RWMutex
+	formatters   = make(map[string]Formatter)
+)
+
+// Register は出力形式を登録します（登録済みの名前を指定すると、その形式を置き換えます）
+func Register(name string, formatter Formatter) {
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+	if formatter == nil {
+		panic("output: Register formatter is nil")
+	}
+	formatters[name] = formatter
+}
+
+// Lookup は登録されている出力形式を返します
+func Lookup(name string) (Formatter, bool) {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+	formatter, ok := formatters[name]
+	return formatter, ok
+}
+
+// Formats は登録されている出力形式の名前を名前順に返します
+func Formats() []string {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register("md", MarkdownFormatter{})
+	Register("json", JSONFormatter{})
+	Register("csv", CSVFormatter{})
+	Register("xlsx", XLSXFormatter{})
+	Register("confluence", ConfluenceFormatter{})
+	Register("obsidian", ObsidianFormatter{})
+	Register("standup", StandupFormatter{})
+	Register("jsonl", JSONLFormatter{})
+	Register("sqlite", SQLiteFormatter{})
+}
+
+// 登録されている形式を返す
+func lookupFormat(format string) (Formatter, error) {
+	formatter, ok := Lookup(format)
+	if !ok {
+		return nil, fmt.Errorf("Unsupported output format: %s", format)
+	}
+	return formatter, nil
+}
+
+// 登録された形式で結果を書き出す
+func writeReport(file io.Writer, report model.Report, formatter Formatter, opts Options) error {
+	return formatter.Format(file, &report, opts)
+}
+
+// 登録された形式で複数ユーザーの結果を書き出す
+func writeTeamReport(file io.Writer, team model.TeamReport, formatter Formatter, opts Options) error {
+	if teamFormatter, ok := formatter.(TeamFormatter); ok {
+		return teamFormatter.FormatTeam(file, &team, opts)
+	}
+	for i := range team.Members {
+		if i > 0 {
+			fmt.Fprintln(file)
+		}
+		if err := formatter.Format(file, &team.Members[i], opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarkdownFormatter は Markdown 形式（"md"）です
+type MarkdownFormatter struct{}
+
+func (MarkdownFormatter) Format(w io.Writer, report *model.Report, opts Options) error {
+	return writeMarkdownReport(w, *report, opts)
+}
+
+func (MarkdownFormatter) FormatTeam(w io.Writer, team *model.TeamReport, opts Options) error {
+	return writeTeamMarkdownReport(w, *team, opts)
+}
+
+func (MarkdownFormatter) ContentType() string {
+	return "text/markdown; charset=utf-8"
+}
+
+func (MarkdownFormatter) AppendFile(filename string, dateRange model.DateRange, render func(w io.Writer) error) error {
+	return appendJournal(filename, dateRange, render)
+}
+
+// JSONFormatter は項目の JSON 配列（"json"）です。チームレポートはメンバーのレポートの配列になります
+type JSONFormatter struct{}
+
+func (JSONFormatter) Format(w io.Writer, report *model.Report, opts Options) error {
+	return writeJSONFormat(w, report.Items)
+}
+
+func (JSONFormatter) FormatTeam(w io.Writer, team *model.TeamReport, opts Options) error {
+	return writeJSONFormat(w, team.Members)
+}
+
+func (JSONFormatter) ContentType() string {
+	return "application/json"
+}
+
+// CSVFormatter は 1 項目 1 行の CSV（"csv"）です
+type CSVFormatter struct{}
+
+func (CSVFormatter) Format(w io.Writer, report *model.Report, opts Options) error {
+	return writeCSVFormat(w, []model.Report{*report}, false)
+}
+
+func (CSVFormatter) FormatTeam(w io.Writer, team *model.TeamReport, opts Options) error {
+	return writeCSVFormat(w, team.Members, true)
+}
+
+func (CSVFormatter) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+// XLSXFormatter は Excel のブック（"xlsx"）です
+type XLSXFormatter struct{}
+
+func (XLSXFormatter) Format(w io.Writer, report *model.Report, opts Options) error {
+	return writeXLSXFormat(w, []model.Report{*report})
+}
+
+func (XLSXFormatter) FormatTeam(w io.Writer, team *model.TeamReport, opts Options) error {
+	return writeXLSXFormat(w, team.Members)
+}
+
+func (XLSXFormatter) ContentType() string {
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+// ConfluenceFormatter は Confluence のストレージ形式（"confluence"）です
+type ConfluenceFormatter struct{}
+
+func (ConfluenceFormatter) Format(w io.Writer, report *model.Report, opts Options) error {
+	return writeConfluenceFormat(w, *report, opts)
+}
+
+func (ConfluenceFormatter) FormatTeam(w io.Writer, team *model.TeamReport, opts Options) error {
+	return writeTeamConfluenceFormat(w, *team, opts)
+}
+
+func (ConfluenceFormatter) ContentType() string {
+	return "application/xml; charset=utf-8"
+}
+
+// ObsidianFormatter は Obsidian のデイリーノート（"obsidian"）です
+type ObsidianFormatter struct{}
+
+func (ObsidianFormatter) Format(w io.Writer, report *model.Report, opts Options) error {
+	return writeObsidianFormat(w, *report, opts)
+}
+
+func (ObsidianFormatter) FormatTeam(w io.Writer, team *model.TeamReport, opts Options) error {
+	return writeTeamObsidianFormat(w, *team, opts)
+}
+
+func (ObsidianFormatter) ContentType() string {
+	return "text/markdown; charset=utf-8"
+}
+
+// StandupFormatter はスタンドアップの 3 項目（"standup"）です
+type StandupFormatter struct{}
+
+func (StandupFormatter) Format(w io.Writer, report *model.Report, opts Options) error {
+	return writeStandupFormat(w, *report)
+}
+
+func (StandupFormatter) FormatTeam(w io.Writer, team *model.TeamReport, opts Options) error {
+	return writeTeamStandupFormat(w, *team)
+}
+
+func (StandupFormatter) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+// JSONLFormatter は 1 行 1 項目の JSON Lines（"jsonl"）です。各行にはユーザー名の User が付きます
+// ファイルへは取得中の項目がすぐに書き出されます
+type JSONLFormatter struct{}
+
+func (JSONLFormatter) Format(w io.Writer, report *model.Report, opts Options) error {
+	return writeJSONLFormat(w, []model.Report{*report})
+}
+
+func (JSONLFormatter) FormatTeam(w io.Writer, team *model.TeamReport, opts Options) error {
+	return writeJSONLFormat(w, team.Members)
+}
+
+func (JSONLFormatter) ContentType() string {
+	return "application/x-ndjson"
+}
+
+func (JSONLFormatter) NewStream(filename string) (ItemWriter, error) {
+	return NewJSONLWriter(filename)
+}
+
+// SQLiteFormatter は SQLite のデータベース（"sqlite"）です。実行ごとに既存のデータベースへ追記します
+// 書き出しには PATH にある sqlite3 コマンドを使います
+type SQLiteFormatter struct{}
+
+func (SQLiteFormatter) Format(w io.Writer, report *model.Report, opts Options) error {
+	return errors.New("the sqlite output format can only be written to a file")
+}
+
+func (SQLiteFormatter) WriteFile(filename string, reports []model.Report, opts Options) error {
+	return writeSQLiteFormat(filename, reports)
+}
+
+func (SQLiteFormatter) Check() error {
+	_, err := sqlite3Path()
+	return err
+}
@@ -0,0 +1,44 @@
+package output
+
+import (
+	"sort"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// アイテムを指定したキーと順序で並べ替えたコピーを返します（キーが空の場合は取得順のまま）
+func sortItems(items []model.Item, key, order string) []model.Item {
+	if key == "" {
+		return items
+	}
+
+	less := func(a, b model.Item) bool {
+		switch key {
+		case "created":
+			return a.CreatedAt.Before(b.CreatedAt)
+		case "updated":
+			return a.UpdatedAt.Before(b.UpdatedAt)
+		case "repo":
+			if a.Repository != b.Repository {
+				return a.Repository < b.Repository
+			}
+			return a.Number < b.Number
+		case "number":
+			if a.Number != b.Number {
+				return a.Number < b.Number
+			}
+			return a.Repository < b.Repository
+		}
+		return false
+	}
+
+	sorted := make([]model.Item, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if order == "desc" {
+			return less(sorted[j], sorted[i])
+		}
+		return less(sorted[i], sorted[j])
+	})
+	return sorted
+}
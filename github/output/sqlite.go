@@ -0,0 +1,144 @@
+package output
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// Tables of the SQLite output; every run is appended so several reports can be queried together
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS runs (
+  id INTEGER PRIMARY KEY,
+  username TEXT NOT NULL,
+  start_date TEXT NOT NULL,
+  end_date TEXT NOT NULL,
+  generated_at TEXT NOT NULL,
+  partial INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS items (
+  id INTEGER PRIMARY KEY,
+  run_id INTEGER NOT NULL REFERENCES runs(id),
+  type TEXT NOT NULL,
+  repository TEXT,
+  number INTEGER,
+  title TEXT,
+  url TEXT,
+  state TEXT,
+  author TEXT,
+  involvements TEXT,
+  created_at TEXT,
+  updated_at TEXT,
+  closed_at TEXT,
+  merged_at TEXT,
+  body TEXT
+);
+CREATE TABLE IF NOT EXISTS comments (
+  id INTEGER PRIMARY KEY,
+  item_id INTEGER NOT NULL REFERENCES items(id),
+  author TEXT,
+  body TEXT,
+  created_at TEXT
+);
+CREATE TABLE IF NOT EXISTS labels (
+  item_id INTEGER NOT NULL REFERENCES items(id),
+  name TEXT NOT NULL
+);
+`
+
+// sqlite3Path returns the path of the sqlite3 command
+func sqlite3Path() (string, error) {
+	path, err := exec.LookPath("sqlite3")
+	if err != nil {
+		return "", fmt.Errorf("sqlite3 not found: the sqlite output format writes the database with the sqlite3 command, install it from your package manager or https://sqlite.org/download.html")
+	}
+	return path, nil
+}
+
+// SQLite 形式で出力します（sqlite3 コマンドで既存のデータベースに追記）
+func writeSQLiteFormat(filename string, reports []model.Report) error {
+	path, err := sqlite3Path()
+	if err != nil {
+		return err
+	}
+
+	// Each run is one transaction that takes the write lock up front, waiting up to 30s for another run writing the same file
+	// Rows refer to the run and item just inserted through ids of this connection, not max(id), which another run may have moved
+	var script bytes.Buffer
+	script.WriteString(".timeout 30000\n")
+	script.WriteString("BEGIN IMMEDIATE;\n")
+	script.WriteString(sqliteSchema)
+	script.WriteString("CREATE TEMP TABLE ids (name TEXT PRIMARY KEY, id INTEGER NOT NULL);\n")
+	generatedAt := time.Now()
+	for _, report := range reports {
+		fmt.Fprintf(&script, "INSERT INTO runs (username, start_date, end_date, generated_at, partial) VALUES (%s, %s, %s, %s, %d);\n",
+			sqlString(report.Username),
+			sqlString(report.DateRange.StartDate.Format("2006-01-02")),
+			sqlString(report.DateRange.EndDate.Format("2006-01-02")),
+			sqlTime(generatedAt),
+			sqlBool(report.Partial))
+		script.WriteString("INSERT OR REPLACE INTO temp.ids VALUES ('run', last_insert_rowid());\n")
+		for _, item := range report.Items {
+			fmt.Fprintf(&script, "INSERT INTO items (run_id, type, repository, number, title, url, state, author, involvements, created_at, updated_at, closed_at, merged_at, body) VALUES ((SELECT id FROM temp.ids WHERE name = 'run'), %s, %s, %d, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s);\n",
+				sqlString(item.Type),
+				sqlString(item.Repository),
+				item.Number,
+				sqlString(item.Title),
+				sqlString(item.URL),
+				sqlString(item.State),
+				sqlString(item.Author),
+				sqlString(strings.Join(item.Involvements, ",")),
+				sqlTime(item.CreatedAt),
+				sqlTime(item.UpdatedAt),
+				sqlTime(item.ClosedAt),
+				sqlTime(item.MergedAt),
+				sqlString(item.Body))
+			script.WriteString("INSERT OR REPLACE INTO temp.ids VALUES ('item', last_insert_rowid());\n")
+			for _, label := range item.Labels {
+				fmt.Fprintf(&script, "INSERT INTO labels (item_id, name) VALUES ((SELECT id FROM temp.ids WHERE name = 'item'), %s);\n", sqlString(label))
+			}
+			for _, comment := range item.Comments {
+				fmt.Fprintf(&script, "INSERT INTO comments (item_id, author, body, created_at) VALUES ((SELECT id FROM temp.ids WHERE name = 'item'), %s, %s, %s);\n",
+					sqlString(comment.Author), sqlString(comment.Body), sqlTime(comment.CreatedAt))
+			}
+		}
+	}
+	script.WriteString("COMMIT;\n")
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(path, "-bail", filename)
+	cmd.Stdin = &script
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("sqlite3 failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// 文字列を SQL の式に変換します
+// 値は 16 進数の BLOB リテラルとして渡すので、引用符や改行、sqlite3 のドットコマンドを含んでいてもスクリプトの構文に影響しません
+func sqlString(s string) string {
+	// NUL bytes would end the text when SQLite reads it back
+	s = strings.ReplaceAll(s, "\x00", "")
+	return "CAST(X'" + hex.EncodeToString([]byte(s)) + "' AS TEXT)"
+}
+
+// 日時を RFC 3339 形式の文字列リテラルに変換します（未設定の場合は NULL）
+func sqlTime(t time.Time) string {
+	if t.IsZero() {
+		return "NULL"
+	}
+	return sqlString(t.Format(time.RFC3339))
+}
+
+// 真偽値を 0 または 1 に変換します
+func sqlBool(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,172 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// スタンドアップ形式（Yesterday / Today / Blockers の 3 項目）で出力
+// Geekbot などのスタンドアップボットにそのまま貼れるよう、見出しは "Yesterday:" のような 1 行にしています
+func writeStandupFormat(file io.Writer, report model.Report) error {
+	if report.Partial {
+		fmt.Fprintf(file, "(partial report: the run was interrupted)\n\n")
+	}
+	writeStandupBody(file, report)
+	return nil
+}
+
+// チームレポートをスタンドアップ形式で出力（ユーザーごとに 3 項目）
+func writeTeamStandupFormat(file io.Writer, team model.TeamReport) error {
+	for i, member := range team.Members {
+		if i > 0 {
+			fmt.Fprintln(file)
+		}
+		if member.Partial {
+			fmt.Fprintf(file, "## %s (partial)\n\n", member.Username)
+		} else {
+			fmt.Fprintf(file, "## %s\n\n", member.Username)
+		}
+		writeStandupBody(file, member)
+	}
+	return nil
+}
+
+// 3 項目を書き出す
+func writeStandupBody(file io.Writer, report model.Report) {
+	fmt.Fprintf(file, "Yesterday:\n")
+	done := standupDone(report)
+	if len(done) == 0 {
+		fmt.Fprintf(file, "- No GitHub activity\n")
+	}
+	for _, line := range done {
+		fmt.Fprintf(file, "- %s\n", line)
+	}
+
+	fmt.Fprintf(file, "\nToday:\n- \n")
+
+	fmt.Fprintf(file, "\nBlockers:\n")
+	blockers := standupBlockers(report)
+	if len(blockers) == 0 {
+		fmt.Fprintf(file, "- None\n")
+	}
+	for _, line := range blockers {
+		fmt.Fprintf(file, "- %s\n", line)
+	}
+}
+
+// 期間中にしたことを 1 項目 1 行で返す（最も重要な関わり方で表現し、メンションだけの項目は除く）
+func standupDone(report model.Report) []string {
+	var lines []string
+	for _, item := range report.Items {
+		var verb string
+		switch {
+		case item.HasInvolvement("created") && item.Type == "PR" && item.State == "merged":
+			verb = "Merged"
+		case item.HasInvolvement("created") && item.State == "closed":
+			verb = "Closed"
+		case item.HasInvolvement("created") && item.Type == "Gist":
+			verb = "Shared"
+		case item.HasInvolvement("created"):
+			verb = "Opened"
+		case item.HasInvolvement("reviewed"):
+			verb = "Reviewed"
+		case item.HasInvolvement("transitioned"):
+			verb = "Moved"
+		case item.HasInvolvement("assigned"):
+			verb = "Worked on"
+		case item.HasInvolvement("commented"):
+			verb = "Commented on"
+		default:
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s %s (%s)", verb, standupItemName(item), item.URL))
+	}
+
+	// Commits are summarized per repository
+	commits := make(map[string]int)
+	for _, commit := range report.Commits {
+		commits[commit.Repository]++
+	}
+	repos := make([]string, 0, len(commits))
+	for repo := range commits {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	for _, repo := range repos {
+		if commits[repo] == 1 {
+			lines = append(lines, fmt.Sprintf("Pushed 1 commit to %s", repo))
+		} else {
+			lines = append(lines, fmt.Sprintf("Pushed %d commits to %s", commits[repo], repo))
+		}
+	}
+	return lines
+}
+
+// 自分の open な PR のうち、CI が失敗しているものと変更を求められているものを返す
+func standupBlockers(report model.Report) []string {
+	var lines []string
+	for _, item := range report.Items {
+		if item.Type != "PR" || item.State != "open" || !item.HasInvolvement("created") {
+			continue
+		}
+		if item.ChecksState == "failure" {
+			lines = append(lines, fmt.Sprintf("CI failing on %s (%s)", standupItemName(item), item.URL))
+		}
+		if reviewers := changesRequestedBy(item.Reviews); len(reviewers) > 0 {
+			lines = append(lines, fmt.Sprintf("Changes requested on %s by %s (%s)", standupItemName(item), joinNames(reviewers), item.URL))
+		}
+	}
+	return lines
+}
+
+// 最新のレビューが CHANGES_REQUESTED のままのレビュアーを返す
+func changesRequestedBy(reviews []model.Review) []string {
+	latest := make(map[string]string)
+	var order []string
+	for _, review := range reviews {
+		// Comments leave an earlier verdict in place
+		if review.State == "COMMENTED" {
+			continue
+		}
+		if _, ok := latest[review.Author]; !ok {
+			order = append(order, review.Author)
+		}
+		latest[review.Author] = review.State
+	}
+	var reviewers []string
+	for _, author := range order {
+		if latest[author] == "CHANGES_REQUESTED" {
+			reviewers = append(reviewers, author)
+		}
+	}
+	return reviewers
+}
+
+// 項目を "repo#123: タイトル" の形で表す
+func standupItemName(item model.Item) string {
+	if item.Type == "Gist" {
+		return "gist: " + item.Title
+	}
+	if item.Type == "Jira" {
+		return fmt.Sprintf("%s-%d: %s", item.Repository, item.Number, item.Title)
+	}
+	return fmt.Sprintf("%s#%d: %s", item.Repository, item.Number, item.Title)
+}
+
+// 名前を "a, b and c" の形でつなぐ
+func joinNames(names []string) string {
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return names[0]
+	}
+	result := names[0]
+	for _, name := range names[1 : len(names)-1] {
+		result += ", " + name
+	}
+	return result + " and " + names[len(names)-1]
+}
@@ -0,0 +1,134 @@
+package output
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// Themes shipped with the binary, used when the templates directory has no file of the same name
+//
+//go:embed themes/*.tmpl
+var builtinThemes embed.FS
+
+// Data passed to a theme template
+type themeData struct {
+	DateRange model.DateRange // Period covered by the report
+	Reports   []model.Report  // One report per user (a single one unless --users is given)
+}
+
+// Repository and the items belonging to it, as returned by the "repositories" template function
+type themeRepository struct {
+	Name  string
+	Items []model.Item
+}
+
+// Functions available in theme templates
+var themeFuncs = template.FuncMap{
+	// date formats a time as YYYY-MM-DD
+	"date": func(t time.Time) string { return t.Format("2006-01-02") },
+	// involved keeps the items with any of the involvements
+	"involved": func(items []model.Item, involvements ...string) []model.Item {
+		return filterThemeItems(items, func(item model.Item) bool {
+			for _, involvement := range involvements {
+				if item.HasInvolvement(involvement) {
+					return true
+				}
+			}
+			return false
+		})
+	},
+	// ofType keeps the items of a type (PR, Issue or Gist)
+	"ofType": func(items []model.Item, itemType string) []model.Item {
+		return filterThemeItems(items, func(item model.Item) bool { return item.Type == itemType })
+	},
+	// inState keeps the items in a state (open, closed or merged)
+	"inState": func(items []model.Item, state string) []model.Item {
+		return filterThemeItems(items, func(item model.Item) bool { return item.State == state })
+	},
+	// closedIn keeps the items closed or merged during the period
+	"closedIn": func(items []model.Item, dateRange model.DateRange) []model.Item {
+		return filterThemeItems(items, func(item model.Item) bool {
+			return !item.ClosedAt.IsZero() && !item.ClosedAt.Before(dateRange.StartDate) && !item.ClosedAt.After(dateRange.EndDate)
+		})
+	},
+	// repositories groups the items by repository in alphabetical order
+	"repositories": func(items []model.Item) []themeRepository {
+		byRepo := make(map[string][]model.Item)
+		for _, item := range items {
+			byRepo[item.Repository] = append(byRepo[item.Repository], item)
+		}
+		repos := make([]themeRepository, 0, len(byRepo))
+		for name, repoItems := range byRepo {
+			repos = append(repos, themeRepository{Name: name, Items: repoItems})
+		}
+		sort.Slice(repos, func(i, j int) bool { return repos[i].Name < repos[j].Name })
+		return repos
+	},
+	// truncate shortens a text to at most n characters
+	"truncate": func(n int, s string) string { return truncateRunes(s, n) },
+	// firstLine returns the first line of a text (e.g. a commit subject)
+	"firstLine": commitSubject,
+	"join":      strings.Join,
+}
+
+// アイテムのうち条件を満たすものを返す
+func filterThemeItems(items []model.Item, keep func(model.Item) bool) []model.Item {
+	var filtered []model.Item
+	for _, item := range items {
+		if keep(item) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// LoadTheme はテーマのテンプレートを読み込みます
+// dir に <name>.tmpl があればそれを、なければ同名の組み込みテーマを使います
+func LoadTheme(name, dir string) (*template.Template, error) {
+	filename := name + ".tmpl"
+	if dir != "" {
+		data, err := os.ReadFile(filepath.Join(dir, filename))
+		if err == nil {
+			return template.New(filename).Funcs(themeFuncs).Parse(string(data))
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	data, err := builtinThemes.ReadFile("themes/" + filename)
+	if err != nil {
+		return nil, fmt.Errorf("unknown theme %q (built-in themes: %s)", name, strings.Join(BuiltinThemes(), ", "))
+	}
+	return template.New(filename).Funcs(themeFuncs).Parse(string(data))
+}
+
+// BuiltinThemes は組み込みテーマの名前を返します
+func BuiltinThemes() []string {
+	entries, _ := builtinThemes.ReadDir("themes")
+	var names []string
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".tmpl"))
+	}
+	return names
+}
+
+// テーマのテンプレートでレポートを書き出す
+func writeThemeFormat(file io.Writer, dateRange model.DateRange, reports []model.Report, opts Options) error {
+	tmpl, err := LoadTheme(opts.Theme, opts.ThemeDir)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(file, themeData{DateRange: dateRange, Reports: reports})
+}
@@ -0,0 +1,191 @@
+package output
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// Maximum number of characters Excel accepts in a cell
+const xlsxMaxCell = 32767
+
+// Struct to hold one worksheet; cells are strings or ints
+type xlsxSheet struct {
+	name string
+	rows [][]interface{}
+}
+
+// XLSX 形式で出力（アイテム、コメント、集計の3シート）
+func writeXLSXFormat(file io.Writer, reports []model.Report) error {
+	items := xlsxSheet{name: "Items", rows: [][]interface{}{
+		{"User", "Type", "Repository", "Number", "Title", "State", "Involvement", "Author", "Labels", "Created", "Updated", "Closed", "URL"},
+	}}
+	comments := xlsxSheet{name: "Comments", rows: [][]interface{}{
+		{"User", "Repository", "Number", "Item", "Author", "Created", "Body"},
+	}}
+
+	// The summary is a flat table so that a pivot table can be built on it directly
+	type summaryKey struct{ user, repository, itemType, state, involvement string }
+	counts := make(map[summaryKey]int)
+
+	for _, report := range reports {
+		for _, item := range report.Items {
+			items.rows = append(items.rows, []interface{}{
+				report.Username,
+				item.Type,
+				item.Repository,
+				item.Number,
+				item.Title,
+				item.State,
+				strings.Join(item.Involvements, ", "),
+				item.Author,
+				strings.Join(item.Labels, ", "),
+				formatCSVTime(item.CreatedAt),
+				formatCSVTime(item.UpdatedAt),
+				formatCSVTime(item.ClosedAt),
+				item.URL,
+			})
+			for _, comment := range item.Comments {
+				comments.rows = append(comments.rows, []interface{}{
+					report.Username,
+					item.Repository,
+					item.Number,
+					item.Title,
+					comment.Author,
+					formatCSVTime(comment.CreatedAt),
+					comment.Body,
+				})
+			}
+
+			involvement := ""
+			if len(item.Involvements) > 0 {
+				involvement = item.Involvements[0]
+			}
+			counts[summaryKey{report.Username, item.Repository, item.Type, item.State, involvement}]++
+		}
+	}
+
+	var keys []summaryKey
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.user != b.user {
+			return a.user < b.user
+		}
+		if a.repository != b.repository {
+			return a.repository < b.repository
+		}
+		if a.itemType != b.itemType {
+			return a.itemType < b.itemType
+		}
+		if a.state != b.state {
+			return a.state < b.state
+		}
+		return a.involvement < b.involvement
+	})
+	summary := xlsxSheet{name: "Summary", rows: [][]interface{}{
+		{"User", "Repository", "Type", "State", "Involvement", "Count"},
+	}}
+	for _, key := range keys {
+		summary.rows = append(summary.rows, []interface{}{key.user, key.repository, key.itemType, key.state, key.involvement, counts[key]})
+	}
+
+	return writeXLSXWorkbook(file, []xlsxSheet{items, comments, summary})
+}
+
+// シートを SpreadsheetML のパッケージ（ZIP）として書き出す
+func writeXLSXWorkbook(file io.Writer, sheets []xlsxSheet) error {
+	var contentTypes, workbook, workbookRels bytes.Buffer
+	contentTypes.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	workbook.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	workbookRels.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i, sheet := range sheets {
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, sheet.name, i+1, i+1)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	workbookRels.WriteString(`</Relationships>`)
+
+	parts := []struct {
+		name string
+		data []byte
+	}{
+		{"[Content_Types].xml", contentTypes.Bytes()},
+		{"_rels/.rels", []byte(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`)},
+		{"xl/workbook.xml", workbook.Bytes()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.Bytes()},
+	}
+	for i, sheet := range sheets {
+		parts = append(parts, struct {
+			name string
+			data []byte
+		}{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), xlsxSheetXML(sheet)})
+	}
+
+	zw := zip.NewWriter(file)
+	for _, part := range parts {
+		w, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(part.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// シートの XML を組み立てる（文字列はインライン文字列として埋め込む）
+func xlsxSheetXML(sheet xlsxSheet) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range sheet.rows {
+		fmt.Fprintf(&buf, `<row r="%d">`, r+1)
+		for c, value := range row {
+			ref := xlsxColumn(c) + strconv.Itoa(r+1)
+			switch v := value.(type) {
+			case int:
+				fmt.Fprintf(&buf, `<c r="%s"><v>%d</v></c>`, ref, v)
+			case string:
+				if v == "" {
+					continue
+				}
+				if len(v) > xlsxMaxCell {
+					v = v[:xlsxMaxCell]
+				}
+				fmt.Fprintf(&buf, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+				xml.EscapeText(&buf, []byte(v))
+				buf.WriteString(`</t></is></c>`)
+			}
+		}
+		buf.WriteString(`</row>`)
+	}
+	buf.WriteString(`</sheetData></worksheet>`)
+	return buf.Bytes()
+}
+
+// 0 始まりの列番号を A, B, ..., Z, AA, ... の形式に変換する
+func xlsxColumn(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
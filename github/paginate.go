@@ -0,0 +1,67 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// linkNextPattern は Link ヘッダーから次ページの URL を取り出します
+var linkNextPattern = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// getPages は Link ヘッダーの rel="next" をたどってページごとに取得します
+// 各ページは page にデコードされてから handle に渡され、handle が false を返すとそこで打ち切ります
+func (c *Client) getPages(ctx context.Context, path string, page interface{}, handle func() bool) error {
+	for next := path; next != ""; {
+		var resp *http.Response
+		err := c.withRetry(ctx, func() error {
+			var err error
+			resp, err = c.client.RequestWithContext(ctx, http.MethodGet, next, nil)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		// Start each page from an empty value so nothing carries over from the previous one
+		target := reflect.ValueOf(page).Elem()
+		target.Set(reflect.Zero(target.Type()))
+		err = json.NewDecoder(resp.Body).Decode(page)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if !handle() {
+			return nil
+		}
+
+		next = nextPageURL(resp.Header.Get("Link"))
+	}
+	return nil
+}
+
+// nextPageURL は Link ヘッダーの rel="next" の URL を返します（最終ページでは空文字列）
+func nextPageURL(link string) string {
+	if match := linkNextPattern.FindStringSubmatch(link); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// withPerPage は 1 ページあたりの件数を最大にしてリクエスト数を減らします
+func withPerPage(path string) string {
+	if strings.Contains(path, "?") {
+		return path + "&per_page=100"
+	}
+	return path + "?per_page=100"
+}
+
+// commentLimitReached は MaxComments に達したかどうかを判定します
+func (c *Client) commentLimitReached(item *model.Item) bool {
+	return c.MaxComments > 0 && len(item.Comments) >= c.MaxComments
+}
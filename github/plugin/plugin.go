@@ -0,0 +1,132 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+	"git.pepabo.com/yukyan/gh-pric/github/output"
+)
+
+// Prefixes of the executables looked up in PATH
+const (
+	FilterPrefix = "gh-pric-filter-" // --filter <name> runs gh-pric-filter-<name>
+	FormatPrefix = "gh-pric-format-" // --output-format <name> runs gh-pric-format-<name> unless it is built in
+)
+
+// Longest line a filter may print (an item with long bodies and comments)
+const maxLineSize = 64 * 1024 * 1024
+
+// Line of the item stream, the same as the lines of --output-format jsonl
+type line struct {
+	User string `json:"User"`
+	model.Item
+}
+
+// Find はプラグインの実行ファイルを PATH から探します
+func Find(prefix, name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid plugin name %q", name)
+	}
+	path, err := exec.LookPath(prefix + name)
+	if err != nil {
+		return "", fmt.Errorf("%s%s was not found in PATH", prefix, name)
+	}
+	return path, nil
+}
+
+// Filter はフィルタープラグインにレポートの項目を 1 行 1 件の JSON で渡し、
+// プラグインが同じ形式で標準出力に書いた項目を返します（項目を減らしても、書き換えても、増やしてもかまいません）
+func Filter(path string, report model.Report) ([]model.Item, error) {
+	var stdin bytes.Buffer
+	if err := writeItems(&stdin, []model.Report{report}); err != nil {
+		return nil, err
+	}
+	var stdout bytes.Buffer
+	if err := run(path, report.DateRange, []model.Report{report}, &stdin, &stdout); err != nil {
+		return nil, err
+	}
+
+	items := []model.Item{}
+	scanner := bufio.NewScanner(&stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for number := 1; scanner.Scan(); number++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var l line
+		if err := json.Unmarshal(scanner.Bytes(), &l); err != nil {
+			return nil, fmt.Errorf("%s: invalid item on line %d: %w", path, number, err)
+		}
+		items = append(items, l.Item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return items, nil
+}
+
+// Formatter は出力形式プラグインを output.Formatter として使うためのものです
+// プラグインは項目を 1 行 1 件の JSON で受け取り、標準出力に書いたものがそのまま出力になります
+type Formatter struct {
+	Path string // Path of the gh-pric-format-* executable
+}
+
+func (f Formatter) Format(w io.Writer, report *model.Report, opts output.Options) error {
+	return f.format(w, report.DateRange, []model.Report{*report})
+}
+
+func (f Formatter) FormatTeam(w io.Writer, team *model.TeamReport, opts output.Options) error {
+	return f.format(w, team.DateRange, team.Members)
+}
+
+// format runs the plugin on the items of reports and copies its output to w
+func (f Formatter) format(w io.Writer, dateRange model.DateRange, reports []model.Report) error {
+	var stdin bytes.Buffer
+	if err := writeItems(&stdin, reports); err != nil {
+		return err
+	}
+	return run(f.Path, dateRange, reports, &stdin, w)
+}
+
+// run starts the plugin with the report described in its environment
+// Messages the plugin prints to stderr are passed through
+func run(path string, dateRange model.DateRange, reports []model.Report, stdin io.Reader, stdout io.Writer) error {
+	users := make([]string, len(reports))
+	for i, report := range reports {
+		users[i] = report.Username
+	}
+
+	cmd := exec.Command(path)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"GH_PRIC_USERS="+strings.Join(users, ","),
+		"GH_PRIC_FROM="+dateRange.StartDate.Format("2006-01-02"),
+		"GH_PRIC_TO="+dateRange.EndDate.Format("2006-01-02"),
+	)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// writeItems writes the items of reports as JSON Lines tagged with their user
+func writeItems(w io.Writer, reports []model.Report) error {
+	encoder := json.NewEncoder(w)
+	for _, report := range reports {
+		for _, item := range report.Items {
+			if err := encoder.Encode(line{User: report.Username, Item: item}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// Query for the Projects v2 items of an issue or PR
+const projectItemsQuery = `
+query($owner: String!, $repo: String!, $number: Int!) {
+  repository(owner: $owner, name: $repo) {
+    issueOrPullRequest(number: $number) {
+      ... on Issue { projectItems(first: 20) { ...projectItems } }
+      ... on PullRequest { projectItems(first: 20) { ...projectItems } }
+    }
+  }
+}
+
+fragment projectItems on ProjectV2ItemConnection {
+  nodes {
+    project { title url }
+    fieldValues(first: 30) {
+      nodes {
+        ... on ProjectV2ItemFieldSingleSelectValue {
+          name
+          field { ... on ProjectV2FieldCommon { name } }
+        }
+        ... on ProjectV2ItemFieldIterationValue {
+          title
+          field { ... on ProjectV2FieldCommon { name } }
+        }
+        ... on ProjectV2ItemFieldTextValue {
+          text
+          field { ... on ProjectV2FieldCommon { name } }
+        }
+      }
+    }
+  }
+}`
+
+// FetchProjectItems はアイテムが属する Projects v2 の項目とフィールド値を取得します
+func (c *Client) FetchProjectItems(ctx context.Context, item *model.Item) error {
+	repoPath := getRepoPathFromURL(item.Repository)
+	owner, repo, ok := strings.Cut(repoPath, "/")
+	if !ok {
+		return fmt.Errorf("Failed to extract repository path: %s", item.Repository)
+	}
+
+	type fieldValue struct {
+		Name  string `json:"name"`
+		Title string `json:"title"`
+		Text  string `json:"text"`
+		Field struct {
+			Name string `json:"name"`
+		} `json:"field"`
+	}
+	type projectItems struct {
+		Nodes []struct {
+			Project struct {
+				Title string `json:"title"`
+				URL   string `json:"url"`
+			} `json:"project"`
+			FieldValues struct {
+				Nodes []fieldValue `json:"nodes"`
+			} `json:"fieldValues"`
+		} `json:"nodes"`
+	}
+	var response struct {
+		Repository struct {
+			IssueOrPullRequest struct {
+				ProjectItems projectItems `json:"projectItems"`
+			} `json:"issueOrPullRequest"`
+		} `json:"repository"`
+	}
+
+	variables := map[string]interface{}{
+		"owner":  owner,
+		"repo":   repo,
+		"number": item.Number,
+	}
+	err := c.withRetry(ctx, func() error {
+		return c.graphql.DoWithContext(ctx, projectItemsQuery, variables, &response)
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to retrieve project items: %w", err)
+	}
+
+	for _, node := range response.Repository.IssueOrPullRequest.ProjectItems.Nodes {
+		projectItem := model.ProjectItem{
+			Project: node.Project.Title,
+			URL:     node.Project.URL,
+			Fields:  make(map[string]string),
+		}
+		for _, value := range node.FieldValues.Nodes {
+			if value.Field.Name == "" {
+				continue
+			}
+			switch {
+			case value.Title != "":
+				// Iteration fields
+				projectItem.Fields[value.Field.Name] = value.Title
+				projectItem.Iteration = value.Title
+			case value.Name != "":
+				// Single select fields
+				projectItem.Fields[value.Field.Name] = value.Name
+				if value.Field.Name == "Status" {
+					projectItem.Status = value.Name
+				}
+			case value.Text != "":
+				projectItem.Fields[value.Field.Name] = value.Text
+			}
+		}
+		item.Projects = append(item.Projects, projectItem)
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Confluence は Confluence Cloud のスペースにページとしてレポートを書き込むクライアントです
+type Confluence struct {
+	BaseURL    string       // Site URL (e.g. https://example.atlassian.net)
+	Email      string       // Email address of the Atlassian account
+	APIToken   string       // API token of the Atlassian account
+	HTTPClient *http.Client // HTTP client (http.DefaultClient when nil)
+}
+
+// Struct to hold a page as returned by the Confluence REST API
+type confluencePage struct {
+	ID      string `json:"id"`
+	Version struct {
+		Number int `json:"number"`
+	} `json:"version"`
+	Links struct {
+		WebUI string `json:"webui"`
+	} `json:"_links"`
+}
+
+// PublishPage はスペース内の同じタイトルのページを更新し、なければ parentID の下に作成してページの URL を返します
+// body はストレージ形式（--output-format confluence の出力）です
+func (c *Confluence) PublishPage(ctx context.Context, spaceKey, parentID, title, body string) (string, bool, error) {
+	query := url.Values{}
+	query.Set("spaceKey", spaceKey)
+	query.Set("title", title)
+	query.Set("type", "page")
+	query.Set("expand", "version")
+	var found struct {
+		Results []confluencePage `json:"results"`
+	}
+	if err := c.do(ctx, http.MethodGet, "content?"+query.Encode(), nil, &found); err != nil {
+		return "", false, fmt.Errorf("failed to search Confluence pages: %w", err)
+	}
+
+	page := map[string]interface{}{
+		"type":  "page",
+		"title": title,
+		"space": map[string]string{"key": spaceKey},
+		"body": map[string]interface{}{
+			"storage": map[string]string{"value": body, "representation": "storage"},
+		},
+	}
+
+	if len(found.Results) > 0 {
+		existing := found.Results[0]
+		page["id"] = existing.ID
+		page["version"] = map[string]interface{}{"number": existing.Version.Number + 1, "message": "Published by gh pric"}
+		var updated confluencePage
+		if err := c.do(ctx, http.MethodPut, "content/"+existing.ID, page, &updated); err != nil {
+			return "", false, err
+		}
+		return c.pageURL(updated), false, nil
+	}
+
+	if parentID != "" {
+		page["ancestors"] = []map[string]string{{"id": parentID}}
+	}
+	var created confluencePage
+	if err := c.do(ctx, http.MethodPost, "content", page, &created); err != nil {
+		return "", false, err
+	}
+	return c.pageURL(created), true, nil
+}
+
+// ページの URL を返す
+func (c *Confluence) pageURL(page confluencePage) string {
+	return strings.TrimSuffix(c.BaseURL, "/") + "/wiki" + page.Links.WebUI
+}
+
+// REST API にリクエストを送り、レスポンスを response に読み込む
+func (c *Confluence) do(ctx context.Context, method, path string, body, response interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.BaseURL, "/")+"/wiki/rest/api/"+path, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.Email, c.APIToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, apiErr.Message)
+	}
+	if response == nil {
+		return nil
+	}
+	return json.Unmarshal(data, response)
+}
@@ -0,0 +1,113 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits of a Discord webhook message
+const (
+	discordMaxEmbeds     = 10   // Embeds per message
+	discordMaxEmbedChars = 6000 // Characters of all embeds of a message together
+)
+
+// Discord は Discord チャンネルの Webhook に埋め込み（embed）形式のメッセージを投稿するクライアントです
+type Discord struct {
+	WebhookURL string       // Webhook URL of the channel (https://discord.com/api/webhooks/...)
+	HTTPClient *http.Client // HTTP client (http.DefaultClient when nil)
+}
+
+// DiscordEmbed は Discord のメッセージに付ける埋め込みです
+type DiscordEmbed struct {
+	Title       string         `json:"title,omitempty"`
+	URL         string         `json:"url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Color       int            `json:"color,omitempty"`
+	Fields      []DiscordField `json:"fields,omitempty"`
+}
+
+// DiscordField は埋め込みの項目です
+type DiscordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// Post は埋め込みを投稿します
+// 1 通に収まらない場合（10 個または合計 6000 文字を超える場合）は複数のメッセージに分けて投稿します
+func (d *Discord) Post(ctx context.Context, embeds []DiscordEmbed) error {
+	var batch []DiscordEmbed
+	chars := 0
+	for _, embed := range embeds {
+		size := embed.size()
+		if len(batch) > 0 && (len(batch) == discordMaxEmbeds || chars+size > discordMaxEmbedChars) {
+			if err := d.send(ctx, batch); err != nil {
+				return err
+			}
+			batch, chars = nil, 0
+		}
+		batch = append(batch, embed)
+		chars += size
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	return d.send(ctx, batch)
+}
+
+// Number of characters counted against the limit of a message
+func (e DiscordEmbed) size() int {
+	n := utf8.RuneCountInString(e.Title) + utf8.RuneCountInString(e.Description)
+	for _, field := range e.Fields {
+		n += utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+	}
+	return n
+}
+
+// メッセージをひとつ投稿する
+func (d *Discord) send(ctx context.Context, embeds []DiscordEmbed) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"username":         "gh pric",
+		"embeds":           embeds,
+		"allowed_mentions": map[string][]string{"parse": {}},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.WebhookURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gh-pric")
+
+	client := d.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 300))
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(body, &apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(body))
+		}
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, apiErr.Message)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Base URL of the esa.io API
+const esaAPI = "https://api.esa.io/v1/teams/"
+
+// Esa は esa.io のチームに記事としてレポートを書き込むクライアントです
+type Esa struct {
+	Token      string       // Personal access token with the write scope
+	Team       string       // Team name (the subdomain of esa.io)
+	HTTPClient *http.Client // HTTP client (http.DefaultClient when nil)
+}
+
+// Struct to hold a post as returned by the esa.io API
+type esaPost struct {
+	Number   int    `json:"number"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	URL      string `json:"url"`
+}
+
+// Publish はカテゴリ内の同名の記事を更新し、なければ新しく作成して記事の URL を返します
+// 同じ期間のレポートを何度公開しても記事はひとつのままです
+func (e *Esa) Publish(ctx context.Context, category, name, body string) (string, bool, error) {
+	query := url.Values{}
+	query.Set("q", fmt.Sprintf("on:%q title:%q", category, name))
+	query.Set("per_page", "100")
+	var found struct {
+		Posts []esaPost `json:"posts"`
+	}
+	if err := e.do(ctx, http.MethodGet, "posts?"+query.Encode(), nil, &found); err != nil {
+		return "", false, fmt.Errorf("failed to search esa.io posts: %w", err)
+	}
+
+	post := map[string]interface{}{
+		"name":     name,
+		"category": category,
+		"body_md":  body,
+		"wip":      false,
+		"message":  "Published by gh pric",
+	}
+	// The search matches words, so the exact name and category are checked here
+	for _, existing := range found.Posts {
+		if existing.Name == name && existing.Category == category {
+			var updated esaPost
+			if err := e.do(ctx, http.MethodPatch, fmt.Sprintf("posts/%d", existing.Number), map[string]interface{}{"post": post}, &updated); err != nil {
+				return "", false, err
+			}
+			return updated.URL, false, nil
+		}
+	}
+
+	var created esaPost
+	if err := e.do(ctx, http.MethodPost, "posts", map[string]interface{}{"post": post}, &created); err != nil {
+		return "", false, err
+	}
+	return created.URL, true, nil
+}
+
+// API にリクエストを送り、レスポンスを response に読み込む
+func (e *Esa) do(ctx context.Context, method, path string, body, response interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, esaAPI+url.PathEscape(e.Team)+"/"+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+e.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := e.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, apiErr.Message)
+	}
+	if response == nil {
+		return nil
+	}
+	return json.Unmarshal(data, response)
+}
@@ -0,0 +1,88 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// ErrGistNotFound is returned when the gist to update does not exist (anymore)
+var ErrGistNotFound = errors.New("gist not found")
+
+// Gist は GitHub の秘密の Gist にレポートを公開するクライアントです
+type Gist struct {
+	Client *api.RESTClient // REST client of the GitHub host (authenticated like gh)
+}
+
+// Struct to hold a gist as returned by the GitHub API
+type gistResponse struct {
+	ID      string              `json:"id"`
+	HTMLURL string              `json:"html_url"`
+	Files   map[string]struct{} `json:"files"`
+}
+
+// Publish は内容をひとつのファイルとして秘密の Gist に書き込み、Gist の ID と URL を返します
+// id が空なら新しい Gist を作成し、そうでなければその Gist を更新します（ほかのファイルは削除します）
+func (g *Gist) Publish(ctx context.Context, id, filename, description, content string) (string, string, error) {
+	files := map[string]interface{}{
+		filename: map[string]string{"content": content},
+	}
+
+	if id == "" {
+		body := map[string]interface{}{
+			"description": description,
+			"public":      false,
+			"files":       files,
+		}
+		var created gistResponse
+		if err := g.do(ctx, http.MethodPost, "gists", body, &created); err != nil {
+			return "", "", err
+		}
+		return created.ID, created.HTMLURL, nil
+	}
+
+	// The gist shows only the latest report, even when the file name changes between runs
+	var existing gistResponse
+	if err := g.do(ctx, http.MethodGet, "gists/"+id, nil, &existing); err != nil {
+		return "", "", err
+	}
+	for name := range existing.Files {
+		if name != filename {
+			files[name] = nil
+		}
+	}
+
+	body := map[string]interface{}{
+		"description": description,
+		"files":       files,
+	}
+	var updated gistResponse
+	if err := g.do(ctx, http.MethodPatch, "gists/"+id, body, &updated); err != nil {
+		return "", "", err
+	}
+	return updated.ID, updated.HTMLURL, nil
+}
+
+// API にリクエストを送り、レスポンスを out に読み込む
+func (g *Gist) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	err := g.Client.DoWithContext(ctx, method, path, reader, out)
+	var httpErr *api.HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+		return ErrGistNotFound
+	}
+	return err
+}
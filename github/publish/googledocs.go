@@ -0,0 +1,112 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"git.pepabo.com/yukyan/gh-pric/github/googleauth"
+)
+
+// Settings for the Google Drive API
+const (
+	driveUploadAPI = "https://www.googleapis.com/upload/drive/v3/files?uploadType=multipart&supportsAllDrives=true&fields=id,webViewLink"
+	driveScope     = "https://www.googleapis.com/auth/drive.file"
+)
+
+// GoogleDocs は Markdown のレポートを Google ドキュメントに変換して Google ドライブに作成するクライアントです
+// 認証情報はアプリケーションのデフォルト認証情報（サービスアカウントのキーなど）を使います
+type GoogleDocs struct {
+	FolderID   string       // Drive folder of the document (empty = My Drive of the account)
+	HTTPClient *http.Client // HTTP client (http.DefaultClient when nil)
+}
+
+// Create は Markdown を Google ドキュメントとしてアップロードし、ドキュメントの URL を返します
+func (g *GoogleDocs) Create(ctx context.Context, title, markdown string) (string, error) {
+	client := g.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	token, err := googleauth.AccessToken(ctx, client, driveScope)
+	if err != nil {
+		return "", err
+	}
+
+	// Drive converts the uploaded markdown when the target type is a Google document
+	metadata := map[string]interface{}{
+		"name":     title,
+		"mimeType": "application/vnd.google-apps.document",
+	}
+	if g.FolderID != "" {
+		metadata["parents"] = []string{g.FolderID}
+	}
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return "", err
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		data        []byte
+	}{
+		{"application/json; charset=UTF-8", metadataJSON},
+		{"text/markdown; charset=UTF-8", []byte(markdown)},
+	} {
+		w, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		if err != nil {
+			return "", err
+		}
+		w.Write(part.data)
+	}
+	writer.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, driveUploadAPI, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "multipart/related; boundary="+writer.Boundary())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.Unmarshal(data, &apiErr)
+		if apiErr.Error.Message == "" {
+			apiErr.Error.Message = strings.TrimSpace(string(data))
+		}
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, apiErr.Error.Message)
+	}
+
+	var created struct {
+		ID          string `json:"id"`
+		WebViewLink string `json:"webViewLink"`
+	}
+	if err := json.Unmarshal(data, &created); err != nil {
+		return "", err
+	}
+	if created.WebViewLink == "" {
+		return "https://docs.google.com/document/d/" + created.ID + "/edit", nil
+	}
+	return created.WebViewLink, nil
+}
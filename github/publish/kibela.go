@@ -0,0 +1,125 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Kibela は Kibela のチームにノートとしてレポートを書き込むクライアントです
+type Kibela struct {
+	Token      string       // Access token with the write scope
+	Team       string       // Team name (the subdomain of kibe.la)
+	HTTPClient *http.Client // HTTP client (http.DefaultClient when nil)
+}
+
+// CreateNote はグループ（とフォルダー）にノートを作成し、ノートの URL を返します
+// group はグループの名前、folder は空ならグループ直下です
+func (k *Kibela) CreateNote(ctx context.Context, group, folder, title, content string) (string, error) {
+	groupID, err := k.groupID(ctx, group)
+	if err != nil {
+		return "", err
+	}
+
+	input := map[string]interface{}{
+		"title":     title,
+		"content":   content,
+		"groupIds":  []string{groupID},
+		"coediting": true,
+		"draft":     false,
+	}
+	if folder != "" {
+		input["folders"] = []map[string]string{{"groupId": groupID, "folderName": folder}}
+	}
+
+	var result struct {
+		CreateNote struct {
+			Note struct {
+				URL string `json:"url"`
+			} `json:"note"`
+		} `json:"createNote"`
+	}
+	mutation := `mutation($input: CreateNoteInput!) { createNote(input: $input) { note { url } } }`
+	if err := k.do(ctx, mutation, map[string]interface{}{"input": input}, &result); err != nil {
+		return "", err
+	}
+	return result.CreateNote.Note.URL, nil
+}
+
+// グループ名からグループの ID を探す
+func (k *Kibela) groupID(ctx context.Context, name string) (string, error) {
+	var result struct {
+		Groups struct {
+			Nodes []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"nodes"`
+		} `json:"groups"`
+	}
+	if err := k.do(ctx, `query { groups(first: 100) { nodes { id name } } }`, nil, &result); err != nil {
+		return "", fmt.Errorf("failed to read the Kibela groups: %w", err)
+	}
+
+	var names []string
+	for _, group := range result.Groups.Nodes {
+		if group.Name == name {
+			return group.ID, nil
+		}
+		names = append(names, group.Name)
+	}
+	return "", fmt.Errorf("no Kibela group named %q (groups: %s)", name, strings.Join(names, ", "))
+}
+
+// GraphQL API にクエリを送り、data を response に読み込む
+func (k *Kibela) do(ctx context.Context, query string, variables map[string]interface{}, response interface{}) error {
+	data, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("https://%s.kibe.la/api/v1", k.Team), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+k.Token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "gh-pric")
+
+	client := k.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+		return err
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("Kibela API: %s", result.Errors[0].Message)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return json.Unmarshal(result.Data, response)
+}
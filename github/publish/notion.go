@@ -0,0 +1,165 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// Settings for the Notion API
+const (
+	notionAPI      = "https://api.notion.com/v1/"
+	notionVersion  = "2022-06-28"
+	notionInterval = 350 * time.Millisecond // Notion allows about three requests per second
+	notionMaxText  = 2000                   // Maximum length of a rich text value
+)
+
+// Notion は Notion のデータベースにレポートを書き込むクライアントです
+type Notion struct {
+	Token      string       // Integration token (the database must be shared with the integration)
+	DatabaseID string       // Database that receives one row per item
+	HTTPClient *http.Client // HTTP client (http.DefaultClient when nil)
+}
+
+// Struct to hold a database property as returned by the Notion API
+type notionProperty struct {
+	Type string `json:"type"`
+}
+
+// PublishItems は各アイテムをデータベースの1行として作成し、作成した行数を返します
+// タイトル列にはアイテムのタイトルが入り、URL・State・Type・Repository・User・Involvement・Created
+// という名前の列がデータベースにあればその値も設定します
+func (n *Notion) PublishItems(ctx context.Context, reports []model.Report) (int, error) {
+	var database struct {
+		Properties map[string]notionProperty `json:"properties"`
+	}
+	if err := n.do(ctx, http.MethodGet, "databases/"+n.DatabaseID, nil, &database); err != nil {
+		return 0, fmt.Errorf("failed to read the Notion database: %w", err)
+	}
+
+	created := 0
+	for _, report := range reports {
+		for _, item := range report.Items {
+			values := map[string]string{
+				"url":         item.URL,
+				"state":       item.State,
+				"type":        item.Type,
+				"repository":  item.Repository,
+				"user":        report.Username,
+				"involvement": strings.Join(item.Involvements, ","),
+				"created":     item.CreatedAt.Format("2006-01-02"),
+			}
+
+			properties := make(map[string]interface{})
+			for name, property := range database.Properties {
+				if property.Type == "title" {
+					properties[name] = notionValue("title", item.Title)
+					continue
+				}
+				if value, ok := values[strings.ToLower(name)]; ok && value != "" {
+					if v := notionValue(property.Type, value); v != nil {
+						properties[name] = v
+					}
+				}
+			}
+
+			page := map[string]interface{}{
+				"parent":     map[string]string{"database_id": n.DatabaseID},
+				"properties": properties,
+			}
+			if err := n.do(ctx, http.MethodPost, "pages", page, nil); err != nil {
+				return created, fmt.Errorf("failed to create a Notion page for %s: %w", item.URL, err)
+			}
+			created++
+
+			select {
+			case <-ctx.Done():
+				return created, ctx.Err()
+			case <-time.After(notionInterval):
+			}
+		}
+	}
+	return created, nil
+}
+
+// 列の種類に合わせてプロパティの値を組み立てる（対応していない種類の場合は nil）
+func notionValue(propertyType, value string) interface{} {
+	if len(value) > notionMaxText {
+		value = value[:notionMaxText]
+	}
+	text := []map[string]interface{}{{"text": map[string]string{"content": value}}}
+
+	switch propertyType {
+	case "title":
+		return map[string]interface{}{"title": text}
+	case "rich_text":
+		return map[string]interface{}{"rich_text": text}
+	case "url":
+		return map[string]interface{}{"url": value}
+	case "select":
+		return map[string]interface{}{"select": map[string]string{"name": value}}
+	case "multi_select":
+		var options []map[string]string
+		for _, option := range strings.Split(value, ",") {
+			options = append(options, map[string]string{"name": option})
+		}
+		return map[string]interface{}{"multi_select": options}
+	case "date":
+		return map[string]interface{}{"date": map[string]string{"start": value}}
+	default:
+		return nil
+	}
+}
+
+// Notion API にリクエストを送信し、レスポンスを response にデコードする
+func (n *Notion) do(ctx context.Context, method, path string, body, response interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, notionAPI+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+n.Token)
+	req.Header.Set("Notion-Version", notionVersion)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := n.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &apiErr)
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, apiErr.Message)
+	}
+	if response == nil {
+		return nil
+	}
+	return json.Unmarshal(data, response)
+}
@@ -0,0 +1,62 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Content type of an Adaptive Card attachment
+const adaptiveCardContentType = "application/vnd.microsoft.card.adaptive"
+
+// Teams は Microsoft Teams チャンネルの Webhook（Workflows または Incoming Webhook コネクタ）に
+// Adaptive Card を投稿するクライアントです
+type Teams struct {
+	WebhookURL string       // Webhook URL of the channel
+	HTTPClient *http.Client // HTTP client (http.DefaultClient when nil)
+}
+
+// PostCard は Adaptive Card（"type": "AdaptiveCard" のオブジェクト）をメッセージとして投稿します
+func (t *Teams) PostCard(ctx context.Context, card map[string]interface{}) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{
+			{"contentType": adaptiveCardContentType, "contentUrl": nil, "content": card},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.WebhookURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gh-pric")
+
+	client := t.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 300))
+	message := strings.TrimSpace(string(body))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, message)
+	}
+	// Incoming Webhook connectors report some failures with status 200
+	if strings.HasPrefix(message, "Webhook message delivery failed") {
+		return fmt.Errorf("%s", message)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Header carrying the HMAC signature of the body, named like the one of GitHub webhooks
+const webhookSignatureHeader = "X-Hub-Signature-256"
+
+// Webhook は任意のエンドポイントにレポートを JSON で POST するクライアントです
+type Webhook struct {
+	URL        string       // Endpoint that receives the report
+	Secret     string       // Key of the HMAC-SHA256 signature (empty = unsigned)
+	HTTPClient *http.Client // HTTP client (http.DefaultClient when nil)
+}
+
+// Post は payload を JSON にして POST します
+// Secret があれば本文の HMAC-SHA256 を "sha256=<hex>" の形で X-Hub-Signature-256 ヘッダーに付けます
+func (w *Webhook) Post(ctx context.Context, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gh-pric")
+	if w.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.Secret))
+		mac.Write(data)
+		req.Header.Set(webhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	client := w.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 300))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
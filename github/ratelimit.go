@@ -0,0 +1,66 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimit は API リソースごとのレート制限の状態です
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// RateLimits は REST・検索・GraphQL の各リソースのレート制限です
+type RateLimits struct {
+	Core    RateLimit
+	Search  RateLimit
+	GraphQL RateLimit
+}
+
+// FetchRateLimits は現在のレート制限の状態を取得します（このリクエスト自体は制限を消費しません）
+func (c *Client) FetchRateLimits(ctx context.Context) (RateLimits, error) {
+	type resource struct {
+		Limit     int   `json:"limit"`
+		Remaining int   `json:"remaining"`
+		Reset     int64 `json:"reset"`
+	}
+	var response struct {
+		Resources struct {
+			Core    resource `json:"core"`
+			Search  resource `json:"search"`
+			GraphQL resource `json:"graphql"`
+		} `json:"resources"`
+	}
+
+	if err := c.get(ctx, "rate_limit", &response); err != nil {
+		return RateLimits{}, fmt.Errorf("failed to retrieve rate limit: %w", err)
+	}
+
+	convert := func(r resource) RateLimit {
+		return RateLimit{Limit: r.Limit, Remaining: r.Remaining, Reset: time.Unix(r.Reset, 0)}
+	}
+	return RateLimits{
+		Core:    convert(response.Resources.Core),
+		Search:  convert(response.Resources.Search),
+		GraphQL: convert(response.Resources.GraphQL),
+	}, nil
+}
+
+// rateLimitHooks はレスポンスの X-RateLimit-* ヘッダーから残りのクォータを observe に通知するフックを返します
+func rateLimitHooks(observe func(resource string, remaining int)) Hooks {
+	return Hooks{
+		AfterResponse: func(req *http.Request, resp *http.Response, err error, elapsed time.Duration) {
+			if err != nil {
+				return
+			}
+			if remaining, convErr := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); convErr == nil {
+				observe(resp.Header.Get("X-RateLimit-Resource"), remaining)
+			}
+		},
+	}
+}
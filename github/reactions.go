@@ -0,0 +1,29 @@
+package github
+
+import "git.pepabo.com/yukyan/gh-pric/github/model"
+
+// Struct for the reaction rollup included in issue and comment responses
+type reactionRollup struct {
+	PlusOne  int `json:"+1"`
+	MinusOne int `json:"-1"`
+	Laugh    int `json:"laugh"`
+	Hooray   int `json:"hooray"`
+	Confused int `json:"confused"`
+	Heart    int `json:"heart"`
+	Rocket   int `json:"rocket"`
+	Eyes     int `json:"eyes"`
+}
+
+// toModel はリアクションの集計をモデルに変換します
+func (r reactionRollup) toModel() model.Reactions {
+	return model.Reactions{
+		ThumbsUp:   r.PlusOne,
+		ThumbsDown: r.MinusOne,
+		Laugh:      r.Laugh,
+		Hooray:     r.Hooray,
+		Confused:   r.Confused,
+		Heart:      r.Heart,
+		Rocket:     r.Rocket,
+		Eyes:       r.Eyes,
+	}
+}
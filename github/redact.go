@@ -0,0 +1,77 @@
+package github
+
+import (
+	"regexp"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// Replacement for redacted secrets
+const redactedText = "[REDACTED]"
+
+// DefaultSecretPatterns はタイトルや本文、コメント、コミットメッセージから取り除く認証情報とメールアドレスのパターンです
+var DefaultSecretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), // Private keys
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),                                             // GitHub tokens
+	regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}\b`),                                           // GitHub fine-grained tokens
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),                                              // AWS access key IDs
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`),                                           // Slack tokens
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),                                                  // Google API keys
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}\b`),                                                  // OpenAI and Anthropic style API keys
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\b`),          // JSON Web Tokens
+	regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`),          // E-mail addresses
+}
+
+// RedactSecrets はタイトル、本文、コメント、レビュー、コミットメッセージに含まれる patterns に一致する文字列を伏せ字にします
+func RedactSecrets(items []model.Item, patterns []*regexp.Regexp) {
+	for i := range items {
+		items[i].Title = redact(items[i].Title, patterns)
+		items[i].Body = redact(items[i].Body, patterns)
+
+		// New slices keep the comments shared with other copies of the item untouched
+		comments := make([]model.Comment, len(items[i].Comments))
+		for j, comment := range items[i].Comments {
+			comment.Body = redact(comment.Body, patterns)
+			comment.DiffHunk = redact(comment.DiffHunk, patterns)
+			comments[j] = comment
+		}
+		items[i].Comments = comments
+
+		reviews := make([]model.Review, len(items[i].Reviews))
+		for j, review := range items[i].Reviews {
+			review.Body = redact(review.Body, patterns)
+			reviews[j] = review
+		}
+		items[i].Reviews = reviews
+
+		references := make([]model.Reference, len(items[i].ReferencedBy))
+		for j, ref := range items[i].ReferencedBy {
+			ref.Title = redact(ref.Title, patterns)
+			references[j] = ref
+		}
+		items[i].ReferencedBy = references
+
+		items[i].Commits = RedactCommits(items[i].Commits, patterns)
+	}
+}
+
+// RedactCommits はコミットメッセージ（Signed-off-by などのトレーラーのメールアドレスを含む）を伏せ字にしたコピーを返します
+func RedactCommits(commits []model.Commit, patterns []*regexp.Regexp) []model.Commit {
+	if commits == nil {
+		return nil
+	}
+	redacted := make([]model.Commit, len(commits))
+	for i, commit := range commits {
+		commit.Message = redact(commit.Message, patterns)
+		redacted[i] = commit
+	}
+	return redacted
+}
+
+// redact replaces every match of patterns in s
+func redact(s string, patterns []*regexp.Regexp) string {
+	for _, pattern := range patterns {
+		s = pattern.ReplaceAllLiteralString(s, redactedText)
+	}
+	return s
+}
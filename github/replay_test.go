@@ -0,0 +1,145 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// interaction is one recorded request and the response GitHub sent to it
+type interaction struct {
+	Method string            `json:"method"`
+	Path   string            `json:"path"`
+	Query  []string          `json:"query"` // Substrings of the unescaped query string, all of which must match
+	Status int               `json:"status"`
+	Header map[string]string `json:"header"`
+	Body   json.RawMessage   `json:"body"`
+
+	used bool
+}
+
+// replayTransport answers requests with the interactions recorded in a file of testdata
+// Each interaction answers one request, in the order they are recorded
+type replayTransport struct {
+	t            *testing.T
+	mu           sync.Mutex
+	interactions []*interaction
+	requests     []recordedRequest
+}
+
+// recordedRequest is a request the transport received
+type recordedRequest struct {
+	Method string
+	Path   string
+	Query  string // Unescaped query string
+	Body   []byte
+}
+
+// newReplayTransport loads testdata/<name>.json
+func newReplayTransport(t *testing.T, name string) *replayTransport {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &replayTransport{t: t}
+	if err := json.Unmarshal(data, &r.interactions); err != nil {
+		t.Fatalf("testdata/%s.json: %v", name, err)
+	}
+	return r
+}
+
+// newReplayClient returns a client whose requests are answered from testdata/<name>.json
+func newReplayClient(t *testing.T, name string) (*Client, *replayTransport) {
+	t.Helper()
+	t.Setenv("GH_TOKEN", "test-token")
+	transport := newReplayTransport(t, name)
+
+	// Every test starts with a full quota, whatever earlier tests were told by their recordings
+	throttlesMu.Lock()
+	delete(throttles, "")
+	throttlesMu.Unlock()
+
+	client, err := NewClient(ClientOptions{Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A failed request is reported as it is instead of being retried
+	client.MaxRetries = 1
+	return client, transport
+}
+
+func (r *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+	}
+	query, err := url.PathUnescape(req.URL.RawQuery)
+	if err != nil {
+		query = req.URL.RawQuery
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, recordedRequest{Method: req.Method, Path: req.URL.Path, Query: query, Body: body})
+	for _, recorded := range r.interactions {
+		if recorded.used || !recorded.matches(req.Method, req.URL.Path, query) {
+			continue
+		}
+		recorded.used = true
+
+		header := http.Header{"Content-Type": {"application/json; charset=utf-8"}}
+		for name, value := range recorded.Header {
+			header.Set(name, value)
+		}
+		status := recorded.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		return &http.Response{
+			StatusCode: status,
+			Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+			Header:     header,
+			Body:       io.NopCloser(bytes.NewReader(recorded.Body)),
+			Request:    req,
+		}, nil
+	}
+
+	r.t.Errorf("no recorded response for %s %s?%s", req.Method, req.URL.Path, query)
+	return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL.Path)
+}
+
+// matches reports whether the interaction was recorded for the request
+func (i *interaction) matches(method, path, query string) bool {
+	if i.Method != method || i.Path != path {
+		return false
+	}
+	for _, part := range i.Query {
+		if !strings.Contains(query, part) {
+			return false
+		}
+	}
+	return true
+}
+
+// unused returns the interactions no request was answered with
+func (r *replayTransport) unused() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unused []string
+	for _, recorded := range r.interactions {
+		if !recorded.used {
+			unused = append(unused, recorded.Method+" "+recorded.Path+" "+strings.Join(recorded.Query, " "))
+		}
+	}
+	return unused
+}
@@ -0,0 +1,59 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// FetchCreatedRepos は期間内にユーザーが作成したリポジトリを取得します
+// 認証済みユーザー本人であれば /user/repos からプライベートリポジトリも含めて取得します
+func (c *Client) FetchCreatedRepos(ctx context.Context, opts FetchOptions) ([]model.Repository, error) {
+	var repos []struct {
+		FullName    string    `json:"full_name"`
+		URL         string    `json:"html_url"`
+		Description string    `json:"description"`
+		Private     bool      `json:"private"`
+		Fork        bool      `json:"fork"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+
+	reposURL := fmt.Sprintf("users/%s/repos?type=owner&sort=created&direction=desc&per_page=100", opts.Username)
+	if self, err := c.GetUsername(ctx); err == nil && strings.EqualFold(self, opts.Username) {
+		reposURL = "user/repos?affiliation=owner&sort=created&direction=desc&per_page=100"
+	}
+
+	var created []model.Repository
+	err := c.getPages(ctx, reposURL, &repos, func() bool {
+		for _, repo := range repos {
+			// Sorted by creation date, so everything after this is older than the period
+			if repo.CreatedAt.Before(opts.DateRange.StartDate) {
+				return false
+			}
+			if repo.CreatedAt.After(opts.DateRange.EndDate) {
+				continue
+			}
+			if !opts.repoAllowed(repo.FullName) || !opts.visibilityAllowed(repo.Private) {
+				continue
+			}
+
+			created = append(created, model.Repository{
+				Name:        repo.FullName,
+				URL:         repo.URL,
+				Description: repo.Description,
+				Private:     repo.Private,
+				Fork:        repo.Fork,
+				CreatedAt:   repo.CreatedAt,
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to retrieve repositories: %w", err)
+	}
+
+	return created, nil
+}
@@ -0,0 +1,144 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// Default retry settings for API requests
+const (
+	DefaultMaxRetries = 3
+	DefaultRetryWait  = 1 * time.Second
+	maxRetryWait      = 30 * time.Second
+
+	// Longest wait for a rate limit to lift before a retry; a later reset is reported as ErrRateLimited instead
+	maxRateLimitWait = 5 * time.Minute
+)
+
+// get は指定したパスをリトライ付きで取得します
+func (c *Client) get(ctx context.Context, path string, response interface{}) error {
+	return c.withRetry(ctx, func() error {
+		return c.client.DoWithContext(ctx, http.MethodGet, path, nil, response)
+	})
+}
+
+// withRetry は失敗した処理を指数バックオフで再試行します
+// コンテキストがキャンセルされた場合は待機を中断してそのエラーを返します
+// 最終的なエラーは classifyError で ErrRateLimited・ErrAuth・ErrNotFound に分類されます
+func (c *Client) withRetry(ctx context.Context, fn func() error) error {
+	maxRetries := c.MaxRetries
+	if maxRetries < 1 {
+		maxRetries = 1
+	}
+
+	var err error
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		err = fn()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !isRetryable(err) {
+			return classifyError(err)
+		}
+
+		// Do not wait after the last attempt
+		if attempt < maxRetries-1 {
+			wait, ok := retryDelay(err, attempt, c.RetryWait)
+			if !ok {
+				return classifyError(err)
+			}
+			c.retrying(attempt+2, maxRetries, wait, err)
+			if waitErr := sleep(ctx, wait); waitErr != nil {
+				return waitErr
+			}
+		}
+	}
+	return classifyError(err)
+}
+
+// sleep は指定した時間だけ待機します（コンテキストがキャンセルされた場合は中断します）
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isRetryable はエラーが再試行に値するかどうかを判定します
+func isRetryable(err error) bool {
+	// GraphQL errors describe problems with the query itself
+	var graphqlErr *api.GraphQLError
+	if errors.As(err, &graphqlErr) {
+		return false
+	}
+
+	var httpErr *api.HTTPError
+	if !errors.As(err, &httpErr) {
+		// Network errors and the like are always retried
+		return true
+	}
+
+	switch {
+	case httpErr.StatusCode == http.StatusTooManyRequests:
+		return true
+	case httpErr.StatusCode == http.StatusForbidden:
+		// 403 is only temporary when it comes from a rate limit
+		return isRateLimited(httpErr)
+	case httpErr.StatusCode >= 500:
+		return true
+	default:
+		return false
+	}
+}
+
+// isRateLimited はレスポンスがレート制限によるものかどうかを判定します
+func isRateLimited(httpErr *api.HTTPError) bool {
+	return httpErr.Headers.Get("Retry-After") != "" || httpErr.Headers.Get("X-RateLimit-Remaining") == "0"
+}
+
+// retryDelay は次の再試行までの待機時間を計算します
+// baseWait は最初の再試行までの待機時間で、以降は倍々に増えます
+// サーバーが maxRateLimitWait より長い待機を求めた場合は false を返し、再試行せずにレート制限として報告させます
+func retryDelay(err error, attempt int, baseWait time.Duration) (time.Duration, bool) {
+	var httpErr *api.HTTPError
+	if errors.As(err, &httpErr) {
+		// Respect the wait time requested by the server
+		if seconds, convErr := strconv.Atoi(httpErr.Headers.Get("Retry-After")); convErr == nil {
+			wait := time.Duration(seconds) * time.Second
+			return wait, wait <= maxRateLimitWait
+		}
+
+		// Primary rate limit: wait until the quota resets
+		if httpErr.Headers.Get("X-RateLimit-Remaining") == "0" {
+			if reset, convErr := strconv.ParseInt(httpErr.Headers.Get("X-RateLimit-Reset"), 10, 64); convErr == nil {
+				if wait := time.Until(time.Unix(reset, 0)); wait > 0 {
+					return wait, wait <= maxRateLimitWait
+				}
+			}
+		}
+	}
+
+	// Exponential backoff with jitter, capped unless the base wait itself is longer
+	limit := maxRetryWait
+	if baseWait > limit {
+		limit = baseWait
+	}
+	wait := baseWait << attempt
+	if wait > limit || wait < baseWait {
+		wait = limit
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1)), true
+}
@@ -0,0 +1,243 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// Number of results requested per search page
+const searchPerPage = 100
+
+// GitHub search only returns the first 1000 results of any query
+const searchResultLimit = 1000
+
+// Search windows are not split any further than this
+const minSearchWindow = time.Hour
+
+// Search requests in flight at once, shared by every goroutine using a client
+// GitHub's secondary rate limits punish bursts of concurrent searches
+const maxConcurrentSearches = 3
+
+// Struct for a single page of the search API response
+type searchResponse struct {
+	TotalCount int `json:"total_count"`
+	Items      []struct {
+		NodeID        string    `json:"node_id"`
+		URL           string    `json:"html_url"`
+		Number        int       `json:"number"`
+		Title         string    `json:"title"`
+		State         string    `json:"state"`
+		CreatedAt     time.Time `json:"created_at"`
+		UpdatedAt     time.Time `json:"updated_at"`
+		ClosedAt      time.Time `json:"closed_at"`
+		RepositoryURL string    `json:"repository_url"`
+		User          struct {
+			Login string `json:"login"`
+			Type  string `json:"type"`
+		} `json:"user"`
+		Assignees []struct {
+			Login string `json:"login"`
+		} `json:"assignees"`
+		Labels []struct {
+			Name  string `json:"name"`
+			Color string `json:"color"`
+		} `json:"labels"`
+		Reactions   reactionRollup `json:"reactions"`
+		PullRequest struct {
+			MergedAt time.Time `json:"merged_at"`
+		} `json:"pull_request"`
+	} `json:"items"`
+}
+
+// searchRange は opts.DateFields の日付が期間内のアイテムをそれぞれ検索し、重複を除いて結合します
+func (c *Client) searchRange(ctx context.Context, qualifiers, itemType string, opts FetchOptions) ([]model.Item, bool, error) {
+	var items []model.Item
+	truncated := false
+	seen := make(map[string]bool)
+	for _, dateField := range opts.dateFields() {
+		fieldItems, fieldTruncated, err := c.searchItems(ctx, qualifiers, itemType, dateField, opts)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, item := range fieldItems {
+			if !seen[item.Key()] {
+				seen[item.Key()] = true
+				items = append(items, item)
+			}
+		}
+		truncated = truncated || fieldTruncated
+	}
+
+	return items, truncated, nil
+}
+
+// searchItems は検索クエリに一致し、dateField（created または closed）が期間内のアイテムを取得します
+// 結果が検索上限を超える場合は期間を分割して取得します
+func (c *Client) searchItems(ctx context.Context, qualifiers, itemType, dateField string, opts FetchOptions) ([]model.Item, bool, error) {
+	dateRange := opts.DateRange
+	query := fmt.Sprintf("search/issues?q=%s+%s:%s..%s",
+		qualifiers, dateField, formatSearchTime(dateRange.StartDate), formatSearchTime(dateRange.EndDate))
+	if !opts.UpdatedSince.IsZero() {
+		query += fmt.Sprintf("+updated:>=%s", formatSearchTime(opts.UpdatedSince))
+	}
+	query += opts.scopeQualifiers()
+	query += fmt.Sprintf("&per_page=%d", searchPerPage)
+
+	items := []model.Item{}
+	page := 1
+	truncated := false
+
+	for {
+		var response searchResponse
+		pageQuery := fmt.Sprintf("%s&page=%d", query, page)
+
+		err := c.search(ctx, pageQuery, &response)
+		if err != nil {
+			return nil, false, fmt.Errorf("Failed to retrieve %ss: %w", itemType, err)
+		}
+
+		// Split the window in two when the search cannot return every result
+		if page == 1 && response.TotalCount > searchResultLimit && dateRange.EndDate.Sub(dateRange.StartDate) > minSearchWindow {
+			return c.searchSplitWindow(ctx, qualifiers, itemType, dateField, opts)
+		}
+
+		// Exit if the response is empty
+		if len(response.Items) == 0 {
+			break
+		}
+
+		for _, result := range response.Items {
+			// Skip items outside the date range
+			date := result.CreatedAt
+			if dateField == "closed" {
+				date = result.ClosedAt
+			}
+			if date.After(dateRange.EndDate) || date.Before(dateRange.StartDate) {
+				continue
+			}
+
+			// Extract repository name
+			repoParts := strings.Split(result.RepositoryURL, "/")
+			repoName := ""
+			if len(repoParts) >= 2 {
+				repoName = fmt.Sprintf("%s/%s", repoParts[len(repoParts)-2], repoParts[len(repoParts)-1])
+			}
+
+			// Enforce the repository filters again on the results
+			if !opts.repoAllowed(repoName) {
+				continue
+			}
+			private, err := c.resultVisibility(ctx, repoName, opts)
+			if err != nil {
+				return nil, false, err
+			}
+			if !opts.visibilityAllowed(private) {
+				continue
+			}
+
+			// Extract assignees
+			assignees := make([]string, len(result.Assignees))
+			for i, a := range result.Assignees {
+				assignees[i] = a.Login
+			}
+
+			// Extract labels
+			labels := make([]string, len(result.Labels))
+			labelColors := make(map[string]string, len(result.Labels))
+			for i, l := range result.Labels {
+				labels[i] = l.Name
+				labelColors[l.Name] = l.Color
+			}
+
+			// Merged PRs can be told apart here already, so they are correct even without details
+			state := result.State
+			if !result.PullRequest.MergedAt.IsZero() {
+				state = "merged"
+			}
+
+			items = append(items, model.Item{
+				Type:        itemType,
+				Number:      result.Number,
+				Title:       result.Title,
+				URL:         result.URL,
+				State:       state,
+				CreatedAt:   result.CreatedAt,
+				UpdatedAt:   result.UpdatedAt,
+				ClosedAt:    result.ClosedAt,
+				MergedAt:    result.PullRequest.MergedAt,
+				Author:      result.User.Login,
+				AuthorIsBot: isBot(result.User.Login, result.User.Type),
+				Assignees:   assignees,
+				Labels:      labels,
+				LabelColors: labelColors,
+				Repository:  repoName,
+				Private:     private,
+				Reactions:   result.Reactions.toModel(),
+				NodeID:      result.NodeID,
+			})
+		}
+
+		// Exit once every result reported by the search has been read
+		fetched := page * searchPerPage
+		if fetched >= response.TotalCount {
+			break
+		}
+
+		// Exit if the page limit or the search result limit has been reached
+		if (opts.MaxPages > 0 && page >= opts.MaxPages) || fetched >= searchResultLimit {
+			truncated = true
+			break
+		}
+
+		page++
+	}
+
+	return items, truncated, nil
+}
+
+// search は検索 API を呼び出します（同時に送る検索リクエストは maxConcurrentSearches 件まで）
+func (c *Client) search(ctx context.Context, path string, response interface{}) error {
+	if c.searches != nil {
+		select {
+		case c.searches <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		defer func() { <-c.searches }()
+	}
+	return c.get(ctx, path, response)
+}
+
+// searchSplitWindow は期間を半分に分けてそれぞれ検索し、結果を結合します
+func (c *Client) searchSplitWindow(ctx context.Context, qualifiers, itemType, dateField string, opts FetchOptions) ([]model.Item, bool, error) {
+	dateRange := opts.DateRange
+	middle := dateRange.StartDate.Add(dateRange.EndDate.Sub(dateRange.StartDate) / 2).Truncate(time.Second)
+	windows := []model.DateRange{
+		{StartDate: dateRange.StartDate, EndDate: middle},
+		{StartDate: middle.Add(time.Second), EndDate: dateRange.EndDate},
+	}
+
+	var items []model.Item
+	truncated := false
+	for _, window := range windows {
+		windowOpts := opts
+		windowOpts.DateRange = window
+		windowItems, windowTruncated, err := c.searchItems(ctx, qualifiers, itemType, dateField, windowOpts)
+		if err != nil {
+			return nil, false, err
+		}
+		items = append(items, windowItems...)
+		truncated = truncated || windowTruncated
+	}
+
+	return items, truncated, nil
+}
+
+// formatSearchTime は検索クエリで使用する日時文字列を返します
+func formatSearchTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
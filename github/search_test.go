@@ -0,0 +1,117 @@
+package github
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// period returns the days from start to end inclusive, in UTC
+func period(start, end string) model.DateRange {
+	from, _ := time.Parse("2006-01-02", start)
+	to, _ := time.Parse("2006-01-02", end)
+	return model.DateRange{StartDate: from, EndDate: to.Add(24*time.Hour - time.Second)}
+}
+
+func TestFetchPRsPaginates(t *testing.T) {
+	client, transport := newReplayClient(t, "search_pagination")
+
+	items, truncated, err := client.FetchPRs(context.Background(), FetchOptions{
+		Username:    "octocat",
+		Involvement: "created",
+		DateRange:   period("2024-03-01", "2024-03-07"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if truncated {
+		t.Error("truncated = true, want false")
+	}
+	if unused := transport.unused(); len(unused) > 0 {
+		t.Errorf("pages not requested: %v", unused)
+	}
+
+	// The PR created before the period is left out
+	want := []struct {
+		repo   string
+		number int
+		state  string
+	}{
+		{"octo-org/api", 12, "merged"},
+		{"octo-org/web", 7, "open"},
+		{"octo-org/api", 15, "open"},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d: %+v", len(items), len(want), items)
+	}
+	for i, w := range want {
+		item := items[i]
+		if item.Repository != w.repo || item.Number != w.number || item.State != w.state {
+			t.Errorf("items[%d] = %s#%d (%s), want %s#%d (%s)", i, item.Repository, item.Number, item.State, w.repo, w.number, w.state)
+		}
+		if item.Type != "PR" {
+			t.Errorf("items[%d].Type = %q, want PR", i, item.Type)
+		}
+	}
+	if items[0].NodeID != "PR_kwDOAAABc84AAAAB" {
+		t.Errorf("NodeID = %q", items[0].NodeID)
+	}
+	if got := items[0].LabelColors["feature"]; got != "a2eeef" {
+		t.Errorf("LabelColors[feature] = %q, want a2eeef", got)
+	}
+	if len(items[0].Assignees) != 1 || items[0].Assignees[0] != "hubot" {
+		t.Errorf("Assignees = %v, want [hubot]", items[0].Assignees)
+	}
+}
+
+func TestFetchPRsStopsAtMaxPages(t *testing.T) {
+	client, transport := newReplayClient(t, "search_pagination")
+
+	items, truncated, err := client.FetchPRs(context.Background(), FetchOptions{
+		Username:    "octocat",
+		Involvement: "created",
+		DateRange:   period("2024-03-01", "2024-03-07"),
+		MaxPages:    1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !truncated {
+		t.Error("truncated = false, want true")
+	}
+	if len(items) != 2 {
+		t.Errorf("got %d items, want the 2 of the first page", len(items))
+	}
+	if len(transport.requests) != 1 {
+		t.Errorf("sent %d requests, want 1", len(transport.requests))
+	}
+}
+
+func TestFetchIssuesSplitsLargeWindows(t *testing.T) {
+	client, transport := newReplayClient(t, "search_split")
+
+	items, truncated, err := client.FetchIssues(context.Background(), FetchOptions{
+		Username:    "octocat",
+		Involvement: "commented",
+		DateRange:   period("2024-03-01", "2024-03-02"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if truncated {
+		t.Error("truncated = true, want false")
+	}
+	if unused := transport.unused(); len(unused) > 0 {
+		t.Errorf("windows not searched: %v", unused)
+	}
+
+	// More than 1000 results for the two days, so each day is searched on its own
+	if len(items) != 2 || items[0].Number != 40 || items[1].Number != 41 {
+		t.Fatalf("got %+v, want octo-org/api#40 and octo-org/web#41", items)
+	}
+	if !items[1].AuthorIsBot {
+		t.Error("the Issue opened by dependabot[bot] is not marked as a bot's")
+	}
+}
@@ -0,0 +1,124 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// State は前回の実行で取得したデータを保持します
+type State struct {
+	LastRun   time.Time    `json:"last_run"`   // Time the last successful fetch started
+	StartDate time.Time    `json:"start_date"` // Earliest creation date covered by Items
+	Items     []model.Item `json:"items"`      // Items collected so far
+}
+
+// Load は保存済みの状態を読み込みます（存在しない場合は空の状態を返します）
+func Load(dir, username string) (*State, error) {
+	data, err := os.ReadFile(statePath(dir, username))
+	if errors.Is(err, os.ErrNotExist) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Save は状態をファイルに保存します
+func Save(dir, username string, s *State) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	// Write atomically so an interrupted run never corrupts the saved dataset
+	path := statePath(dir, username)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Covers は保存済みのデータが指定した期間の開始日以前から揃っているかどうかを返します
+func (s *State) Covers(dateRange model.DateRange) bool {
+	return !s.LastRun.IsZero() && !dateRange.StartDate.Before(s.StartDate)
+}
+
+// Merge は新しく取得したアイテムで保存済みのアイテムを置き換え、新規のものは追加します
+func (s *State) Merge(items []model.Item) {
+	index := make(map[string]int, len(s.Items))
+	for i, item := range s.Items {
+		index[item.Key()] = i
+	}
+
+	for _, item := range items {
+		if i, ok := index[item.Key()]; ok {
+			s.Items[i] = item
+			continue
+		}
+		index[item.Key()] = len(s.Items)
+		s.Items = append(s.Items, item)
+	}
+}
+
+// ItemsIn は指定した期間内に作成された（または closedInRange の場合クローズされた）アイテムを返します
+func (s *State) ItemsIn(dateRange model.DateRange, closedInRange bool) []model.Item {
+	var items []model.Item
+	for _, item := range s.Items {
+		if inRange(item.CreatedAt, dateRange) || (closedInRange && inRange(item.ClosedAt, dateRange)) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// 日時が期間内かどうかを返します
+func inRange(t time.Time, dateRange model.DateRange) bool {
+	return !t.Before(dateRange.StartDate) && !t.After(dateRange.EndDate)
+}
+
+// ユーザーごとの状態ファイルのパスを返します
+func statePath(dir, username string) string {
+	return filepath.Join(dir, username+".json")
+}
+
+// Repositories は保存済みのすべてのユーザーのデータに含まれるリポジトリ名を重複なく返します
+func Repositories(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var repos []string
+	for _, path := range paths {
+		s, err := Load(dir, strings.TrimSuffix(filepath.Base(path), ".json"))
+		if err != nil {
+			continue
+		}
+		for _, item := range s.Items {
+			if item.Repository != "" && !seen[item.Repository] {
+				seen[item.Repository] = true
+				repos = append(repos, item.Repository)
+			}
+		}
+	}
+	sort.Strings(repos)
+	return repos, nil
+}
@@ -0,0 +1,273 @@
+package github
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Quota windows of the API resources, used until GitHub reports the actual state
+var quotaWindows = map[string]struct {
+	limit  int
+	window time.Duration
+}{
+	"core":        {5000, time.Hour},
+	"search":      {30, time.Minute},
+	"code_search": {10, time.Minute},
+	"graphql":     {5000, time.Hour},
+}
+
+// bucket は API リソース 1 つ分のトークンバケットです
+// レスポンスの X-RateLimit-* ヘッダーを見るたびに、残りのクォータがリセットまで持つように補充の速さとバーストの大きさを調整します
+type bucket struct {
+	mu     sync.Mutex
+	limit  float64       // Requests allowed per window
+	window time.Duration // Length of the quota window
+	tokens float64       // Requests that may be sent right away (negative = owed by waiting requests)
+	burst  float64       // Most tokens the bucket holds
+	rate   float64       // Tokens added per second
+	last   time.Time     // When tokens were last added
+	reset  time.Time     // When the current window ends (zero = unknown)
+	paused time.Time     // No request is sent before this time (Retry-After)
+
+	// unlimited is set when the host reports no rate limit, e.g. a GitHub Enterprise Server with rate limiting disabled
+	unlimited bool
+}
+
+// newBucket returns a full bucket for a quota of limit requests per window
+func newBucket(limit int, window time.Duration, now time.Time) *bucket {
+	b := &bucket{limit: float64(limit), window: window, last: now}
+	b.renew()
+	return b
+}
+
+// renew starts a fresh window: half of the quota may be sent at once, the rest at an even pace
+func (b *bucket) renew() {
+	b.burst = b.limit / 2
+	b.tokens = b.burst
+	b.rate = b.limit / b.window.Seconds()
+	b.reset = time.Time{}
+}
+
+// refill adds the tokens earned since the last call
+func (b *bucket) refill(now time.Time) {
+	if !b.reset.IsZero() && !now.Before(b.reset) {
+		b.renew()
+	}
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += b.rate * elapsed
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+}
+
+// take は 1 リクエスト分のトークンを取り出し、送信までに待つ時間を返します
+func (b *bucket) take(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.unlimited {
+		return b.afterPause(now, 0)
+	}
+	b.refill(now)
+	b.tokens--
+	if b.tokens >= 0 {
+		return b.afterPause(now, 0)
+	}
+
+	// The quota is renewed at the reset, so nobody waits longer than that
+	var wait time.Duration
+	if b.rate > 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	if !b.reset.IsZero() && (b.rate <= 0 || now.Add(wait).After(b.reset)) {
+		wait = b.reset.Sub(now)
+	}
+	return b.afterPause(now, wait)
+}
+
+// afterPause extends wait to the end of a pause
+func (b *bucket) afterPause(now time.Time, wait time.Duration) time.Duration {
+	if until := b.paused.Sub(now); until > wait {
+		return until
+	}
+	return wait
+}
+
+// observe は GitHub が報告したクォータに合わせてバケットを調整します
+// 残りが少ないほど補充は遅く、一度に送れる数は少なくなり、使い切るとリセットまで止まります
+func (b *bucket) observe(limit, remaining int, reset, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	untilReset := reset.Sub(now).Seconds()
+	if untilReset <= 0 {
+		return
+	}
+	if limit > 0 {
+		b.limit = float64(limit)
+	}
+	b.unlimited = false
+	b.reset = reset
+	b.rate = float64(remaining) / untilReset
+	b.burst = float64(remaining) / 2
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// unlimit stops counting requests until GitHub reports a quota again
+func (b *bucket) unlimit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.unlimited = true
+}
+
+// pause は until まで新しいリクエストを止めます（Retry-After を返すセカンダリレート制限など）
+func (b *bucket) pause(until time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if until.After(b.paused) {
+		b.paused = until
+	}
+}
+
+// throttle はホストごとの API リソースのバケットをまとめたものです
+type throttle struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	resources map[string]string // API resource GitHub reported for each route (X-RateLimit-Resource)
+}
+
+// Throttles by GitHub host, shared by every client of the process because the quota belongs to the token
+var (
+	throttlesMu sync.Mutex
+	throttles   = make(map[string]*throttle)
+)
+
+// throttleFor returns the throttle shared by the clients of host
+func throttleFor(host string) *throttle {
+	throttlesMu.Lock()
+	defer throttlesMu.Unlock()
+	t, ok := throttles[host]
+	if !ok {
+		t = &throttle{buckets: make(map[string]*bucket), resources: make(map[string]string)}
+		throttles[host] = t
+	}
+	return t
+}
+
+// routeOf groups request paths that count against the same API resource
+// Each kind of search is its own route, since GitHub gives e.g. code search a smaller quota than issue search
+func routeOf(path string) string {
+	if strings.HasSuffix(path, "/graphql") {
+		return "graphql"
+	}
+	if i := strings.Index(path, "/search/"); i >= 0 {
+		kind, _, _ := strings.Cut(path[i+len("/search/"):], "/")
+		return "search/" + kind
+	}
+	return "core"
+}
+
+// guessResource returns the API resource of a route GitHub has not reported yet
+func guessResource(route string) string {
+	switch {
+	case route == "graphql":
+		return "graphql"
+	case route == "search/code":
+		return "code_search"
+	case strings.HasPrefix(route, "search/"):
+		return "search"
+	}
+	return "core"
+}
+
+// bucket returns the bucket of the API resource a request on route counts against
+// The resource GitHub reported for the route is used once a response arrived, the one guessed from the path before that
+func (t *throttle) bucket(route string) *bucket {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	resource, ok := t.resources[route]
+	if !ok {
+		resource = guessResource(route)
+	}
+	return t.bucketLocked(resource)
+}
+
+// learn records the API resource GitHub reported for route and returns its bucket
+// The core route is not remapped, since its endpoints count against several resources (e.g. dependency_snapshots)
+func (t *throttle) learn(route, resource string) *bucket {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if route != "core" {
+		if t.resources == nil {
+			t.resources = make(map[string]string)
+		}
+		t.resources[route] = resource
+	}
+	return t.bucketLocked(resource)
+}
+
+// bucketLocked returns the bucket of resource, creating it with the assumed quota; t.mu must be held
+func (t *throttle) bucketLocked(resource string) *bucket {
+	b, ok := t.buckets[resource]
+	if !ok {
+		quota, known := quotaWindows[resource]
+		if !known {
+			quota = quotaWindows["core"]
+		}
+		b = newBucket(quota.limit, quota.window, time.Now())
+		t.buckets[resource] = b
+	}
+	return b
+}
+
+// throttleTransport は API のクォータに合わせてリクエストの送信を待たせます
+// 待っている間にリクエストのコンテキストがキャンセルされた場合はそのエラーを返します
+type throttleTransport struct {
+	base     http.RoundTripper
+	throttle *throttle
+}
+
+// RoundTrip はバケットからトークンを取り出せるまで待ってからリクエストを送信し、レスポンスのヘッダーでバケットを調整します
+func (t *throttleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	route := routeOf(req.URL.Path)
+	b := t.throttle.bucket(route)
+	if wait := b.take(time.Now()); wait > 0 {
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Later requests on the route count against the resource GitHub reports, which the path only approximates
+	if resource := resp.Header.Get("X-RateLimit-Resource"); resource != "" {
+		b = t.throttle.learn(route, resource)
+	}
+
+	now := time.Now()
+	if seconds, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil {
+		b.pause(now.Add(time.Duration(seconds) * time.Second))
+	}
+	limit, limitErr := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	remaining, remainingErr := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	reset, resetErr := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	switch {
+	case limitErr == nil && remainingErr == nil && resetErr == nil:
+		b.observe(limit, remaining, time.Unix(reset, 0), now)
+	case resp.Header.Get("X-RateLimit-Limit") == "" && resp.StatusCode < http.StatusBadRequest:
+		b.unlimit()
+	}
+	return resp, nil
+}
@@ -0,0 +1,199 @@
+package github
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+var throttleStart = time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+func TestBucketPacesAfterBurst(t *testing.T) {
+	b := newBucket(30, time.Minute, throttleStart)
+
+	// Half of the quota goes out at once
+	for i := 0; i < 15; i++ {
+		if wait := b.take(throttleStart); wait != 0 {
+			t.Fatalf("request %d waits %s within the burst", i+1, wait)
+		}
+	}
+	// The rest at 30 per minute, one every 2 seconds
+	if wait := b.take(throttleStart); wait != 2*time.Second {
+		t.Errorf("request 16 waits %s, want 2s", wait)
+	}
+	if wait := b.take(throttleStart); wait != 4*time.Second {
+		t.Errorf("request 17 waits %s, want 4s", wait)
+	}
+	// The tokens earned meanwhile pay back what the waiting requests owe
+	if wait := b.take(throttleStart.Add(10 * time.Second)); wait != 0 {
+		t.Errorf("request after 10s waits %s, want 0", wait)
+	}
+}
+
+func TestBucketFollowsReportedQuota(t *testing.T) {
+	b := newBucket(5000, time.Hour, throttleStart)
+	b.observe(5000, 10, throttleStart.Add(10*time.Second), throttleStart)
+
+	// 10 requests left for 10 seconds: 5 at once, then one per second
+	for i := 0; i < 5; i++ {
+		if wait := b.take(throttleStart); wait != 0 {
+			t.Fatalf("request %d waits %s within the burst", i+1, wait)
+		}
+	}
+	if wait := b.take(throttleStart); wait != time.Second {
+		t.Errorf("request 6 waits %s, want 1s", wait)
+	}
+}
+
+func TestBucketWaitsForResetWhenExhausted(t *testing.T) {
+	b := newBucket(5000, time.Hour, throttleStart)
+	reset := throttleStart.Add(30 * time.Second)
+	b.observe(5000, 0, reset, throttleStart)
+
+	if wait := b.take(throttleStart); wait != 30*time.Second {
+		t.Errorf("wait = %s, want the 30s until the reset", wait)
+	}
+	// A new window starts with the full quota again
+	if wait := b.take(reset.Add(time.Second)); wait != 0 {
+		t.Errorf("wait after the reset = %s, want 0", wait)
+	}
+}
+
+func TestBucketPause(t *testing.T) {
+	b := newBucket(5000, time.Hour, throttleStart)
+	b.pause(throttleStart.Add(5 * time.Second))
+	// An earlier end does not shorten the pause
+	b.pause(throttleStart.Add(time.Second))
+
+	if wait := b.take(throttleStart); wait != 5*time.Second {
+		t.Errorf("wait = %s, want the 5s of the pause", wait)
+	}
+	if wait := b.take(throttleStart.Add(5 * time.Second)); wait != 0 {
+		t.Errorf("wait after the pause = %s, want 0", wait)
+	}
+}
+
+func TestBucketUnlimited(t *testing.T) {
+	b := newBucket(30, time.Minute, throttleStart)
+	b.unlimit()
+	for i := 0; i < 100; i++ {
+		if wait := b.take(throttleStart); wait != 0 {
+			t.Fatalf("request %d waits %s without a rate limit", i+1, wait)
+		}
+	}
+	// A reported quota applies again
+	b.observe(30, 0, throttleStart.Add(time.Minute), throttleStart)
+	if wait := b.take(throttleStart); wait != time.Minute {
+		t.Errorf("wait = %s, want the minute until the reset", wait)
+	}
+}
+
+func TestThrottleTransport(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		resource string
+		check    func(t *testing.T, b *bucket)
+	}{
+		{
+			name:     "exhausted search quota",
+			url:      "https://api.github.com/search/issues?q=author:octocat",
+			resource: "search",
+			check: func(t *testing.T, b *bucket) {
+				if wait, until := b.take(time.Now()), time.Until(time.Unix(4102444800, 0)); wait < until-time.Minute {
+					t.Errorf("next search waits %s, want until the reported reset", wait)
+				}
+			},
+		},
+		{
+			name:     "exhausted code search quota",
+			url:      "https://api.github.com/search/code?q=repo:octo-org/api",
+			resource: "code_search",
+			check: func(t *testing.T, b *bucket) {
+				if wait, until := b.take(time.Now()), time.Until(time.Unix(4102444800, 0)); wait < until-time.Minute {
+					t.Errorf("next code search waits %s, want until the reported reset", wait)
+				}
+			},
+		},
+		{
+			name:     "resource only known from the response",
+			url:      "https://api.github.com/search/labels?q=bug",
+			resource: "label_search",
+			check: func(t *testing.T, b *bucket) {
+				if wait := b.take(time.Now()); wait < time.Hour {
+					t.Errorf("next label search waits %s, want until the reported reset", wait)
+				}
+			},
+		},
+		{
+			name:     "secondary rate limit",
+			url:      "https://api.github.com/repos/octo-org/api/issues/40/timeline",
+			resource: "core",
+			check: func(t *testing.T, b *bucket) {
+				if wait := b.take(time.Now()); wait < 29*time.Second || wait > 30*time.Second {
+					t.Errorf("next request waits %s, want the 30s of Retry-After", wait)
+				}
+			},
+		},
+		{
+			name:     "server without rate limit",
+			url:      "https://ghe.example.com/api/v3/user",
+			resource: "core",
+			check: func(t *testing.T, b *bucket) {
+				// Far more than the assumed quota of 5000 per hour goes out at once
+				for i := 0; i < 10000; i++ {
+					if wait := b.take(time.Now()); wait != 0 {
+						t.Fatalf("request %d waits %s, want 0", i+1, wait)
+					}
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := &throttle{buckets: make(map[string]*bucket), resources: make(map[string]string)}
+			transport := &throttleTransport{base: newReplayTransport(t, "throttle"), throttle: th}
+
+			req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			b := th.buckets[tt.resource]
+			if b == nil {
+				t.Fatalf("no %s bucket", tt.resource)
+			}
+			tt.check(t, b)
+
+			// Later requests on the route count against the reported resource
+			if got := th.bucket(routeOf(req.URL.Path)); got != b {
+				t.Errorf("later requests on %s use another bucket than %s", req.URL.Path, tt.resource)
+			}
+		})
+	}
+}
+
+func TestThrottleSeparatesSearchKinds(t *testing.T) {
+	th := &throttle{buckets: make(map[string]*bucket), resources: make(map[string]string)}
+	transport := &throttleTransport{base: newReplayTransport(t, "throttle"), throttle: th}
+
+	req, err := http.NewRequest(http.MethodGet, "https://api.github.com/search/code?q=repo:octo-org/api", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// Running out of code searches leaves issue searches alone
+	if wait := th.bucket(routeOf("/search/issues")).take(time.Now()); wait != 0 {
+		t.Errorf("issue search waits %s after the code search quota ran out, want 0", wait)
+	}
+}
@@ -0,0 +1,129 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// Timeline event types included in the report
+var timelineEventTypes = map[string]bool{
+	"labeled":    true,
+	"unlabeled":  true,
+	"assigned":   true,
+	"unassigned": true,
+	"closed":     true,
+	"reopened":   true,
+	"merged":     true,
+}
+
+// FetchTimeline は期間内に発生したアイテムのタイムラインイベントを取得します
+// 他の Issue や PR からの参照（cross-referenced）は ReferencedBy に記録します
+func (c *Client) FetchTimeline(ctx context.Context, item *model.Item, dateRange model.DateRange) error {
+	repoPath := getRepoPathFromURL(item.Repository)
+	if repoPath == "" {
+		return fmt.Errorf("Failed to extract repository path: %s", item.Repository)
+	}
+
+	var events []struct {
+		Event     string    `json:"event"`
+		CreatedAt time.Time `json:"created_at"`
+		Actor     struct {
+			Login string `json:"login"`
+		} `json:"actor"`
+		Label struct {
+			Name string `json:"name"`
+		} `json:"label"`
+		Assignee struct {
+			Login string `json:"login"`
+		} `json:"assignee"`
+		RequestedReviewer struct {
+			Login string `json:"login"`
+		} `json:"requested_reviewer"`
+		RequestedTeam struct {
+			Slug string `json:"slug"`
+		} `json:"requested_team"`
+		Source struct {
+			Issue struct {
+				Number      int       `json:"number"`
+				Title       string    `json:"title"`
+				URL         string    `json:"html_url"`
+				PullRequest *struct{} `json:"pull_request"`
+				Repository  struct {
+					FullName string `json:"full_name"`
+				} `json:"repository"`
+			} `json:"issue"`
+		} `json:"source"`
+	}
+
+	// Add events within the period to the Item struct, page by page
+	referenced := make(map[string]bool)
+	timelineURL := fmt.Sprintf("repos/%s/issues/%d/timeline?per_page=100", repoPath, item.Number)
+	err := c.getPages(ctx, timelineURL, &events, func() bool {
+		for _, e := range events {
+			// Review requests are kept regardless of the period to measure review turnaround
+			if e.Event == "review_requested" {
+				reviewer := e.RequestedReviewer.Login
+				if reviewer == "" {
+					reviewer = e.RequestedTeam.Slug
+				}
+				item.ReviewRequests = append(item.ReviewRequests, model.ReviewRequest{Reviewer: reviewer, RequestedAt: e.CreatedAt})
+			}
+
+			if e.CreatedAt.Before(dateRange.StartDate) || e.CreatedAt.After(dateRange.EndDate) {
+				continue
+			}
+
+			// The same item may reference this one several times
+			if e.Event == "cross-referenced" {
+				source := e.Source.Issue
+				if source.URL == "" || referenced[source.URL] {
+					continue
+				}
+				referenced[source.URL] = true
+
+				refType := "Issue"
+				if source.PullRequest != nil {
+					refType = "PR"
+				}
+				item.ReferencedBy = append(item.ReferencedBy, model.Reference{
+					Type:         refType,
+					Number:       source.Number,
+					Title:        source.Title,
+					URL:          source.URL,
+					Repository:   source.Repository.FullName,
+					Actor:        e.Actor.Login,
+					ReferencedAt: e.CreatedAt,
+				})
+				continue
+			}
+
+			if !timelineEventTypes[e.Event] {
+				continue
+			}
+
+			detail := ""
+			switch e.Event {
+			case "labeled", "unlabeled":
+				detail = e.Label.Name
+			case "assigned", "unassigned":
+				detail = e.Assignee.Login
+			}
+
+			item.Events = append(item.Events, model.Event{
+				Type:      e.Event,
+				Actor:     e.Actor.Login,
+				Detail:    detail,
+				CreatedAt: e.CreatedAt,
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to retrieve timeline: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package github
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// traceHooks はすべての API リクエストの URL・ステータス・レート制限状況と再試行をログに出力するフックを返します
+func traceHooks(out io.Writer) Hooks {
+	var mu sync.Mutex
+	// Lines are written whole so that concurrent requests do not interleave
+	logf := func(format string, args ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(out, "[api] "+format+"\n", args...)
+	}
+
+	return Hooks{
+		AfterResponse: func(req *http.Request, resp *http.Response, err error, elapsed time.Duration) {
+			elapsed = elapsed.Round(time.Millisecond)
+			if err != nil {
+				logf("%s %s -> error: %v (%s)", req.Method, req.URL, err, elapsed)
+				return
+			}
+
+			line := fmt.Sprintf("%s %s -> %d (%s)", req.Method, req.URL, resp.StatusCode, elapsed)
+			if remaining := resp.Header.Get("X-RateLimit-Remaining"); remaining != "" {
+				line += fmt.Sprintf(" rate limit %s: %s/%s remaining",
+					resp.Header.Get("X-RateLimit-Resource"), remaining, resp.Header.Get("X-RateLimit-Limit"))
+				if reset := resp.Header.Get("X-RateLimit-Reset"); reset != "" {
+					line += ", resets " + formatReset(reset)
+				}
+			}
+			logf("%s", line)
+		},
+		OnRetry: func(attempt, maxAttempts int, wait time.Duration, err error) {
+			logf("retrying in %s (attempt %d/%d): %v", wait.Round(time.Millisecond), attempt, maxAttempts, err)
+		},
+	}
+}
+
+// formatReset は X-RateLimit-Reset の UNIX 時刻を読みやすい形式に変換します
+func formatReset(reset string) string {
+	seconds, err := strconv.ParseInt(reset, 10, 64)
+	if err != nil {
+		return reset
+	}
+	return time.Unix(seconds, 0).Format("15:04:05")
+}
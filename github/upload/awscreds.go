@@ -0,0 +1,437 @@
+package upload
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Struct to hold AWS credentials
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// Endpoints of the credentials of ECS tasks and EC2 instances
+const (
+	ecsCredentialsHost = "http://169.254.170.2"
+	imdsURL            = "http://169.254.169.254/latest"
+)
+
+// Profiles followed through source_profile before giving up on a loop
+const maxSourceProfiles = 5
+
+// Profile keys of credential providers gh pric cannot use; a profile with one of them fails instead of being skipped
+var unsupportedProfileKeys = []struct {
+	key    string
+	method string
+}{
+	{"sso_session", "AWS IAM Identity Center (SSO)"},
+	{"sso_start_url", "AWS IAM Identity Center (SSO)"},
+	{"sso_account_id", "AWS IAM Identity Center (SSO)"},
+	{"login_session", "aws login"},
+	{"mfa_serial", "MFA for role_arn"},
+}
+
+// errNoProfileCredentials reports a profile that exists without any credentials, so the chain goes on
+var errNoProfileCredentials = errors.New("no credentials in the profile")
+
+// 認証情報を AWS の SDK の標準的な順序で探す
+// 環境変数のキー、環境変数の Web ID フェデレーション（AWS_WEB_IDENTITY_TOKEN_FILE と AWS_ROLE_ARN）、
+// プロファイル（静的なキー、role_arn の AssumeRole、web_identity_token_file、credential_process）、ECS タスクのロール、EC2 インスタンスのロールの順
+// SSO などの対応していない方式のプロファイルは、黙って飛ばさずにエラーにする
+func loadAWSCredentials(ctx context.Context, client *http.Client, profile, region string) (awsCredentials, error) {
+	if creds, ok := envAWSCredentials(); ok {
+		return creds, nil
+	}
+
+	if tokenFile, roleARN := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"), os.Getenv("AWS_ROLE_ARN"); tokenFile != "" && roleARN != "" {
+		return webIdentityAWSCredentials(ctx, client, tokenFile, roleARN, os.Getenv("AWS_ROLE_SESSION_NAME"), region)
+	}
+
+	creds, err := profileAWSCredentials(ctx, client, profile, region, 0)
+	if err == nil {
+		return creds, nil
+	}
+	if !errors.Is(err, errNoProfileCredentials) {
+		return awsCredentials{}, err
+	}
+
+	// The metadata endpoints are only reachable on AWS, so they are tried last and briefly
+	metadataCreds, err := metadataAWSCredentials(ctx, client)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("no AWS credentials found (set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or add profile %q to ~/.aws/credentials or ~/.aws/config): %w", profile, err)
+	}
+	return metadataCreds, nil
+}
+
+// 環境変数 AWS_ACCESS_KEY_ID と AWS_SECRET_ACCESS_KEY のキーを返す
+func envAWSCredentials() (awsCredentials, bool) {
+	id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if id == "" || secret == "" {
+		return awsCredentials{}, false
+	}
+	return awsCredentials{AccessKeyID: id, SecretAccessKey: secret, SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, true
+}
+
+// プロファイルの認証情報を返す（認証情報のないプロファイルでは errNoProfileCredentials）
+// depth は source_profile をたどった数
+func profileAWSCredentials(ctx context.Context, client *http.Client, profile, region string, depth int) (awsCredentials, error) {
+	if depth > maxSourceProfiles {
+		return awsCredentials{}, fmt.Errorf("AWS profile %q: source_profile is nested too deeply (is there a loop?)", profile)
+	}
+	values := awsProfile(profile)
+	for _, unsupported := range unsupportedProfileKeys {
+		if values[unsupported.key] != "" {
+			return awsCredentials{}, fmt.Errorf("AWS profile %q uses %s (%s), which gh pric does not support; export the credentials instead, e.g. with eval \"$(aws configure export-credentials --profile %s --format env)\"", profile, unsupported.method, unsupported.key, profile)
+		}
+	}
+
+	static := awsCredentials{
+		AccessKeyID:     values["aws_access_key_id"],
+		SecretAccessKey: values["aws_secret_access_key"],
+		SessionToken:    values["aws_session_token"],
+	}
+	roleARN := values["role_arn"]
+	switch {
+	case roleARN != "" && values["web_identity_token_file"] != "":
+		return webIdentityAWSCredentials(ctx, client, values["web_identity_token_file"], roleARN, values["role_session_name"], region)
+
+	case roleARN != "":
+		var source awsCredentials
+		var err error
+		switch sourceProfile, credentialSource := values["source_profile"], values["credential_source"]; {
+		case sourceProfile == profile:
+			// A profile may assume a role with its own keys
+			source = static
+			if source.AccessKeyID == "" || source.SecretAccessKey == "" {
+				err = fmt.Errorf("AWS profile %q is its own source_profile but has no keys", profile)
+			}
+		case sourceProfile != "":
+			source, err = profileAWSCredentials(ctx, client, sourceProfile, region, depth+1)
+			if errors.Is(err, errNoProfileCredentials) {
+				err = fmt.Errorf("source_profile %q of AWS profile %q has no credentials", sourceProfile, profile)
+			}
+		case credentialSource == "Environment":
+			var ok bool
+			if source, ok = envAWSCredentials(); !ok {
+				err = fmt.Errorf("AWS profile %q takes its credentials from the environment, but AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are not set", profile)
+			}
+		case credentialSource == "Ec2InstanceMetadata" || credentialSource == "EcsContainer":
+			source, err = metadataAWSCredentials(ctx, client)
+		case credentialSource != "":
+			err = fmt.Errorf("AWS profile %q has an unknown credential_source %q", profile, credentialSource)
+		default:
+			err = fmt.Errorf("AWS profile %q has role_arn but no source_profile, credential_source or web_identity_token_file", profile)
+		}
+		if err != nil {
+			return awsCredentials{}, err
+		}
+		return assumeAWSRole(ctx, client, source, roleARN, values["role_session_name"], values["external_id"], region)
+
+	case values["credential_process"] != "":
+		return processAWSCredentials(ctx, values["credential_process"])
+
+	case static.AccessKeyID != "" && static.SecretAccessKey != "":
+		return static, nil
+	}
+	return awsCredentials{}, errNoProfileCredentials
+}
+
+// プロファイルの設定を ~/.aws/config と ~/.aws/credentials から読み込む（同じキーは credentials が優先）
+func awsProfile(profile string) map[string]string {
+	values := readINISection(awsConfigFile(), configSection(profile))
+	for key, value := range readINISection(awsCredentialsFile(), profile) {
+		values[key] = value
+	}
+	return values
+}
+
+// 共有の設定ファイルでのプロファイルのセクション名を返す
+func configSection(profile string) string {
+	if profile == "default" {
+		return "default"
+	}
+	return "profile " + profile
+}
+
+// AssumeRole でロールの一時的な認証情報を取得する（source の認証情報で署名）
+func assumeAWSRole(ctx context.Context, client *http.Client, source awsCredentials, roleARN, sessionName, externalID, region string) (awsCredentials, error) {
+	form := url.Values{
+		"Action":          {"AssumeRole"},
+		"Version":         {"2011-06-15"},
+		"RoleArn":         {roleARN},
+		"RoleSessionName": {roleSessionName(sessionName)},
+	}
+	if externalID != "" {
+		form.Set("ExternalId", externalID)
+	}
+	body := []byte(form.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, stsEndpoint(region), bytes.NewReader(body))
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	signAWSRequest(req, body, source, region, "sts", time.Now().UTC())
+	return stsCredentials(client, req, roleARN)
+}
+
+// AssumeRoleWithWebIdentity で、トークンファイルの OIDC トークン（EKS や GitHub Actions）からロールの一時的な認証情報を取得する
+func webIdentityAWSCredentials(ctx context.Context, client *http.Client, tokenFile, roleARN, sessionName, region string) (awsCredentials, error) {
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to read the web identity token: %w", err)
+	}
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {roleARN},
+		"RoleSessionName":  {roleSessionName(sessionName)},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, stsEndpoint(region), strings.NewReader(form.Encode()))
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	return stsCredentials(client, req, roleARN)
+}
+
+// STS のリクエストを送り、応答の XML から認証情報を読む
+func stsCredentials(client *http.Client, req *http.Request, roleARN string) (awsCredentials, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return awsCredentials{}, fmt.Errorf("failed to assume %s: %w", roleARN, responseError("AWS STS", resp, body))
+	}
+
+	// AssumeRoleResult and AssumeRoleWithWebIdentityResult hold the same credentials
+	var response struct {
+		Result struct {
+			Credentials struct {
+				AccessKeyID     string `xml:"AccessKeyId"`
+				SecretAccessKey string `xml:"SecretAccessKey"`
+				SessionToken    string `xml:"SessionToken"`
+			} `xml:"Credentials"`
+		} `xml:",any"`
+	}
+	if err := xml.Unmarshal(body, &response); err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to parse the response of AWS STS: %w", err)
+	}
+	creds := response.Result.Credentials
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return awsCredentials{}, fmt.Errorf("AWS STS returned no credentials for %s", roleARN)
+	}
+	return awsCredentials{AccessKeyID: creds.AccessKeyID, SecretAccessKey: creds.SecretAccessKey, SessionToken: creds.SessionToken}, nil
+}
+
+// STS のエンドポイントを返す（AWS_ENDPOINT_URL_STS があればそれを使う）
+func stsEndpoint(region string) string {
+	if endpoint := firstEnv("AWS_ENDPOINT_URL_STS", "AWS_ENDPOINT_URL"); endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/") + "/"
+	}
+	return fmt.Sprintf("https://sts.%s.amazonaws.com/", region)
+}
+
+// ロールのセッション名を返す（指定がなければ gh-pric と時刻）
+func roleSessionName(name string) string {
+	if name != "" {
+		return name
+	}
+	return fmt.Sprintf("gh-pric-%d", time.Now().Unix())
+}
+
+// credential_process のコマンドを実行し、標準出力の JSON から認証情報を読む
+func processAWSCredentials(ctx context.Context, command string) (awsCredentials, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd.exe", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("credential_process failed: %w", err)
+	}
+
+	var response struct {
+		Version         int    `json:"Version"`
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string `json:"SecretAccessKey"`
+		SessionToken    string `json:"SessionToken"`
+	}
+	if err := json.Unmarshal(out, &response); err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to parse the output of credential_process: %w", err)
+	}
+	if response.Version != 1 {
+		return awsCredentials{}, fmt.Errorf("credential_process printed version %d of the credentials, only version 1 is supported", response.Version)
+	}
+	if response.AccessKeyID == "" || response.SecretAccessKey == "" {
+		return awsCredentials{}, fmt.Errorf("credential_process printed no credentials")
+	}
+	return awsCredentials{AccessKeyID: response.AccessKeyID, SecretAccessKey: response.SecretAccessKey, SessionToken: response.SessionToken}, nil
+}
+
+// ECS タスクのロール、なければ EC2 インスタンスのロール（IMDSv2）の一時的な認証情報を取得する
+func metadataAWSCredentials(ctx context.Context, client *http.Client) (awsCredentials, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); uri != "" {
+		return requestAWSCredentials(ctx, client, ecsCredentialsHost+uri, "Authorization", os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN"))
+	}
+	if uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI"); uri != "" {
+		return requestAWSCredentials(ctx, client, uri, "Authorization", os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN"))
+	}
+	if strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		return awsCredentials{}, fmt.Errorf("the EC2 instance metadata service is disabled")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, imdsURL+"/api/token", nil)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "300")
+	token, err := metadataText(client, req)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, imdsURL+"/meta-data/iam/security-credentials/", nil)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	roles, err := metadataText(client, req)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	role := strings.TrimSpace(strings.SplitN(roles, "\n", 2)[0])
+	if role == "" {
+		return awsCredentials{}, fmt.Errorf("the EC2 instance has no IAM role")
+	}
+	return requestAWSCredentials(ctx, client, imdsURL+"/meta-data/iam/security-credentials/"+role, "X-aws-ec2-metadata-token", token)
+}
+
+// 認証情報のエンドポイントに GET を送り、JSON の認証情報を読む
+func requestAWSCredentials(ctx context.Context, client *http.Client, target, header, value string) (awsCredentials, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	if value != "" {
+		req.Header.Set(header, value)
+	}
+	body, err := metadataText(client, req)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+
+	var response struct {
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string `json:"SecretAccessKey"`
+		Token           string `json:"Token"`
+	}
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to parse the AWS credentials: %w", err)
+	}
+	if response.AccessKeyID == "" || response.SecretAccessKey == "" {
+		return awsCredentials{}, fmt.Errorf("the AWS credentials endpoint returned no credentials")
+	}
+	return awsCredentials{AccessKeyID: response.AccessKeyID, SecretAccessKey: response.SecretAccessKey, SessionToken: response.Token}, nil
+}
+
+// メタデータのリクエストを送り、本文を返す
+func metadataText(client *http.Client, req *http.Request) (string, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return "", responseError("AWS metadata", resp, body)
+	}
+	return string(body), nil
+}
+
+// リージョンを環境変数、共有の設定ファイル（~/.aws/config）の順に探す（既定は us-east-1）
+func awsRegion(profile string) string {
+	if region := firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"); region != "" {
+		return region
+	}
+	if region := readINISection(awsConfigFile(), configSection(profile))["region"]; region != "" {
+		return region
+	}
+	return "us-east-1"
+}
+
+// 共有の設定ファイル（AWS_CONFIG_FILE、既定は ~/.aws/config）のパスを返す
+func awsConfigFile() string {
+	if path := os.Getenv("AWS_CONFIG_FILE"); path != "" {
+		return path
+	}
+	return awsConfigPath("config")
+}
+
+// 共有の認証情報ファイル（AWS_SHARED_CREDENTIALS_FILE、既定は ~/.aws/credentials）のパスを返す
+func awsCredentialsFile() string {
+	if path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE"); path != "" {
+		return path
+	}
+	return awsConfigPath("credentials")
+}
+
+// ~/.aws にあるファイルのパスを返す
+func awsConfigPath(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".aws", name)
+}
+
+// INI 形式のファイルからセクションのキーと値を読み込む（ファイルがなければ空）
+func readINISection(path, section string) map[string]string {
+	values := make(map[string]string)
+	file, err := os.Open(path)
+	if err != nil {
+		return values
+	}
+	defer file.Close()
+
+	current := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			current = strings.TrimSpace(line[1 : len(line)-1])
+		case current == section:
+			if key, value, ok := strings.Cut(line, "="); ok {
+				values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+		}
+	}
+	return values
+}
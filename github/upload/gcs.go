@@ -0,0 +1,44 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"git.pepabo.com/yukyan/gh-pric/github/googleauth"
+)
+
+// Settings for Google Cloud Storage
+const (
+	gcsUploadAPI = "https://storage.googleapis.com/upload/storage/v1/b/"
+	gcsScope     = "https://www.googleapis.com/auth/devstorage.read_write"
+)
+
+// Cloud Storage にオブジェクトを書き込む（JSON API の単純アップロード）
+func putGCSObject(ctx context.Context, client *http.Client, bucket, key, contentType string, body []byte) error {
+	token, err := googleauth.AccessToken(ctx, client, gcsScope)
+	if err != nil {
+		return err
+	}
+
+	target := gcsUploadAPI + url.PathEscape(bucket) + "/o?uploadType=media&name=" + url.QueryEscape(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return responseError("Cloud Storage", resp, respBody)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Destination はアップロード先のバケットとオブジェクト名です
+type Destination struct {
+	Scheme string // "s3" or "gs"
+	Bucket string
+	Key    string // Object name; ends with "/" when the file name is to be appended
+}
+
+// String returns the destination as an s3:// or gs:// URL
+func (d Destination) String() string {
+	return d.Scheme + "://" + d.Bucket + "/" + d.Key
+}
+
+// ParseDestination は s3://bucket/path/ または gs://bucket/path/ 形式のアップロード先を解析します
+// パスが / で終わる場合（または空の場合）はファイル名が付け足されます
+func ParseDestination(raw string) (Destination, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Destination{}, err
+	}
+	if u.Scheme != "s3" && u.Scheme != "gs" {
+		return Destination{}, fmt.Errorf("unsupported destination %q (please specify s3://bucket/path/ or gs://bucket/path/)", raw)
+	}
+	if u.Host == "" {
+		return Destination{}, fmt.Errorf("destination %q has no bucket", raw)
+	}
+	return Destination{Scheme: u.Scheme, Bucket: u.Host, Key: strings.TrimPrefix(u.Path, "/")}, nil
+}
+
+// Upload はファイルをアップロード先に書き込み、書き込んだオブジェクトの URL を返します
+// 認証情報は各クラウドの標準的な順序（環境変数、共有の設定ファイル、メタデータサーバー）で探します
+// S3 では Web ID フェデレーション、AssumeRole、credential_process にも対応し、SSO のプロファイルはエラーになります
+func Upload(ctx context.Context, dest Destination, filename string, client *http.Client) (string, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	body, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+
+	if dest.Key == "" || strings.HasSuffix(dest.Key, "/") {
+		dest.Key = path.Join(dest.Key, filepath.Base(filename))
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(filename))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	switch dest.Scheme {
+	case "s3":
+		err = putS3Object(ctx, client, dest.Bucket, dest.Key, contentType, body)
+	case "gs":
+		err = putGCSObject(ctx, client, dest.Bucket, dest.Key, contentType, body)
+	}
+	if err != nil {
+		return "", err
+	}
+	return dest.String(), nil
+}
+
+// エラーレスポンスの本文を短くまとめたエラーを返す
+func responseError(service string, resp *http.Response, body []byte) error {
+	message := strings.TrimSpace(string(body))
+	if len(message) > 300 {
+		message = message[:300] + "..."
+	}
+	return fmt.Errorf("%s returned %s: %s", service, resp.Status, message)
+}
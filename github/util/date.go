@@ -7,14 +7,14 @@ import (
 	"git.pepabo.com/yukyan/gh-pric/github/model"
 )
 
-// ParseDateRange は日付文字列を解析して日付範囲を返します
-func ParseDateRange(startStr, endStr string) (model.DateRange, error) {
-	startDate, err := time.Parse("2006-01-02", startStr)
+// ParseDateRange は日付文字列を loc のタイムゾーンの日付として解析して日付範囲を返します
+func ParseDateRange(startStr, endStr string, loc *time.Location) (model.DateRange, error) {
+	startDate, err := time.ParseInLocation("2006-01-02", startStr, loc)
 	if err != nil {
 		return model.DateRange{}, fmt.Errorf("Failed to parse start date: %w", err)
 	}
 
-	endDate, err := time.Parse("2006-01-02", endStr)
+	endDate, err := time.ParseInLocation("2006-01-02", endStr, loc)
 	if err != nil {
 		return model.DateRange{}, fmt.Errorf("Failed to parse end date: %w", err)
 	}
@@ -31,3 +31,39 @@ func ParseDateRange(startStr, endStr string) (model.DateRange, error) {
 		EndDate:   endDate,
 	}, nil
 } 
+
+// Sprint はスプリント（イテレーション）の長さと、いずれかのスプリントの開始日です
+type Sprint struct {
+	Length int       // Length in days
+	Anchor time.Time // First day of any sprint
+}
+
+// PresetPeriod はプリセット名（this-week, last-week, this-month, sprint）に対応する期間の初日と最終日を返します
+// 週は月曜日始まりで、今週・今月・今のスプリントは today までです
+func PresetPeriod(preset string, today time.Time, sprint Sprint) (time.Time, time.Time, error) {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+	monday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+
+	switch preset {
+	case "this-week":
+		return monday, today, nil
+	case "last-week":
+		return monday.AddDate(0, 0, -7), monday.AddDate(0, 0, -1), nil
+	case "this-month":
+		return time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location()), today, nil
+	case "sprint":
+		if sprint.Anchor.IsZero() || sprint.Length < 1 {
+			return time.Time{}, time.Time{}, fmt.Errorf("the sprint length and anchor date are not configured")
+		}
+		// Days are counted on UTC dates so daylight saving time does not shift them
+		anchor := time.Date(sprint.Anchor.Year(), sprint.Anchor.Month(), sprint.Anchor.Day(), 0, 0, 0, 0, time.UTC)
+		days := int(time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC).Sub(anchor) / (24 * time.Hour))
+		offset := days % sprint.Length
+		if offset < 0 {
+			offset += sprint.Length
+		}
+		return today.AddDate(0, 0, -offset), today, nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown period preset: %s", preset)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Returned by acquireLock while another run holds the lock
+var errLocked = errors.New("another gh pric run holds the lock")
+
+// acquireLock takes the lock file of the cache directory so only one run works at a time (--lock)
+// The lock is held while the returned file stays open and is released by the OS when the process exits
+func acquireLock(dir string) (*os.File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "gh-pric.lock")
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	// The PID only helps to find the run holding the lock
+	file.Truncate(0)
+	fmt.Fprintf(file, "%d\n", os.Getpid())
+	return file, nil
+}
+
+// datedOutputName adds the period to a file name (github-activity.txt -> github-activity-2024-03-11_2024-03-17.txt)
+// so runs on different days never write to the same file
+func datedOutputName(name, from, to string) string {
+	ext := filepath.Ext(name)
+	return fmt.Sprintf("%s-%s_%s%s", name[:len(name)-len(ext)], from, to, ext)
+}
@@ -1,18 +1,29 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"syscall"
 	"time"
+	_ "time/tzdata" // --timezone works without a system time zone database (e.g. on Windows)
 
 	"git.pepabo.com/yukyan/gh-pric/github"
+	"git.pepabo.com/yukyan/gh-pric/github/cache"
+	"git.pepabo.com/yukyan/gh-pric/github/calendar"
+	"git.pepabo.com/yukyan/gh-pric/github/jira"
 	"git.pepabo.com/yukyan/gh-pric/github/model"
 	"git.pepabo.com/yukyan/gh-pric/github/output"
 	"git.pepabo.com/yukyan/gh-pric/github/util"
-	"github.com/briandowns/spinner"
+
+	"github.com/cli/go-gh/v2/pkg/term"
 )
 
 func main() {
@@ -20,23 +31,395 @@ func main() {
 	var startDateStr, endDateStr, outputFile string
 	var commentIgnoreUsers string
 	var outputFormat string
+	var maxPages int
+	var cacheDir string
+	var noCache bool
+	var sinceLastRun bool
+	var closedInRange bool
+	var includeProjects bool
+	var onlyMyComments bool
+	var repos stringList
+	var excludeRepos stringList
+	var orgs stringList
+	var hostname string
+	var profile string
+	var thisWeek, lastWeek, thisMonth, sprint bool
+	var quiet bool
+	var progressFormat string
+	var noUpdateCheck bool
+	var lock bool
+	var openFile bool
+	var ghaSummary bool
+	var jiraURL, jiraUser string
+	var calendarSource string
+	var force bool
+	var noClobber bool
+	var noBots bool
+	var visibility string
+	var targetUser string
+	var teamUsers string
+	var offline bool
+	var inputFile string
+	var verbose bool
+	var strictRateLimit bool
+	var maxRetries int
+	var maxComments int
+	var includeCI bool
+	var retryWait time.Duration
+	var timeout time.Duration
+	var publishOpts publishOptions
+	var outputOpts output.Options
+	var breakdownLabels string
+	var comparePrevious bool
+	var anonymize bool
+	var redact bool
+	var redactPatterns stringList
+	var filters stringList
+	var configPath string
+	var summarize bool
+	var timezone string
+	var obsidian bool
+	var dailyNoteFormat string
 	var defaultEndDate = time.Now().Format("2006-01-02")
 	var defaultStartDate = time.Now().AddDate(0, 0, -3).Format("2006-01-02") // Default is 3 days ago
 
+	flag.StringVar(&targetUser, "user", "", "User to report on (defaults to the authenticated user)")
+	flag.StringVar(&teamUsers, "users", "", "Generate a team report for several users (comma-separated)")
 	flag.StringVar(&startDateStr, "from", defaultStartDate, "Start date (YYYY-MM-DD format)")
 	flag.StringVar(&endDateStr, "to", defaultEndDate, "End date (YYYY-MM-DD format)")
+	flag.BoolVar(&thisWeek, "this-week", false, "Report on this week, from Monday to today (instead of --from/--to)")
+	flag.BoolVar(&lastWeek, "last-week", false, "Report on last week, from Monday to Sunday (instead of --from/--to)")
+	flag.BoolVar(&thisMonth, "this-month", false, "Report on this month up to today (instead of --from/--to)")
+	flag.BoolVar(&sprint, "sprint", false, "Report on the current sprint up to today, using the sprint length and anchor date from the config file")
+	flag.StringVar(&timezone, "timezone", "UTC", "Time zone for the --from/--to boundaries and every date in the output (IANA name like Asia/Tokyo, or Local)")
 	flag.StringVar(&outputFile, "output", "github-activity.txt", "Output file name")
 	flag.StringVar(&outputFile, "o", "github-activity.txt", "Output file name (alias for --output)")
 	flag.StringVar(&commentIgnoreUsers, "comment-ignore", "", "Usernames of comments to exclude from output (comma-separated for multiple)")
-	flag.StringVar(&outputFormat, "output-format", "md", "Output format (md or json)")
+	flag.StringVar(&outputFormat, "output-format", "md", "Output format ("+formatList()+", or <name> for a gh-pric-format-<name> plugin; sqlite needs the sqlite3 command)")
+	flag.BoolVar(&obsidian, "obsidian", false, "Write an Obsidian daily note (wiki-links, #tags from labels, callouts for comments) named after --daily-note-format")
+	flag.StringVar(&dailyNoteFormat, "daily-note-format", "YYYY-MM-DD", "File name of the note for --obsidian in the Moment.js format of Obsidian daily notes (e.g. [Daily]/YYYY/MM/YYYY-MM-DD)")
+	flag.BoolVar(&outputOpts.Append, "append", false, "Append the report to the markdown file as a section headed by the period instead of overwriting it (a running journal)")
+	flag.StringVar(&outputOpts.Theme, "theme", "", "Render the markdown report with a template: built-in standup, weekly or review, or <name>.tmpl in --theme-dir")
+	flag.StringVar(&outputOpts.ThemeDir, "theme-dir", defaultThemeDir(), "Directory searched for --theme templates before the built-in ones")
+	flag.StringVar(&outputOpts.GroupBy, "group-by", "involvement", "How markdown item details are grouped (involvement, day or repo)")
+	flag.StringVar(&outputOpts.Sort, "sort", "", "Sort items in every section by created, updated, repo or number (default: the order they were fetched in)")
+	flag.StringVar(&outputOpts.Order, "order", "asc", "Sort order for --sort (asc or desc)")
+	flag.BoolVar(&outputOpts.Full, "full", false, "Disable all truncation: full bodies and every comment in the output, no --max-comments or --max-pages cap")
+	flag.StringVar(&outputOpts.Mermaid, "mermaid", "", "Embed a Mermaid chart of when PRs were opened and merged at the top of the markdown report (gantt or timeline)")
+	flag.BoolVar(&outputOpts.SummaryOnly, "summary-only", false, "Write only the summary and one line per item, skipping the detail requests for speed")
+	flag.StringVar(&breakdownLabels, "breakdown-labels", "", "Only count these labels in the per-label breakdown of the summary (comma-separated, e.g. bug,feature,chore)")
+	flag.BoolVar(&comparePrevious, "compare-previous", false, "Also fetch the equally long preceding period and show the changes in the summary")
+	flag.BoolVar(&anonymize, "anonymize", false, "Replace other users' logins with stable pseudonyms (user-1, user-2, ...) and hide private repository names")
+	flag.BoolVar(&redact, "redact", true, "Mask tokens, API keys and e-mail addresses in titles, bodies, comments and commit messages (use --redact=false to keep them)")
+	flag.Var(&filters, "filter", "Pass the items through the gh-pric-filter-<name> plugin in PATH before writing (repeatable, applied in order)")
+	flag.Var(&redactPatterns, "redact-pattern", "Additional regular expression to mask in titles, bodies, comments and commit messages (repeatable)")
+	flag.BoolVar(&outputOpts.Emoji, "emoji", false, "Prefix markdown items with type and state icons (🔀 PR, 📝 Issue, 🟢 open, 🟣 merged, 🔴 closed)")
+	flag.BoolVar(&outputOpts.LabelChips, "label-chips", false, "Prefix labels in the markdown report with a colored square close to the label color")
+	flag.IntVar(&outputOpts.MaxTokens, "max-tokens", 0, "Reduce the detail of the markdown report (older comments, long bodies, bot items) until it fits an estimated token budget (0 = no limit)")
+	flag.BoolVar(&summarize, "summarize", false, "Prepend a natural-language summary generated by an OpenAI-compatible LLM to the report (md or confluence)")
+	flag.StringVar(&configPath, "config", defaultConfigPath(), "Config file with the LLM settings for --summarize and the --profile definitions")
+	flag.StringVar(&cacheDir, "cache-dir", cache.DefaultDir(), "Directory for the on-disk API response cache")
+	flag.BoolVar(&noCache, "no-cache", false, "Disable the on-disk API response cache")
+	flag.BoolVar(&closedInRange, "closed-in-range", false, "Also include items closed or merged during the period even if they were created earlier")
+	flag.Var(&repos, "repo", "Restrict the report to a repository (owner/name, repeatable)")
+	flag.Var(&orgs, "org", "Restrict the report to repositories owned by an organization or user (repeatable)")
+	flag.StringVar(&hostname, "hostname", "", "GitHub hostname to use, e.g. a GitHub Enterprise Server (default: the gh default host)")
+	flag.StringVar(&profile, "profile", "", "Apply the flags of a named profile from the config file (flags given on the command line take precedence)")
+	flag.Var(&excludeRepos, "exclude-repo", "Exclude repositories matching a pattern (owner/name, glob like owner/*-sandbox supported, repeatable)")
+	flag.StringVar(&visibility, "visibility", "all", "Repository visibility to include (public, private, or all)")
+	flag.BoolVar(&noBots, "no-bots", true, "Drop items and comments authored by bots (use --no-bots=false to keep them)")
+	flag.BoolVar(&onlyMyComments, "only-my-comments", false, "On commented items, only include your own comments")
+	flag.BoolVar(&includeProjects, "include-projects", false, "Fetch Projects v2 status and iteration fields for each item (requires the read:project scope)")
+	flag.StringVar(&calendarSource, "calendar", "", "iCalendar file or URL (https:// or webcal://) whose meetings annotate each day of --group-by day")
+	flag.StringVar(&jiraURL, "jira-url", "", "Also include the Jira issues you transitioned or commented on during the period, from this site (token from JIRA_API_TOKEN)")
+	flag.StringVar(&jiraUser, "jira-user", "", "Jira account email (Cloud) or username for --jira-url (empty: JIRA_API_TOKEN is a personal access token)")
+	flag.BoolVar(&includeCI, "include-ci", false, "List GitHub Actions workflow runs you triggered in the repositories you worked in")
+	flag.BoolVar(&sinceLastRun, "since-last-run", false, "Only fetch items updated since the last successful run and merge them into the saved dataset")
+	flag.BoolVar(&offline, "offline", false, "Skip all API calls and build the report from saved data (the --since-last-run dataset or --input)")
+	flag.StringVar(&inputFile, "input", "", "JSON report (from --output-format json) to render in --offline mode")
+	flag.IntVar(&maxComments, "max-comments", 0, "Maximum number of comments (including review comments) fetched per item (0 = unlimited)")
+	flag.IntVar(&maxRetries, "max-retries", github.DefaultMaxRetries, "Number of attempts for each API request before giving up")
+	flag.DurationVar(&retryWait, "retry-wait", github.DefaultRetryWait, "Wait before the first retry, doubled on each further attempt (e.g. 500ms, 2s)")
+	flag.DurationVar(&timeout, "timeout", 0, "Stop fetching after this long and write a partial report, e.g. 10m (0 = no limit)")
+	flag.BoolVar(&strictRateLimit, "strict-rate-limit", false, "Abort before fetching when the remaining API quota looks insufficient for the run")
+	flag.BoolVar(&quiet, "quiet", false, "Print nothing but errors and the path of the written file (for scripts and cron jobs)")
+	flag.BoolVar(&force, "force", false, "Overwrite an existing output file without asking (required when not running in a terminal, except for the period-named files of --lock)")
+	flag.BoolVar(&noClobber, "no-clobber", false, "Leave an existing output file alone and exit with status 0 instead of asking (for scheduled runs)")
+	flag.BoolVar(&ghaSummary, "gha-summary", false, "In GitHub Actions, also write the Markdown report to the job summary and set the item counts as step outputs")
+	flag.BoolVar(&openFile, "open", false, "Open the written file when done: text formats in $EDITOR, xlsx and sqlite in their default application")
+	flag.BoolVar(&lock, "lock", false, "Exit quietly when another run holds the lock in the cache directory, and name the default output file after the period (for cron and systemd timers)")
+	flag.BoolVar(&noUpdateCheck, "no-update-check", false, "Do not check for a newer release of gh pric (also \"update_check: false\" in the config file)")
+	flag.StringVar(&progressFormat, "progress-format", "text", "Progress output: text, or json for NDJSON events (phase, completed, total, rate limit remaining) on stderr")
+	flag.BoolVar(&verbose, "verbose", false, "Log every API request, status code, retry and rate limit state to stderr")
+	flag.StringVar(&publishOpts.target, "publish", "", "Also publish the report to a destination (notion, gist, esa, kibela, confluence or gdocs)")
+	flag.StringVar(&publishOpts.post, "post", "", "Also send the report to a webhook or chat: webhook (JSON report), discord or teams (summary)")
+	flag.StringVar(&publishOpts.notionDatabase, "notion-database", "", "Notion database ID that receives one row per item with --publish notion (token from NOTION_TOKEN)")
+	flag.StringVar(&publishOpts.upload, "upload", "", "Also copy the written file to object storage: s3://bucket/path/ or gs://bucket/path/ (the file name is appended to paths ending with /)")
+	flag.StringVar(&publishOpts.esaTeam, "esa-team", "", "esa.io team that receives the report with --publish esa (token from ESA_ACCESS_TOKEN)")
+	flag.StringVar(&publishOpts.esaCategory, "esa-category", "gh-pric", "Category of the esa.io post, with the placeholders of --output (e.g. 日報/{{.From}})")
+	flag.StringVar(&publishOpts.kibelaTeam, "kibela-team", "", "Kibela team that receives the report with --publish kibela (token from KIBELA_TOKEN)")
+	flag.StringVar(&publishOpts.kibelaGroup, "kibela-group", "Home", "Group of the Kibela note")
+	flag.StringVar(&publishOpts.kibelaFolder, "kibela-folder", "", "Folder of the Kibela note, with the placeholders of --output (e.g. 週報/{{.User}})")
+	flag.StringVar(&publishOpts.confluenceURL, "confluence-url", "", "Confluence Cloud site that receives the page with --publish confluence (e.g. https://example.atlassian.net)")
+	flag.StringVar(&publishOpts.confluenceSpace, "confluence-space", "", "Key of the space of the Confluence page")
+	flag.StringVar(&publishOpts.confluenceParent, "confluence-parent", "", "ID of the page new Confluence pages are created under (default: top level of the space)")
+	flag.StringVar(&publishOpts.gdocsFolder, "gdocs-folder", "", "ID of the Google Drive folder of the document created with --publish gdocs (default: My Drive)")
+	flag.StringVar(&publishOpts.webhookURL, "webhook-url", "", "Endpoint that receives the JSON report with --post webhook (signed with GH_PRIC_WEBHOOK_SECRET when set)")
+	flag.StringVar(&publishOpts.discordWebhook, "discord-webhook-url", "", "Discord webhook URL that receives a summary with --post discord (default: DISCORD_WEBHOOK_URL)")
+	flag.StringVar(&publishOpts.teamsWebhook, "teams-webhook-url", "", "Microsoft Teams webhook URL that receives an Adaptive Card summary with --post teams (default: TEAMS_WEBHOOK_URL)")
+	flag.StringVar(&publishOpts.gistID, "gist-id", "", "Gist updated by --publish gist (defaults to the gist of the previous run for the same users, or a new secret gist)")
+	flag.IntVar(&maxPages, "max-pages", 0, "Maximum number of search result pages to fetch per query (0 = unlimited)")
+
+	// Subcommands come before the flags and use their definitions
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "completion":
+			os.Exit(runCompletion(os.Args[2:]))
+		case "serve":
+			os.Exit(runServe(os.Args[2:]))
+		case "version":
+			os.Exit(runVersion(os.Args[2:]))
+		case "__complete":
+			os.Exit(runCompleteValues(os.Args[2:]))
+		}
+	}
 	flag.Parse()
 
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read the config file: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Profile values fill in the flags that were not given on the command line
+	if profile != "" {
+		if err := applyProfile(cfg, profile); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --profile: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// Output format validation
-	if outputFormat != "md" && outputFormat != "json" {
-		fmt.Fprintf(os.Stderr, "Invalid output format: %s (please specify md or json)\n", outputFormat)
+	formatter, err := lookupFormat(outputFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid output format: %s (please specify %s, or install a plugin: %v)\n", outputFormat, formatList(), err)
+		os.Exit(1)
+	}
+	// A format that needs an external command (sqlite3) fails the run before fetching
+	if checker, ok := formatter.(output.Checker); ok {
+		if err := checker.Check(); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Filter plugins
+	filterPlugins, err := findFilterPlugins(filters)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --filter: %v\n", err)
+		os.Exit(1)
+	}
+	if _, streams := formatter.(output.Streamer); streams && len(filterPlugins) > 0 {
+		fmt.Fprintf(os.Stderr, "--filter cannot be used with --output-format %s (items are written before they could be filtered)\n", outputFormat)
+		os.Exit(1)
+	}
+
+	if outputOpts.GroupBy != "involvement" && outputOpts.GroupBy != "day" && outputOpts.GroupBy != "repo" {
+		fmt.Fprintf(os.Stderr, "Invalid --group-by: %s (please specify involvement, day or repo)\n", outputOpts.GroupBy)
+		os.Exit(1)
+	}
+
+	if outputOpts.Sort != "" && outputOpts.Sort != "created" && outputOpts.Sort != "updated" && outputOpts.Sort != "repo" && outputOpts.Sort != "number" {
+		fmt.Fprintf(os.Stderr, "Invalid --sort: %s (please specify created, updated, repo or number)\n", outputOpts.Sort)
+		os.Exit(1)
+	}
+	if outputOpts.Order != "asc" && outputOpts.Order != "desc" {
+		fmt.Fprintf(os.Stderr, "Invalid --order: %s (please specify asc or desc)\n", outputOpts.Order)
+		os.Exit(1)
+	}
+
+	if outputOpts.Mermaid != "" && outputOpts.Mermaid != "gantt" && outputOpts.Mermaid != "timeline" {
+		fmt.Fprintf(os.Stderr, "Invalid --mermaid: %s (please specify gantt or timeline)\n", outputOpts.Mermaid)
+		os.Exit(1)
+	}
+
+	if outputOpts.SummaryOnly && outputOpts.Full {
+		fmt.Fprintf(os.Stderr, "--summary-only cannot be used with --full\n")
 		os.Exit(1)
 	}
 
+	if outputOpts.Full && (maxComments > 0 || maxPages > 0) {
+		fmt.Fprintf(os.Stderr, "--full cannot be used with --max-comments or --max-pages\n")
+		os.Exit(1)
+	}
+
+	if outputOpts.MaxTokens < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid --max-tokens: %d (must not be negative)\n", outputOpts.MaxTokens)
+		os.Exit(1)
+	}
+	if outputOpts.MaxTokens > 0 && outputOpts.Full {
+		fmt.Fprintf(os.Stderr, "--max-tokens cannot be used with --full\n")
+		os.Exit(1)
+	}
+
+	if obsidian {
+		if outputFormat != "md" {
+			fmt.Fprintf(os.Stderr, "--obsidian cannot be used with --output-format %s\n", outputFormat)
+			os.Exit(1)
+		}
+		outputFormat = "obsidian"
+		formatter, _ = output.Lookup(outputFormat)
+	}
+	if outputOpts.MaxTokens > 0 && outputFormat != "md" {
+		fmt.Fprintf(os.Stderr, "--max-tokens can only be used with --output-format md\n")
+		os.Exit(1)
+	}
+
+	if outputOpts.Theme != "" {
+		if outputFormat != "md" {
+			fmt.Fprintf(os.Stderr, "--theme can only be used with --output-format md\n")
+			os.Exit(1)
+		}
+		if _, err := output.LoadTheme(outputOpts.Theme, outputOpts.ThemeDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --theme: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Placeholders are checked before anything is fetched
+	if isOutputTemplate(outputFile) {
+		sample := outputNameData{User: "user", From: "2006-01-02", To: "2006-01-02", Date: "2006-01-02", Format: outputFormat}
+		if _, err := expandOutputName(outputFile, sample); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --output: %v (placeholders: {{.User}}, {{.From}}, {{.To}}, {{.Date}}, {{.Format}})\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if force && noClobber {
+		fmt.Fprintf(os.Stderr, "--force and --no-clobber cannot be used together\n")
+		os.Exit(1)
+	}
+
+	if _, appends := formatter.(output.Appender); outputOpts.Append && !appends {
+		fmt.Fprintf(os.Stderr, "--append cannot be used with --output-format %s\n", outputFormat)
+		os.Exit(1)
+	}
+
+	if summarize && outputFormat != "md" && outputFormat != "confluence" && outputFormat != "obsidian" {
+		fmt.Fprintf(os.Stderr, "--summarize can only be used with --output-format md, confluence or obsidian\n")
+		os.Exit(1)
+	}
+
+	if targetUser != "" && teamUsers != "" {
+		fmt.Fprintf(os.Stderr, "--user and --users cannot be used together\n")
+		os.Exit(1)
+	}
+
+	if offline && noCache {
+		fmt.Fprintf(os.Stderr, "--offline cannot be used with --no-cache\n")
+		os.Exit(1)
+	}
+	if inputFile != "" && !offline {
+		fmt.Fprintf(os.Stderr, "--input can only be used with --offline\n")
+		os.Exit(1)
+	}
+
+	// The calendar belongs to one person, like the Jira account below
+	if calendarSource != "" {
+		if outputOpts.GroupBy != "day" || outputFormat != "md" {
+			fmt.Fprintf(os.Stderr, "--calendar can only be used with --group-by day and --output-format md\n")
+			os.Exit(1)
+		}
+		if teamUsers != "" {
+			fmt.Fprintf(os.Stderr, "--calendar cannot be used with --users\n")
+			os.Exit(1)
+		}
+	}
+
+	// Jira activity belongs to the Jira account of the token, so it is only merged into a single-user report
+	var jiraClient *jira.Client
+	if jiraUser != "" && jiraURL == "" {
+		fmt.Fprintf(os.Stderr, "--jira-user can only be used with --jira-url\n")
+		os.Exit(1)
+	}
+	if jiraURL != "" {
+		if !strings.HasPrefix(jiraURL, "https://") && !strings.HasPrefix(jiraURL, "http://") {
+			fmt.Fprintf(os.Stderr, "Invalid --jira-url: %s (please specify the http(s) URL of the site)\n", jiraURL)
+			os.Exit(1)
+		}
+		if teamUsers != "" || offline {
+			fmt.Fprintf(os.Stderr, "--jira-url cannot be used with --users or --offline\n")
+			os.Exit(1)
+		}
+		if os.Getenv("JIRA_API_TOKEN") == "" {
+			fmt.Fprintf(os.Stderr, "--jira-url requires the JIRA_API_TOKEN environment variable\n")
+			os.Exit(1)
+		}
+		jiraClient = &jira.Client{BaseURL: jiraURL, User: jiraUser, Token: os.Getenv("JIRA_API_TOKEN")}
+	}
+
+	if ghaSummary {
+		if err := validateActionsSummary(); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	publishOpts.outputFormat = outputFormat
+	publishOpts.hostname = hostname
+	publishOpts.cacheDir = cacheDir
+	if err := publishOpts.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	if progressFormat != "text" && progressFormat != "json" {
+		fmt.Fprintf(os.Stderr, "Invalid --progress-format: %s (please specify text or json)\n", progressFormat)
+		os.Exit(1)
+	}
+
+	if maxRetries < 1 {
+		fmt.Fprintf(os.Stderr, "Invalid --max-retries: %d (must be at least 1)\n", maxRetries)
+		os.Exit(1)
+	}
+	if retryWait < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid --retry-wait: %s (must not be negative)\n", retryWait)
+		os.Exit(1)
+	}
+	if timeout < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid --timeout: %s (must not be negative)\n", timeout)
+		os.Exit(1)
+	}
+
+	// Visibility validation
+	if visibility != "public" && visibility != "private" && visibility != "all" {
+		fmt.Fprintf(os.Stderr, "Invalid visibility: %s (please specify public, private, or all)\n", visibility)
+		os.Exit(1)
+	}
+
+	// Labels counted in the summary breakdown
+	if breakdownLabels != "" {
+		for _, label := range strings.Split(breakdownLabels, ",") {
+			if label = strings.TrimSpace(label); label != "" {
+				outputOpts.LabelAllowlist = append(outputOpts.LabelAllowlist, label)
+			}
+		}
+	}
+
+	// Patterns of secrets masked before writing
+	var secretPatterns []*regexp.Regexp
+	if redact {
+		secretPatterns = append(secretPatterns, github.DefaultSecretPatterns...)
+		for _, pattern := range redactPatterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid --redact-pattern %q: %v\n", pattern, err)
+				os.Exit(1)
+			}
+			secretPatterns = append(secretPatterns, re)
+		}
+	}
+
 	// Create a list of users to ignore for comments
 	var ignoreUsers []string
 	if commentIgnoreUsers != "" {
@@ -47,211 +430,461 @@ func main() {
 	}
 
 	// Parse dates
-	s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
-	s.Suffix = " Parsing date range..."
-	s.Start()
-	dateRange, err := util.ParseDateRange(startDateStr, endDateStr)
-	s.Stop()
+	p := newProgress(quiet)
+	p.json = progressFormat == "json"
+	if verbose {
+		// A spinner redrawing its line would garble the request log on stderr
+		p.tty = false
+	}
+	location, err := time.LoadLocation(timezone)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to parse dates: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Invalid --timezone: %s\n", timezone)
 		os.Exit(1)
 	}
 
-	// Initialize GitHub client
-	s.Suffix = " Initializing GitHub client..."
-	s.Start()
-	client, err := github.NewClient()
-	s.Stop()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to initialize GitHub client: %v\n", err)
-		os.Exit(1)
+	// Default dates are today and 3 days ago in the chosen time zone
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	now := time.Now().In(location)
+	if !explicit["from"] {
+		startDateStr = now.AddDate(0, 0, -3).Format("2006-01-02")
+	}
+	if !explicit["to"] {
+		endDateStr = now.Format("2006-01-02")
 	}
 
-	// Retrieve user information
-	s.Suffix = " Retrieving user information..."
-	s.Start()
-	username, err := client.GetUsername()
-	s.Stop()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to retrieve user information: %v\n", err)
+	// Period presets replace --from/--to
+	var presets []string
+	for name, set := range map[string]bool{"this-week": thisWeek, "last-week": lastWeek, "this-month": thisMonth, "sprint": sprint} {
+		if set {
+			presets = append(presets, name)
+		}
+	}
+	if len(presets) > 1 {
+		fmt.Fprintf(os.Stderr, "Only one of --this-week, --last-week, --this-month and --sprint can be used\n")
 		os.Exit(1)
 	}
+	if len(presets) == 1 {
+		if explicit["from"] || explicit["to"] {
+			fmt.Fprintf(os.Stderr, "--%s cannot be used with --from or --to\n", presets[0])
+			os.Exit(1)
+		}
+		sprintSettings, err := cfg.Sprint.sprint()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read the config file: %v\n", err)
+			os.Exit(1)
+		}
+		start, end, err := util.PresetPeriod(presets[0], now, sprintSettings)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --%s: %v\n", presets[0], err)
+			os.Exit(1)
+		}
+		startDateStr = start.Format("2006-01-02")
+		endDateStr = end.Format("2006-01-02")
+	}
 
-	fmt.Printf("Retrieving GitHub activity for user '%s'...\n", username)
-	fmt.Printf("Period: %s to %s\n", dateRange.StartDate.Format("2006-01-02"), dateRange.EndDate.Format("2006-01-02"))
-
-	// Data retrieval
-	items, err := fetchAllItems(client, username, dateRange)
+	p.Status("Parsing date range")
+	dateRange, err := util.ParseDateRange(startDateStr, endDateStr, location)
+	p.Stop()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to retrieve data: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Failed to parse dates: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Filter comments from specific users
-	if len(ignoreUsers) > 0 {
-		s.Suffix = " Filtering comments from ignored users..."
-		s.Start()
-		github.FilterIgnoredUserComments(items, ignoreUsers)
-		s.Stop()
+	// The Obsidian note is named after the last day of the period unless --output is given
+	if obsidian && !explicit["output"] && !explicit["o"] {
+		outputFile = output.DailyNoteName(dateRange.EndDate, dailyNoteFormat) + ".md"
+		if err := os.MkdirAll(filepath.Dir(outputFile), 0o755); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create the note directory: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	// Output results
-	s.Suffix = " Writing results to file..."
-	s.Start()
-	err = output.WriteResults(items, outputFile, username, dateRange, outputFormat)
-	s.Stop()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to write to file: %v\n", err)
-		os.Exit(1)
+	// Scheduled runs never overlap and never overwrite the report of another period
+	regenerate := false
+	if lock {
+		held, err := acquireLock(cacheDir)
+		if errors.Is(err, errLocked) {
+			p.Info("Another gh pric run is in progress; exiting")
+			os.Exit(0)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to take the lock: %v\n", err)
+			os.Exit(1)
+		}
+		defer held.Close()
+
+		// A journal keeps every period in the same file
+		if !explicit["output"] && !explicit["o"] && !obsidian && !outputOpts.Append {
+			outputFile = datedOutputName(outputFile, dateRange.StartDate.Format("2006-01-02"), dateRange.EndDate.Format("2006-01-02"))
+			// A file named after the period is only ever replaced by a re-run for the same period
+			regenerate = true
+		}
 	}
 
-	fmt.Printf("Results saved to %s\n", outputFile)
-}
+	// Look for a newer release while the report is being built (never offline or in quiet mode)
+	var updateCheck <-chan string
+	if !offline && !quiet && updateCheckEnabled(noUpdateCheck, cfg) {
+		updateCheck = startUpdateCheck(currentBuildInfo().Version)
+	}
 
-// fetchAllItems retrieves all items (PRs, Issues) for the specified user
-func fetchAllItems(client *github.Client, username string, dateRange model.DateRange) ([]model.Item, error) {
-	var allItems []model.Item
-	ctx := context.Background()
+	// Cancel fetching on Ctrl-C and write whatever was collected so far
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-sigCtx.Done()
+		// A second interrupt terminates immediately
+		stop()
+	}()
 
-	s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+	// --timeout ends the run like an interrupt, once the time is up
+	ctx := sigCtx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 
-	// Retrieve created Issues
-	s.Suffix = " Retrieving created Issues..."
-	s.Start()
-	createdIssues, err := client.FetchIssues(ctx, username, "created", dateRange)
-	s.Stop()
-	if err != nil {
-		return nil, err
+	opts := options{
+		dateRange:       dateRange,
+		cacheDir:        cacheDir,
+		noCache:         noCache,
+		maxPages:        maxPages,
+		closedInRange:   closedInRange,
+		repos:           repos,
+		excludeRepos:    excludeRepos,
+		orgs:            orgs,
+		hostname:        hostname,
+		visibility:      visibility,
+		includeProjects: includeProjects,
+		sinceLastRun:    sinceLastRun,
+		ignoreUsers:     ignoreUsers,
+		noBots:          noBots,
+		onlyMyComments:  onlyMyComments,
+		offline:         offline,
+		inputFile:       inputFile,
+		verbose:         verbose,
+		maxRetries:      maxRetries,
+		maxComments:     maxComments,
+		includeCI:       includeCI,
+		jira:            jiraClient,
+		summaryOnly:     outputOpts.SummaryOnly,
+		comparePrevious: comparePrevious,
+		redactPatterns:  secretPatterns,
+		anonymize:       anonymize,
+		retryWait:       retryWait,
+		onRateLimit:     p.RateLimit,
+	}
+
+	// Pseudonyms are shared by every user of a team report
+	var anonymizer *github.Anonymizer
+	if anonymize {
+		anonymizer = github.NewAnonymizer()
 	}
 
-	for i := range createdIssues {
-		createdIssues[i].Involvement = "created"
-		// Retrieve Issue details (body and comments)
-		s.Suffix = fmt.Sprintf(" Retrieving details for created Issue #%d (%s)...",
-			createdIssues[i].Number, createdIssues[i].Repository)
-		s.Start()
-		err = client.FetchIssueDetails(ctx, &createdIssues[i])
-		s.Stop()
+	// Team members, or the single user (the authenticated user unless --user is given)
+	var usernames []string
+	var client *github.Client
+	username := targetUser
+	if teamUsers != "" {
+		seen := make(map[string]bool)
+		for _, user := range strings.Split(teamUsers, ",") {
+			// Each user is reported once however often they are listed
+			if user = strings.TrimSpace(user); user != "" && !seen[strings.ToLower(user)] {
+				seen[strings.ToLower(user)] = true
+				usernames = append(usernames, user)
+			}
+		}
+	} else {
+		// Initialize GitHub client
+		p.Status("Initializing GitHub client")
+		client, err = newClient(opts)
+		p.Stop()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to retrieve details for Issue (ID: %d): %v\n", createdIssues[i].Number, err)
+			fmt.Fprintf(os.Stderr, "Failed to initialize GitHub client: %v\n", err)
+			os.Exit(1)
 		}
-	}
-	allItems = append(allItems, createdIssues...)
 
-	// Retrieve assigned Issues
-	s.Suffix = " Retrieving assigned Issues..."
-	s.Start()
-	assignedIssues, err := client.FetchIssues(ctx, username, "assigned", dateRange)
-	s.Stop()
-	if err != nil {
-		return nil, err
+		// Retrieve user information
+		if username == "" {
+			p.Status("Retrieving user information")
+			username, err = client.GetUsername(ctx)
+			p.Stop()
+			if err != nil && offline {
+				fmt.Fprintf(os.Stderr, "Failed to retrieve user information: %v (pass --user in offline mode)\n", err)
+				os.Exit(1)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to retrieve user information: %v%s\n", err, fetchErrorHint(err))
+				os.Exit(1)
+			}
+		}
 	}
 
-	for i := range assignedIssues {
-		assignedIssues[i].Involvement = "assigned"
-		// Retrieve Issue details (body and comments)
-		s.Suffix = fmt.Sprintf(" Retrieving details for assigned Issue #%d (%s)...",
-			assignedIssues[i].Number, assignedIssues[i].Repository)
-		s.Start()
-		err = client.FetchIssueDetails(ctx, &assignedIssues[i])
-		s.Stop()
+	// Placeholders in --output are filled in once the users and the period are known
+	if isOutputTemplate(outputFile) {
+		users := usernames
+		if len(users) == 0 {
+			users = []string{username}
+		}
+		outputFile, err = expandOutputName(outputFile, newOutputNameData(users, dateRange, outputFormat, location))
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to retrieve details for Issue (ID: %d): %v\n", assignedIssues[i].Number, err)
+			fmt.Fprintf(os.Stderr, "Invalid --output: %v\n", err)
+			os.Exit(1)
+		}
+		if err := os.MkdirAll(filepath.Dir(outputFile), 0o755); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create the output directory: %v\n", err)
+			os.Exit(1)
 		}
 	}
-	allItems = append(allItems, assignedIssues...)
 
-	// Retrieve commented Issues
-	s.Suffix = " Retrieving commented Issues..."
-	s.Start()
-	commentedIssues, err := client.FetchIssues(ctx, username, "commented", dateRange)
-	s.Stop()
-	if err != nil {
-		return nil, err
+	// An existing report (maybe edited by hand) is only replaced on purpose; journals and databases are appended to
+	if _, ownsFile := formatter.(output.FileFormatter); !outputOpts.Append && !ownsFile {
+		if _, err := os.Stat(outputFile); err == nil && noClobber {
+			p.Info("%s already exists; leaving it as it is", outputFile)
+			os.Exit(0)
+		}
+		if err := confirmOverwrite(outputFile, force || regenerate); err != nil {
+			fmt.Fprintf(os.Stderr, "Aborted: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	for i := range commentedIssues {
-		commentedIssues[i].Involvement = "commented"
-		// Retrieve Issue details (body and comments)
-		s.Suffix = fmt.Sprintf(" Retrieving details for commented Issue #%d (%s)...",
-			commentedIssues[i].Number, commentedIssues[i].Repository)
-		s.Start()
-		err = client.FetchIssueDetails(ctx, &commentedIssues[i])
-		s.Stop()
+	// Streaming formats (jsonl) are written while fetching instead of at the end
+	// Anonymized items are written at the end, once every private repository is known
+	var stream output.ItemWriter
+	if streamer, ok := formatter.(output.Streamer); ok && anonymizer == nil {
+		stream, err = streamer.NewStream(outputFile)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to retrieve details for Issue (ID: %d): %v\n", commentedIssues[i].Number, err)
+			fmt.Fprintf(os.Stderr, "Failed to write to file: %v\n", err)
+			os.Exit(1)
+		}
+		opts.onItem = func(username string, item model.Item) {
+			stream.Write(username, item.In(location))
 		}
 	}
-	allItems = append(allItems, commentedIssues...)
 
-	// Retrieve created PRs
-	s.Suffix = " Retrieving created PRs..."
-	s.Start()
-	createdPRs, err := client.FetchPRs(ctx, username, "created", dateRange)
-	s.Stop()
-	if err != nil {
-		return nil, err
+	// Check the remaining quota up front instead of failing halfway through
+	if !offline {
+		users := 1
+		if teamUsers != "" {
+			users = len(usernames)
+		}
+		p.Status("Checking rate limit")
+		problems, err := checkRateLimit(ctx, opts, users)
+		p.Stop()
+		if err != nil {
+			p.Warn("could not check the rate limit: %v", err)
+		}
+		for _, problem := range problems {
+			p.Warn("%s", problem)
+		}
+		if strictRateLimit && len(problems) > 0 {
+			fmt.Fprintf(os.Stderr, "Aborting because of --strict-rate-limit\n")
+			os.Exit(1)
+		}
 	}
 
-	for i := range createdPRs {
-		createdPRs[i].Involvement = "created"
-		// Retrieve PR details (body and comments)
-		s.Suffix = fmt.Sprintf(" Retrieving details for created PR #%d (%s)...",
-			createdPRs[i].Number, createdPRs[i].Repository)
-		s.Start()
-		err = client.FetchPRDetails(ctx, &createdPRs[i])
-		s.Stop()
+	// Team report for several users
+	if teamUsers != "" {
+		p.Info("Retrieving GitHub activity for %d users (%s)...", len(usernames), strings.Join(usernames, ", "))
+		p.Info("Period: %s to %s", dateRange.StartDate.Format("2006-01-02"), dateRange.EndDate.Format("2006-01-02"))
+
+		// Members of the team keep their logins, also where they appear in each other's items
+		if anonymizer != nil {
+			anonymizer.Keep(usernames...)
+		}
+
+		team, err := collectTeamReport(ctx, usernames, opts, p)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to retrieve details for PR (ID: %d): %v\n", createdPRs[i].Number, err)
+			fmt.Fprintf(os.Stderr, "Failed to retrieve data: %v%s\n", err, fetchErrorHint(err))
+			os.Exit(1)
 		}
+		finishCtx, cancelFinish := finishContext(ctx)
+		defer cancelFinish()
+		partial := false
+		for _, member := range team.Members {
+			for _, warning := range member.Warnings {
+				p.Warn("(%s) %s", member.Username, warning)
+			}
+			partial = partial || member.Partial
+		}
+		if partial {
+			printPartial(ctx, timeout)
+		}
+
+		for i, member := range team.Members {
+			if team.Members[i], err = applyFilterPlugins(member, filterPlugins); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		for i, member := range team.Members {
+			if anonymizer != nil {
+				member = anonymizer.Report(member)
+			}
+			team.Members[i] = member.In(location)
+		}
+
+		if summarize {
+			summarizeReports(finishCtx, newSummarizer(cfg.LLM), team.Members, p)
+		}
+
+		p.Status("Writing results to file")
+		if stream != nil {
+			err = stream.Close()
+		} else {
+			err = output.WriteTeamResults(team, outputFile, outputFormat, outputOpts)
+		}
+		p.Stop()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write to file: %v\n", err)
+			os.Exit(1)
+		}
+
+		printSaved(outputFile, quiet)
+		publishResults(finishCtx, publishOpts, team.Members, outputFile, p)
+		if ghaSummary {
+			if err := writeActionsSummary(team.Members, true, outputFile, outputOpts); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
+		}
+		if openFile {
+			openOutput(outputFile, outputFormat, p)
+		}
+		notifyUpdate(updateCheck, p)
+		return
+	}
+
+	p.Info("Retrieving GitHub activity for user '%s'...", username)
+	p.Info("Period: %s to %s", dateRange.StartDate.Format("2006-01-02"), dateRange.EndDate.Format("2006-01-02"))
+
+	if anonymizer != nil {
+		anonymizer.Keep(username)
 	}
-	allItems = append(allItems, createdPRs...)
 
-	// Retrieve assigned PRs
-	s.Suffix = " Retrieving assigned PRs..."
-	s.Start()
-	assignedPRs, err := client.FetchPRs(ctx, username, "assigned", dateRange)
-	s.Stop()
+	// Data retrieval
+	report, err := collectReport(ctx, client, username, opts, p)
 	if err != nil {
-		return nil, err
+		fmt.Fprintf(os.Stderr, "Failed to retrieve data: %v%s\n", err, fetchErrorHint(err))
+		os.Exit(1)
+	}
+	finishCtx, cancelFinish := finishContext(ctx)
+	defer cancelFinish()
+	for _, warning := range report.Warnings {
+		p.Warn("%s", warning)
+	}
+	if report.Partial {
+		printPartial(ctx, timeout)
+	}
+
+	if report, err = applyFilterPlugins(report, filterPlugins); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
 	}
 
-	for i := range assignedPRs {
-		assignedPRs[i].Involvement = "assigned"
-		// Retrieve PR details (body and comments)
-		s.Suffix = fmt.Sprintf(" Retrieving details for assigned PR #%d (%s)...",
-			assignedPRs[i].Number, assignedPRs[i].Repository)
-		s.Start()
-		err = client.FetchPRDetails(ctx, &assignedPRs[i])
-		s.Stop()
+	if calendarSource != "" {
+		p.Status("Reading the calendar")
+		meetings, err := calendar.Load(finishCtx, calendarSource, dateRange, location)
+		p.Stop()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to retrieve details for PR (ID: %d): %v\n", assignedPRs[i].Number, err)
+			fmt.Fprintf(os.Stderr, "Failed to read the calendar: %v\n", err)
+			os.Exit(1)
 		}
+		report.Meetings = append([]model.Meeting{}, meetings...)
+	}
+
+	if anonymizer != nil {
+		report = anonymizer.Report(report)
 	}
-	allItems = append(allItems, assignedPRs...)
+	report = report.In(location)
 
-	// Retrieve reviewed PRs
-	s.Suffix = " Retrieving reviewed PRs..."
-	s.Start()
-	reviewedPRs, err := client.FetchPRs(ctx, username, "reviewed", dateRange)
-	s.Stop()
+	// The summary is generated from the anonymized report so pseudonyms stay consistent
+	if summarize {
+		reports := []model.Report{report}
+		summarizeReports(finishCtx, newSummarizer(cfg.LLM), reports, p)
+		report = reports[0]
+	}
+
+	// Output results
+	p.Status("Writing results to file")
+	if stream != nil {
+		err = stream.Close()
+	} else {
+		err = output.WriteResults(report, outputFile, outputFormat, outputOpts)
+	}
+	p.Stop()
 	if err != nil {
-		return nil, err
+		fmt.Fprintf(os.Stderr, "Failed to write to file: %v\n", err)
+		os.Exit(1)
 	}
 
-	for i := range reviewedPRs {
-		reviewedPRs[i].Involvement = "reviewed"
-		// Retrieve PR details (body and comments)
-		s.Suffix = fmt.Sprintf(" Retrieving details for reviewed PR #%d (%s)...",
-			reviewedPRs[i].Number, reviewedPRs[i].Repository)
-		s.Start()
-		err = client.FetchPRDetails(ctx, &reviewedPRs[i])
-		s.Stop()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to retrieve details for PR (ID: %d): %v\n", reviewedPRs[i].Number, err)
+	printSaved(outputFile, quiet)
+	publishResults(finishCtx, publishOpts, []model.Report{report}, outputFile, p)
+	if ghaSummary {
+		if err := writeActionsSummary([]model.Report{report}, false, outputFile, outputOpts); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
 		}
 	}
-	allItems = append(allItems, reviewedPRs...)
+	if openFile {
+		openOutput(outputFile, outputFormat, p)
+	}
+	notifyUpdate(updateCheck, p)
+}
+
+// How long the steps after fetching (calendar, summary, publishing) may take
+const finishTimeout = 5 * time.Minute
+
+// finishContext returns the context of the steps after fetching
+// It is not canceled with ctx, so an interrupted or timed-out run still delivers its partial report;
+// a second Ctrl-C ends the run at once
+func finishContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), finishTimeout)
+}
+
+// printPartial tells why the report is partial: --timeout ran out or the run was interrupted
+func printPartial(ctx context.Context, timeout time.Duration) {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		fmt.Fprintf(os.Stderr, "Timed out after %s; writing a partial report\n", timeout)
+	} else {
+		fmt.Fprintln(os.Stderr, "Interrupted; writing a partial report")
+	}
+}
 
-	return allItems, nil
+// printSaved reports the written file; in quiet mode only its path is printed, for scripts
+func printSaved(outputFile string, quiet bool) {
+	if quiet {
+		fmt.Println(outputFile)
+		return
+	}
+	fmt.Printf("Results saved to %s\n", outputFile)
+}
+
+// confirmOverwrite makes sure an existing output file may be replaced
+// A terminal asks for confirmation; otherwise --force (or a --lock file named after its period) is required
+func confirmOverwrite(path string, force bool) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) || force {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	if !term.IsTerminal(os.Stdin) || !term.IsTerminal(os.Stderr) {
+		return fmt.Errorf("%s already exists (pass --force to overwrite it or --no-clobber to keep it)", path)
+	}
+
+	fmt.Fprintf(os.Stderr, "%s already exists. Overwrite it? [y/N] ", path)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return fmt.Errorf("not overwriting %s", path)
 }
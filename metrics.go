@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// A metric family in the Prometheus text exposition format
+type metricFamily struct {
+	name    string
+	help    string
+	samples map[string]float64 // Value by rendered label set
+}
+
+// Escapes label values for the text exposition format
+var metricLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Renders a label set; names and values alternate
+func metricLabels(pairs ...string) string {
+	var parts []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, pairs[i], metricLabelEscaper.Replace(pairs[i+1])))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// writeMetrics writes gauges describing the most recent report of each user
+// An item with several involvements is counted once per involvement
+func writeMetrics(w io.Writer, reports []model.Report, collectedAt []time.Time) error {
+	items := &metricFamily{name: "gh_pric_items_total", help: "Issues, PRs and Gists in the most recent report, by type, involvement and repository", samples: map[string]float64{}}
+	commits := &metricFamily{name: "gh_pric_commits_total", help: "Commits in the most recent report, by repository", samples: map[string]float64{}}
+	merged := &metricFamily{name: "gh_pric_merged_prs_total", help: "PRs created by the user and merged, in the most recent report, by repository", samples: map[string]float64{}}
+	start := &metricFamily{name: "gh_pric_period_start_timestamp_seconds", help: "Start of the period covered by the most recent report", samples: map[string]float64{}}
+	end := &metricFamily{name: "gh_pric_period_end_timestamp_seconds", help: "End of the period covered by the most recent report", samples: map[string]float64{}}
+	collected := &metricFamily{name: "gh_pric_last_run_timestamp_seconds", help: "When the most recent report was collected", samples: map[string]float64{}}
+
+	for i, report := range reports {
+		user := report.Username
+		for _, item := range report.Items {
+			for _, involvement := range item.Involvements {
+				items.samples[metricLabels("user", user, "type", item.Type, "involvement", involvement, "repo", item.Repository)]++
+			}
+			if item.Type == "PR" && item.State == "merged" && item.HasInvolvement("created") {
+				merged.samples[metricLabels("user", user, "repo", item.Repository)]++
+			}
+		}
+		for _, commit := range report.Commits {
+			commits.samples[metricLabels("user", user, "repo", commit.Repository)]++
+		}
+		start.samples[metricLabels("user", user)] = float64(report.DateRange.StartDate.Unix())
+		end.samples[metricLabels("user", user)] = float64(report.DateRange.EndDate.Unix())
+		collected.samples[metricLabels("user", user)] = float64(collectedAt[i].Unix())
+	}
+
+	for _, family := range []*metricFamily{items, commits, merged, start, end, collected} {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", family.name, family.help, family.name); err != nil {
+			return err
+		}
+		labels := make([]string, 0, len(family.samples))
+		for label := range family.samples {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		for _, label := range labels {
+			if _, err := fmt.Fprintf(w, "%s%s %s\n", family.name, label, strconv.FormatFloat(family.samples[label], 'f', -1, 64)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
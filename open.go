@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"git.pepabo.com/yukyan/gh-pric/github/output"
+	ghconfig "github.com/cli/go-gh/v2/pkg/config"
+)
+
+// isTextFormat reports whether the output format is plain text that opens in the editor,
+// judging by the content type of its formatter (text/*, JSON or XML)
+func isTextFormat(format string) bool {
+	formatter, ok := output.Lookup(format)
+	if !ok {
+		return false
+	}
+	typer, ok := formatter.(output.ContentTyper)
+	if !ok {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(typer.ContentType())
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == "application/json" || mediaType == "application/xml" || mediaType == "application/x-ndjson" ||
+		strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml")
+}
+
+// openResult opens the written file (--open): text formats in the editor, others in their default application
+func openResult(path, format string) error {
+	if editor := resolveEditor(); editor != "" && isTextFormat(format) {
+		args := strings.Fields(editor)
+		cmd := exec.Command(args[0], append(args[1:], path)...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s: %w", editor, err)
+		}
+		return nil
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", path)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", path)
+	default:
+		cmd = exec.Command("xdg-open", path)
+	}
+	// The opener returns once the application is launched
+	return cmd.Run()
+}
+
+// openOutput opens the written file, warning when it cannot
+func openOutput(path, format string, p *progress) {
+	if err := openResult(path, format); err != nil {
+		p.Warn("could not open %s: %v", path, err)
+	}
+}
+
+// resolveEditor returns the editor command in the same order of precedence as gh:
+// GH_EDITOR, the editor of the gh config, VISUAL, then EDITOR
+func resolveEditor() string {
+	if editor := os.Getenv("GH_EDITOR"); editor != "" {
+		return editor
+	}
+	if cfg, err := ghconfig.Read(nil); err == nil {
+		if editor, _ := cfg.Get([]string{"editor"}); editor != "" {
+			return editor
+		}
+	}
+	if editor := os.Getenv("VISUAL"); editor != "" {
+		return editor
+	}
+	return os.Getenv("EDITOR")
+}
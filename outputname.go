@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// Values available to the placeholders of --output (e.g. report-{{.User}}-{{.From}}_{{.To}}.md)
+type outputNameData struct {
+	User   string // Login of the user, or the logins of a team report joined with "-"
+	From   string // First day of the period (YYYY-MM-DD)
+	To     string // Last day of the period (YYYY-MM-DD)
+	Date   string // Day of the run (YYYY-MM-DD)
+	Format string // Output format (md, json, ...)
+}
+
+// newOutputNameData collects the placeholder values of a run
+func newOutputNameData(users []string, dateRange model.DateRange, format string, loc *time.Location) outputNameData {
+	return outputNameData{
+		User:   strings.Join(users, "-"),
+		From:   dateRange.StartDate.Format("2006-01-02"),
+		To:     dateRange.EndDate.Format("2006-01-02"),
+		Date:   time.Now().In(loc).Format("2006-01-02"),
+		Format: format,
+	}
+}
+
+// isOutputTemplate tells whether the output file name has placeholders
+func isOutputTemplate(name string) bool {
+	return strings.Contains(name, "{{")
+}
+
+// expandOutputName fills in the placeholders of the output file name
+func expandOutputName(pattern string, data outputNameData) (string, error) {
+	tmpl, err := template.New("output").Option("missingkey=error").Parse(pattern)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	name := b.String()
+	if strings.TrimSpace(name) == "" {
+		return "", fmt.Errorf("%q expands to an empty file name", pattern)
+	}
+	return name, nil
+}
@@ -0,0 +1,281 @@
+package pric
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"git.pepabo.com/yukyan/gh-pric/github"
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// Progress は取得の進み具合を受け取るインターフェースです（gh-pric ではスピナーやログ行として表示されます）
+// Status(message string) と Info(format string, args ...interface{}) も実装していれば、長さのわからない手順とお知らせも受け取ります
+type Progress interface {
+	// Begin starts a phase of total steps
+	Begin(phase string, total int)
+	// Step marks one step of the current phase as done
+	Step(detail string)
+	// Stop ends the current phase
+	Stop()
+	// Log reports a failure that does not stop the run
+	Log(format string, args ...interface{})
+}
+
+// status shows a step of unknown length on progress reporters that can, such as the spinner of gh-pric
+func status(p Progress, message string) {
+	if s, ok := p.(interface{ Status(message string) }); ok {
+		s.Status(message)
+	}
+}
+
+// info shows a notice on progress reporters that can
+func info(p Progress, format string, args ...interface{}) {
+	if i, ok := p.(interface {
+		Info(format string, args ...interface{})
+	}); ok {
+		i.Info(format, args...)
+	}
+}
+
+// Progress that ignores everything
+type noProgress struct{}
+
+func (noProgress) Begin(string, int)          {}
+func (noProgress) Step(string)                {}
+func (noProgress) Stop()                      {}
+func (noProgress) Log(string, ...interface{}) {}
+
+// Items whose details are fetched together, the size of one batched query
+// Smaller chunks emit the first items sooner; larger ones need fewer queries
+const detailChunkSize = 50
+
+// Category is one search of the report: an item type and how the user is involved
+type Category struct {
+	ItemType    string // "Issue" or "PR"
+	Involvement string
+}
+
+// Categories are the searches made for a report, in output order
+var Categories = []Category{
+	{"Issue", "created"},
+	{"Issue", "assigned"},
+	{"Issue", "commented"},
+	{"Issue", "mentioned"},
+	{"PR", "created"},
+	{"PR", "assigned"},
+	{"PR", "reviewed"},
+	{"PR", "mentioned"},
+}
+
+// FetchItems はユーザーの関わった PR と Issue をすべて取得します
+// 複数のカテゴリで見つかった項目は、関わり方を複数持つ 1 つの項目にまとめられます
+// 2 番目の戻り値は結果が打ち切られたカテゴリの警告です
+// ctx がキャンセルされた場合は、それまでに集めた項目をコンテキストのエラーと一緒に返します
+// 一部の項目の詳細を取得できなかった場合は、すべての項目を *github.ErrPartial と一緒に返します
+// emit は詳細を取得し終えた項目ごとに呼ばれます（nil = 呼ばない）。すべてのカテゴリを検索し終えてから、最大 50 件ずつ詳細を取得して呼ばれます
+// summaryOnly では詳細を取得せず、検索結果をそのまま返します
+func FetchItems(ctx context.Context, client github.Fetcher, username string, dateRange model.DateRange, includeProjects, summaryOnly bool, emit func(model.Item), p Progress) ([]model.Item, []string, error) {
+	return fetchItems(ctx, client, github.FetchOptions{Username: username, DateRange: dateRange}, includeProjects, summaryOnly, emit, p)
+}
+
+// fetchItems is FetchItems with the user, period and other search conditions of fetchOpts
+func fetchItems(ctx context.Context, client github.Fetcher, fetchOpts github.FetchOptions, includeProjects, summaryOnly bool, emit func(model.Item), p Progress) ([]model.Item, []string, error) {
+	dateRange := fetchOpts.DateRange
+	if emit == nil {
+		emit = func(model.Item) {}
+	}
+	if p == nil {
+		p = noProgress{}
+	}
+
+	// Search every category first so the number of detail requests is known.
+	// The categories are searched concurrently; the client paces the search requests themselves
+	type searchResult struct {
+		items     []model.Item
+		truncated bool
+		err       error
+		done      bool
+	}
+	results := make([]searchResult, len(Categories))
+	searchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	p.Begin("Searching", len(Categories))
+	var wg sync.WaitGroup
+	for i, category := range Categories {
+		wg.Add(1)
+		go func(i int, category Category) {
+			defer wg.Done()
+			search := fetchOpts
+			search.Involvement = category.Involvement
+			result := &results[i]
+			if category.ItemType == "PR" {
+				result.items, result.truncated, result.err = client.FetchPRs(searchCtx, search)
+			} else {
+				result.items, result.truncated, result.err = client.FetchIssues(searchCtx, search)
+			}
+			if result.err != nil {
+				// One failed category fails the whole search, so the others can stop
+				cancel()
+				return
+			}
+			result.done = true
+			p.Step(fmt.Sprintf("(%s %ss)", category.Involvement, category.ItemType))
+		}(i, category)
+	}
+	wg.Wait()
+	p.Stop()
+
+	// Merge in category order, so involvements are listed in the same order as before
+	var allItems []model.Item
+	var warnings []string
+	seen := make(map[string]int) // repo#number -> index in allItems
+	var searchErr error
+	for i, category := range Categories {
+		result := results[i]
+		if !result.done {
+			if searchErr == nil && result.err != nil && !errors.Is(result.err, context.Canceled) {
+				searchErr = result.err
+			}
+			continue
+		}
+
+		if result.truncated {
+			warnings = append(warnings, fmt.Sprintf("Results for %s %ss were truncated; some items may be missing (try raising --max-pages or narrowing the period)",
+				category.Involvement, category.ItemType))
+		}
+
+		for _, item := range result.items {
+			// Items found in an earlier category only gain another involvement
+			key := item.Key()
+			if index, ok := seen[key]; ok {
+				allItems[index].Involvements = append(allItems[index].Involvements, category.Involvement)
+				continue
+			}
+			item.Involvements = []string{category.Involvement}
+			seen[key] = len(allItems)
+			allItems = append(allItems, item)
+		}
+	}
+	if ctx.Err() != nil {
+		return allItems, warnings, ctx.Err()
+	}
+	if searchErr != nil {
+		return nil, nil, searchErr
+	}
+
+	if summaryOnly {
+		for _, item := range allItems {
+			emit(item)
+		}
+		return allItems, warnings, nil
+	}
+
+	// Retrieve details (body, comments, and so on) of every item
+	p.Begin("Fetching details", len(allItems))
+	defer p.Stop()
+
+	// Bodies and comments are requested for many items at once when the fetcher can;
+	// whatever it could not fill is requested item by item. Each chunk is emitted before the next is requested
+	batch, canBatch := client.(github.BatchFetcher)
+	var failed []github.ItemRef
+	for start := 0; start < len(allItems); start += detailChunkSize {
+		end := start + detailChunkSize
+		if end > len(allItems) {
+			end = len(allItems)
+		}
+
+		batched := make([]bool, end-start)
+		if canBatch {
+			items := make([]*model.Item, end-start)
+			for i := range items {
+				items[i] = &allItems[start+i]
+			}
+			filled, err := batch.FetchDetailsBatch(ctx, items)
+			if ctx.Err() != nil {
+				return allItems, warnings, ctx.Err()
+			}
+			if err != nil {
+				p.Log("Failed to retrieve details in batches, retrieving them one item at a time: %v", err)
+			}
+			copy(batched, filled)
+		}
+
+		for i := start; i < end; i++ {
+			item := &allItems[i]
+			err := fetchItemDetails(ctx, client, item, dateRange, includeProjects, batched[i-start])
+			if ctx.Err() != nil {
+				// Items whose details were not fetched are still reported
+				return allItems, warnings, ctx.Err()
+			}
+			if err != nil {
+				p.Log("Failed to retrieve details for %s %s#%d: %v", item.Type, item.Repository, item.Number, err)
+				failed = append(failed, github.ItemRef{Type: item.Type, Repository: item.Repository, Number: item.Number, Err: err})
+			}
+			emit(*item)
+			p.Step(fmt.Sprintf("(%s #%d)", item.Repository, item.Number))
+		}
+	}
+
+	if len(failed) > 0 {
+		return allItems, warnings, &github.ErrPartial{Failed: failed}
+	}
+	return allItems, warnings, nil
+}
+
+// fetchItemDetails retrieves everything shown for an item besides the search result itself
+// batched skips the body, comments and reviews, already filled by FetchDetailsBatch
+func fetchItemDetails(ctx context.Context, client github.Fetcher, item *model.Item, dateRange model.DateRange, includeProjects, batched bool) error {
+	var err error
+	if item.Type == "PR" {
+		if !batched {
+			err = client.FetchPRDetails(ctx, item)
+		}
+		// Show what PRs you authored actually contained and their CI state
+		if err == nil && item.HasInvolvement("created") {
+			err = client.FetchPRCommits(ctx, item)
+		}
+		if err == nil && item.HasInvolvement("created") {
+			err = client.FetchChecks(ctx, item)
+		}
+	} else if !batched {
+		err = client.FetchIssueDetails(ctx, item)
+	}
+	if err == nil {
+		err = client.FetchTimeline(ctx, item, dateRange)
+	}
+	if err == nil && includeProjects {
+		err = client.FetchProjectItems(ctx, item)
+	}
+	return err
+}
+
+// ActiveRepos はユーザーが作業したリポジトリを、重複を除いて名前順に返します
+func ActiveRepos(items []model.Item, commits []model.Commit, repositories []model.Repository, extra []string) []string {
+	seen := make(map[string]bool)
+	var repos []string
+	add := func(repo string) {
+		if repo != "" && !seen[strings.ToLower(repo)] {
+			seen[strings.ToLower(repo)] = true
+			repos = append(repos, repo)
+		}
+	}
+	for _, item := range items {
+		add(item.Repository)
+	}
+	for _, commit := range commits {
+		add(commit.Repository)
+	}
+	for _, repo := range repositories {
+		add(repo.Name)
+	}
+	for _, repo := range extra {
+		add(repo)
+	}
+	sort.Strings(repos)
+	return repos
+}
@@ -0,0 +1,485 @@
+// Package pric は gh-pric のレポートの収集と出力を、ほかの Go のプログラムに組み込むための API です
+//
+// gh-pric をコマンドとして実行する代わりに、次のようにレポートを取得して任意の形式で書き出せます
+//
+//	report, err := pric.Report(ctx, pric.Options{
+//		Username:  "octocat",
+//		DateRange: model.DateRange{StartDate: from, EndDate: to},
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	err = pric.Render(os.Stdout, report, "md", output.Options{})
+//
+// GitHub の認証には gh と同じ設定（GH_TOKEN や gh auth login の結果）が使われます
+package pric
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github"
+	"git.pepabo.com/yukyan/gh-pric/github/jira"
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+	"git.pepabo.com/yukyan/gh-pric/github/output"
+	"git.pepabo.com/yukyan/gh-pric/github/state"
+)
+
+// Options はレポートを収集するときの設定です（ゼロ値の項目は gh-pric の既定値になります）
+type Options struct {
+	// Username is the user whose activity is reported (empty = the authenticated user)
+	Username string
+
+	// DateRange is the period of the report
+	DateRange model.DateRange
+
+	// Host is the GitHub hostname, e.g. a GitHub Enterprise Server (empty = the gh default host)
+	Host string
+
+	// CacheDir enables the on-disk response cache in the given directory (empty = disabled)
+	CacheDir string
+
+	// Repos restricts the report to these repositories (owner/name)
+	Repos []string
+
+	// ExcludeRepos drops repositories matching these patterns (owner/name, glob supported)
+	ExcludeRepos []string
+
+	// Orgs restricts the report to repositories owned by these organizations or users
+	Orgs []string
+
+	// Visibility restricts the report to "public" or "private" repositories (empty = both)
+	Visibility string
+
+	// ClosedInRange also includes items closed during the period even if they were created earlier
+	ClosedInRange bool
+
+	// ResolveVisibility looks up whether the repository of every item is private, e.g. to anonymize private repositories
+	ResolveVisibility bool
+
+	// MaxPages limits the search result pages fetched per query (0 = unlimited)
+	MaxPages int
+
+	// IncludeProjects adds the GitHub Projects (v2) status of every item
+	IncludeProjects bool
+
+	// IncludeCI adds the workflow runs the user triggered in the repositories they worked in
+	IncludeCI bool
+
+	// SummaryOnly skips fetching item details (bodies, comments, reviews, ...)
+	SummaryOnly bool
+
+	// IgnoreUsers drops comments by these users
+	IgnoreUsers []string
+
+	// KeepBots keeps the items and comments authored by bots, which are dropped by default
+	KeepBots bool
+
+	// OnlyMyComments keeps only the user's own comments on items they merely commented on
+	OnlyMyComments bool
+
+	// RedactPatterns are the secrets masked in titles, bodies, comments and commit messages (nil = github.DefaultSecretPatterns)
+	RedactPatterns []*regexp.Regexp
+
+	// NoRedact leaves titles, bodies, comments and commit messages as they are, without masking secrets
+	NoRedact bool
+
+	// SinceLastRun only fetches the Issues and PRs updated since the previous run and merges them into the dataset saved in StateDir
+	SinceLastRun bool
+
+	// StateDir is where SinceLastRun keeps its dataset (empty = "state" in CacheDir)
+	StateDir string
+
+	// Jira also reports the Jira issues the user worked on during the period, as items of the "Jira" type (nil = disabled)
+	Jira *jira.Client
+
+	// MaxComments caps the comments fetched per item (0 = unlimited)
+	MaxComments int
+
+	// MaxRetries is the number of attempts made for each API request (0 = the gh-pric default)
+	MaxRetries int
+
+	// RetryWait is the wait before the first retry (0 = the gh-pric default)
+	RetryWait time.Duration
+
+	// Trace logs every API request to this writer (nil = disabled)
+	Trace io.Writer
+
+	// Transport sends the API requests (nil = http.DefaultTransport), e.g. to replay recorded responses
+	Transport http.RoundTripper
+
+	// Hooks are called around every API request and before retries, e.g. for logging or metrics
+	Hooks []github.Hooks
+
+	// Fetcher replaces the GitHub client altogether (nil = a client built from the options above)
+	// The connection settings above (Host, CacheDir, MaxComments, MaxRetries, RetryWait, Trace, Transport and Hooks) are not applied to it
+	Fetcher github.Fetcher
+
+	// Progress receives the progress of the run (nil = disabled)
+	Progress Progress
+
+	// OnItem receives each reported item as soon as its details are fetched (nil = disabled)
+	OnItem func(model.Item)
+}
+
+// Report は opts で指定したユーザーと期間のレポートを収集します
+// ctx がキャンセルされた場合は、それまでに集めたデータを Partial なレポートとして返します
+// 一部の項目の詳細を取得できなかった場合は、レポートを *github.ErrPartial と一緒に返します
+// API のエラーは errors.Is で github.ErrAuth・github.ErrNotFound と、errors.As で *github.ErrRateLimited と比べられます
+func Report(ctx context.Context, opts Options) (*model.Report, error) {
+	client, err := NewClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	return client.Report(ctx, opts)
+}
+
+// Client は同じ GitHub クライアント（接続、キャッシュ、レート制限の待ち）を使ってレポートを何度も収集します
+type Client struct {
+	fetcher github.Fetcher
+}
+
+// NewClient は opts の接続の設定（Host、CacheDir、MaxComments、MaxRetries、RetryWait、Trace、Transport、Hooks、Fetcher）で Client を作成します
+func NewClient(opts Options) (*Client, error) {
+	if opts.Fetcher != nil {
+		return &Client{fetcher: opts.Fetcher}, nil
+	}
+	ghClient, err := newClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{fetcher: ghClient}, nil
+}
+
+// Report は pric.Report と同じレポートを、Client の GitHub クライアントで収集します
+// opts の接続の設定は使わず、NewClient に渡したものが使われます。フィルタや MaxPages などの条件は呼び出しごとの opts が使われます
+func (c *Client) Report(ctx context.Context, opts Options) (*model.Report, error) {
+	client := c.fetcher
+	username, err := reportedUser(ctx, client, opts)
+	if err != nil {
+		return nil, err
+	}
+	p := opts.Progress
+	if p == nil {
+		p = noProgress{}
+	}
+	fetchOpts := fetchOptions(username, opts)
+
+	// Load the dataset saved by the previous run for incremental sync
+	stateDir := opts.StateDir
+	if stateDir == "" {
+		stateDir = filepath.Join(opts.CacheDir, "state")
+	}
+	runStartedAt := time.Now()
+	var syncState *state.State
+	if opts.SinceLastRun {
+		syncState, err = state.Load(stateDir, username)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the previous run: %w", err)
+		}
+		if syncState.Covers(opts.DateRange) {
+			fetchOpts.UpdatedSince = syncState.LastRun
+			info(p, "Fetching only items updated since %s", syncState.LastRun.Format("2006-01-02 15:04:05"))
+		} else {
+			// The saved dataset does not reach back far enough, so start over
+			syncState = &state.State{}
+		}
+	}
+
+	// The incremental sync state is left untouched when interrupted, so the next run fetches everything again
+	report := &model.Report{Username: username, DateRange: opts.DateRange}
+	partial := func() (*model.Report, error) {
+		report.Items = filter(report.Items, username, opts)
+		report.Warnings = append(report.Warnings, "The run was interrupted before all data was fetched; this report is incomplete")
+		report.Partial = true
+		return report, nil
+	}
+
+	// Items that survive the filters are passed to OnItem as soon as they are fetched
+	emitted := make(map[string]bool)
+	emit := func(item model.Item) {
+		if opts.OnItem == nil || emitted[item.Key()] {
+			return
+		}
+		for _, filtered := range filter([]model.Item{item}, username, opts) {
+			emitted[item.Key()] = true
+			opts.OnItem(filtered)
+		}
+	}
+
+	report.Items, report.Warnings, err = fetchItems(ctx, client, fetchOpts, opts.IncludeProjects, opts.SummaryOnly, emit, p)
+	if ctx.Err() != nil {
+		return partial()
+	}
+	var detailsErr *github.ErrPartial
+	if errors.As(err, &detailsErr) {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status(p, "Retrieving commits")
+	commits, truncated, err := client.FetchCommits(ctx, fetchOpts)
+	p.Stop()
+	if ctx.Err() != nil {
+		return partial()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if truncated {
+		report.Warnings = append(report.Warnings, "Results for commits were truncated; some commits may be missing (try raising --max-pages or narrowing the period)")
+	}
+	report.Commits = redactCommits(commits, opts)
+
+	// Merge into the saved dataset and record this run
+	if syncState != nil {
+		syncState.Merge(report.Items)
+		syncState.LastRun = runStartedAt
+		if syncState.StartDate.IsZero() || opts.DateRange.StartDate.Before(syncState.StartDate) {
+			syncState.StartDate = opts.DateRange.StartDate
+		}
+		if err := state.Save(stateDir, username, syncState); err != nil {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("Failed to save the run state: %v", err))
+		}
+		report.Items = syncState.ItemsIn(opts.DateRange, opts.ClosedInRange)
+
+		// Items from earlier runs were not fetched this time, so emit them now
+		for _, item := range report.Items {
+			emit(item)
+		}
+	}
+
+	status(p, "Retrieving repositories")
+	report.Repositories, err = client.FetchCreatedRepos(ctx, fetchOpts)
+	p.Stop()
+	if ctx.Err() != nil {
+		return partial()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Workflow runs have no cross-repository search, so look at every repository touched
+	if opts.IncludeCI {
+		repos := ActiveRepos(report.Items, report.Commits, report.Repositories, opts.Repos)
+		p.Begin("Retrieving workflow runs", len(repos))
+		for _, repo := range repos {
+			runs, err := client.FetchWorkflowRuns(ctx, repo, fetchOpts)
+			p.Step(repo)
+			if ctx.Err() != nil {
+				p.Stop()
+				return partial()
+			}
+			if err != nil {
+				// Actions may be disabled or inaccessible in some repositories
+				report.Warnings = append(report.Warnings, err.Error())
+				continue
+			}
+			report.WorkflowRuns = append(report.WorkflowRuns, runs...)
+		}
+		p.Stop()
+	}
+
+	// Gists live outside repositories, so they are skipped when the report is limited to repositories
+	if len(opts.Repos) == 0 && len(opts.Orgs) == 0 {
+		status(p, "Retrieving gists")
+		gists, err := client.FetchGists(ctx, fetchOpts)
+		p.Stop()
+		if ctx.Err() != nil {
+			return partial()
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, gist := range gists {
+			emit(gist)
+		}
+		report.Items = append(report.Items, gists...)
+	}
+
+	// Jira issues are interleaved with the GitHub items, as items of the "Jira" type
+	if opts.Jira != nil {
+		status(p, "Retrieving Jira issues")
+		issues, err := opts.Jira.FetchActivity(ctx, opts.DateRange)
+		p.Stop()
+		if ctx.Err() != nil {
+			return partial()
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			emit(issue)
+		}
+		report.Items = append(report.Items, issues...)
+	}
+
+	report.Items = filter(report.Items, username, opts)
+	if detailsErr != nil {
+		return report, detailsErr
+	}
+	return report, nil
+}
+
+// StreamItems は Report と同じ項目（PR・Issue・Gist、Jira を指定すれば Jira の課題）を、詳細を取得し終えたものから順にチャネルへ送ります
+// SinceLastRun は前回の実行のデータと合わせる必要があるため使えず、指定するとエラーになります
+// 項目のチャネルはすべて送り終えると閉じられます。その後、エラーのチャネルが失敗したときだけ 1 つのエラーを送ってから閉じられます
+// 受け取る側は項目のチャネルを最後まで読むか、ctx をキャンセルしてください
+// opts の接続の設定は使わず、NewClient に渡したものが使われます。フィルタや MaxPages などの条件は呼び出しごとの opts が使われます
+func (c *Client) StreamItems(ctx context.Context, opts Options) (<-chan model.Item, <-chan error) {
+	items := make(chan model.Item)
+	errs := make(chan error, 1)
+	go func() {
+		err := c.streamItems(ctx, opts, items)
+		close(items)
+		if err != nil {
+			errs <- err
+		}
+		close(errs)
+	}()
+	return items, errs
+}
+
+// streamItems sends the filtered items of the report to out until all are fetched or ctx is cancelled
+func (c *Client) streamItems(ctx context.Context, opts Options, out chan<- model.Item) error {
+	if opts.SinceLastRun {
+		return fmt.Errorf("SinceLastRun cannot be used with StreamItems (the items of previous runs are only merged into a Report)")
+	}
+	client := c.fetcher
+	username, err := reportedUser(ctx, client, opts)
+	if err != nil {
+		return err
+	}
+
+	send := func(item model.Item) {
+		for _, filtered := range filter([]model.Item{item}, username, opts) {
+			select {
+			case out <- filtered:
+			case <-ctx.Done():
+			}
+		}
+	}
+	_, _, err = fetchItems(ctx, client, fetchOptions(username, opts), opts.IncludeProjects, opts.SummaryOnly, send, opts.Progress)
+	// Items whose details failed were sent all the same, so the rest is still fetched
+	var detailsErr *github.ErrPartial
+	if err != nil && !errors.As(err, &detailsErr) {
+		return err
+	}
+
+	if len(opts.Repos) == 0 && len(opts.Orgs) == 0 {
+		gists, err := client.FetchGists(ctx, fetchOptions(username, opts))
+		if err != nil {
+			return err
+		}
+		for _, gist := range gists {
+			send(gist)
+		}
+	}
+	if opts.Jira != nil {
+		issues, err := opts.Jira.FetchActivity(ctx, opts.DateRange)
+		if err != nil {
+			return err
+		}
+		for _, issue := range issues {
+			send(issue)
+		}
+	}
+	if detailsErr != nil {
+		return detailsErr
+	}
+	return ctx.Err()
+}
+
+// fetchOptions returns the user, period, filters and limits of opts for the fetches
+func fetchOptions(username string, opts Options) github.FetchOptions {
+	return github.FetchOptions{
+		Username:          username,
+		DateRange:         opts.DateRange,
+		ClosedInRange:     opts.ClosedInRange,
+		Repos:             opts.Repos,
+		ExcludeRepos:      opts.ExcludeRepos,
+		Orgs:              opts.Orgs,
+		Visibility:        opts.Visibility,
+		ResolveVisibility: opts.ResolveVisibility,
+		MaxPages:          opts.MaxPages,
+	}
+}
+
+// reportedUser returns the user of opts, or the authenticated user when none is given
+func reportedUser(ctx context.Context, client github.Fetcher, opts Options) (string, error) {
+	if opts.Username != "" {
+		return opts.Username, nil
+	}
+	return client.GetUsername(ctx)
+}
+
+// newClient creates a GitHub client configured with the connection settings of opts
+func newClient(opts Options) (*github.Client, error) {
+	client, err := github.NewClient(github.ClientOptions{CacheDir: opts.CacheDir, Host: opts.Host, Trace: opts.Trace, Transport: opts.Transport, Hooks: opts.Hooks})
+	if err != nil {
+		return nil, err
+	}
+	client.MaxComments = opts.MaxComments
+	if opts.MaxRetries > 0 {
+		client.MaxRetries = opts.MaxRetries
+	}
+	if opts.RetryWait > 0 {
+		client.RetryWait = opts.RetryWait
+	}
+	return client, nil
+}
+
+// Render はレポートを指定した形式（"md"、"json"、"csv" など gh-pric の --output-format と同じ名前）で w に書き出します
+func Render(w io.Writer, report *model.Report, format string, opts output.Options) error {
+	if report == nil {
+		return fmt.Errorf("no report to render")
+	}
+	return output.WriteReport(w, *report, format, opts)
+}
+
+// Filter は opts のコメント・作成者のフィルタと秘密情報のマスクを、集めた項目に適用します
+// Report と StreamItems が返す項目にはすでに適用されています。保存したデータから作ったレポートなどに使います
+func Filter(items []model.Item, username string, opts Options) []model.Item {
+	return filter(items, username, opts)
+}
+
+// filter applies the comment and author filters and the redaction of opts to the collected items
+func filter(items []model.Item, username string, opts Options) []model.Item {
+	if len(opts.IgnoreUsers) > 0 {
+		github.FilterIgnoredUserComments(items, opts.IgnoreUsers)
+	}
+	if !opts.KeepBots {
+		items = github.FilterBots(items)
+	}
+	if opts.OnlyMyComments {
+		github.FilterOnlyUserComments(items, username)
+	}
+	if !opts.NoRedact {
+		github.RedactSecrets(items, redactPatterns(opts))
+	}
+	return items
+}
+
+// redactCommits masks the secrets and e-mail addresses in the messages of the commits of the report
+func redactCommits(commits []model.Commit, opts Options) []model.Commit {
+	if opts.NoRedact {
+		return commits
+	}
+	return github.RedactCommits(commits, redactPatterns(opts))
+}
+
+// redactPatterns returns the patterns masked by opts
+func redactPatterns(opts Options) []*regexp.Regexp {
+	if opts.RedactPatterns == nil {
+		return github.DefaultSecretPatterns
+	}
+	return opts.RedactPatterns
+}
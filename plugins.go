@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+	"git.pepabo.com/yukyan/gh-pric/github/output"
+	"git.pepabo.com/yukyan/gh-pric/github/plugin"
+)
+
+// lookupFormat returns the formatter of --output-format
+// A name that is not registered is looked up as a gh-pric-format-<name> plugin and registered
+func lookupFormat(name string) (output.Formatter, error) {
+	if formatter, ok := output.Lookup(name); ok {
+		return formatter, nil
+	}
+	if err := registerFormatPlugin(name); err != nil {
+		return nil, err
+	}
+	formatter, _ := output.Lookup(name)
+	return formatter, nil
+}
+
+// formatList lists the registered output formats for help and error messages, e.g. "csv, json or md"
+func formatList() string {
+	names := output.Formats()
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+// registerFormatPlugin makes --output-format <name> run gh-pric-format-<name>
+func registerFormatPlugin(name string) error {
+	path, err := plugin.Find(plugin.FormatPrefix, name)
+	if err != nil {
+		return err
+	}
+	output.Register(name, plugin.Formatter{Path: path})
+	return nil
+}
+
+// findFilterPlugins resolves the gh-pric-filter-* executables of --filter, in the order given
+func findFilterPlugins(names []string) ([]string, error) {
+	paths := make([]string, len(names))
+	for i, name := range names {
+		path, err := plugin.Find(plugin.FilterPrefix, name)
+		if err != nil {
+			return nil, err
+		}
+		paths[i] = path
+	}
+	return paths, nil
+}
+
+// applyFilterPlugins passes the items of the report through every filter plugin in turn
+func applyFilterPlugins(report model.Report, paths []string) (model.Report, error) {
+	for _, path := range paths {
+		items, err := plugin.Filter(path, report)
+		if err != nil {
+			return report, fmt.Errorf("filter plugin failed: %w", err)
+		}
+		report.Items = items
+	}
+	return report, nil
+}
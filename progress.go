@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/cli/go-gh/v2/pkg/term"
+)
+
+// Minimum interval between plain progress lines when stdout is not a terminal
+const progressLogInterval = 5 * time.Second
+
+// progress reports what the tool is doing, as a live spinner line on a terminal
+// and as plain log lines otherwise
+type progress struct {
+	mu      sync.Mutex
+	spinner *spinner.Spinner
+	tty     bool
+	quiet   bool
+	json    bool // Emit NDJSON events on stderr instead of text (--progress-format json)
+
+	rateLimits map[string]int // Last remaining quota seen per API resource (core, search, graphql)
+
+	// Counted phase
+	phase   string
+	done    int
+	total   int
+	started time.Time
+	logged  time.Time
+}
+
+// newProgress creates a progress reporter; a quiet reporter prints nothing
+func newProgress(quiet bool) *progress {
+	return &progress{
+		spinner: spinner.New(spinner.CharSets[9], 100*time.Millisecond),
+		tty:     term.FromEnv().IsTerminalOutput(),
+		quiet:   quiet,
+	}
+}
+
+// Status shows a single step whose length is unknown
+func (p *progress) Status(message string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.quiet {
+		return
+	}
+
+	p.total = 0
+	if p.json {
+		p.emit(map[string]interface{}{"type": "status", "message": message})
+		return
+	}
+	if p.tty {
+		p.spinner.Suffix = " " + message + "..."
+		p.spinner.Start()
+		return
+	}
+	fmt.Println(message + "...")
+}
+
+// Begin starts a phase of total steps, reported as "phase done/total, ~ETA remaining"
+func (p *progress) Begin(phase string, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.quiet {
+		return
+	}
+
+	p.phase = phase
+	p.done = 0
+	p.total = total
+	p.started = time.Now()
+	p.logged = time.Time{}
+	p.render("")
+}
+
+// Step marks one step of the current phase as done
+func (p *progress) Step(detail string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.quiet || p.total == 0 {
+		return
+	}
+
+	p.done++
+	p.render(detail)
+}
+
+// Info prints an informational message
+func (p *progress) Info(format string, args ...interface{}) {
+	if p.quiet {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.json {
+		p.emit(map[string]interface{}{"type": "info", "message": fmt.Sprintf(format, args...)})
+		return
+	}
+
+	active := p.spinner.Active()
+	if active {
+		p.spinner.Stop()
+	}
+	fmt.Printf(format+"\n", args...)
+	if active {
+		p.spinner.Start()
+	}
+}
+
+// Log prints an error message to stderr without garbling the live progress line
+func (p *progress) Log(format string, args ...interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.json {
+		p.emit(map[string]interface{}{"type": "error", "message": fmt.Sprintf(format, args...)})
+		return
+	}
+
+	active := p.spinner.Active()
+	if active {
+		p.spinner.Stop()
+	}
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	if active {
+		p.spinner.Start()
+	}
+}
+
+// Warn prints a warning to stderr unless the reporter is quiet
+func (p *progress) Warn(format string, args ...interface{}) {
+	if p.quiet {
+		return
+	}
+	if p.json {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.emit(map[string]interface{}{"type": "warning", "message": fmt.Sprintf(format, args...)})
+		return
+	}
+	p.Log("Warning: "+format, args...)
+}
+
+// Notice prints a message for the user (like an available update) to stderr unless the reporter is quiet
+func (p *progress) Notice(format string, args ...interface{}) {
+	if p.quiet {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.json {
+		p.emit(map[string]interface{}{"type": "notice", "message": fmt.Sprintf(format, args...)})
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\n"+format+"\n", args...)
+}
+
+// RateLimit records the remaining quota of an API resource, reported with the next progress event
+func (p *progress) RateLimit(resource string, remaining int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.rateLimits == nil {
+		p.rateLimits = make(map[string]int)
+	}
+	p.rateLimits[resource] = remaining
+}
+
+// Stop ends the current step or phase
+func (p *progress) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.spinner.Stop()
+	p.total = 0
+}
+
+// render prints the current progress (the caller must hold the lock)
+func (p *progress) render(detail string) {
+	if p.json {
+		event := map[string]interface{}{"type": "progress", "phase": p.phase, "completed": p.done, "total": p.total}
+		if detail != "" {
+			event["detail"] = detail
+		}
+		if eta := p.remaining(); eta >= time.Second {
+			event["eta_seconds"] = int(eta.Seconds())
+		}
+		if len(p.rateLimits) > 0 {
+			event["rate_limit_remaining"] = p.rateLimits
+		}
+		p.emit(event)
+		return
+	}
+
+	line := fmt.Sprintf("%s %d/%d", p.phase, p.done, p.total)
+	if eta := p.remaining(); eta > 0 {
+		line += fmt.Sprintf(", ~%s remaining", formatDuration(eta))
+	}
+
+	if p.tty {
+		if detail != "" {
+			line += " " + detail
+		}
+		p.spinner.Suffix = " " + line
+		p.spinner.Start()
+		return
+	}
+
+	// Without a terminal, throttle the log lines but always report the start and the end
+	now := time.Now()
+	if p.done != 0 && p.done != p.total && now.Sub(p.logged) < progressLogInterval {
+		return
+	}
+	p.logged = now
+	fmt.Println(line)
+}
+
+// emit writes one JSON event line to stderr (the caller must hold the lock)
+func (p *progress) emit(event map[string]interface{}) {
+	event["time"] = time.Now().Format(time.RFC3339)
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(os.Stderr, string(data))
+}
+
+// remaining estimates the time left from the average step duration so far
+func (p *progress) remaining() time.Duration {
+	if p.done == 0 || p.done >= p.total {
+		return 0
+	}
+	perStep := time.Since(p.started) / time.Duration(p.done)
+	return perStep * time.Duration(p.total-p.done)
+}
+
+// formatDuration formats an estimate as "2m" or "45s"
+func formatDuration(d time.Duration) string {
+	if d >= time.Minute {
+		return fmt.Sprintf("%dm", int((d+30*time.Second)/time.Minute))
+	}
+	return fmt.Sprintf("%ds", int((d+time.Second/2)/time.Second))
+}
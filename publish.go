@@ -0,0 +1,626 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+	"git.pepabo.com/yukyan/gh-pric/github/publish"
+	"git.pepabo.com/yukyan/gh-pric/github/upload"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/cli/go-gh/v2/pkg/auth"
+)
+
+// Settings for publishing the report after it is written
+type publishOptions struct {
+	target           string // Destination given with --publish (empty = disabled)
+	post             string // Webhook or chat that receives the report with --post: webhook, discord or teams (empty = disabled)
+	notionDatabase   string // Notion database ID
+	gistID           string // Gist updated by --publish gist (empty = the one of the previous run, or a new one)
+	outputFormat     string // Format of the written file
+	hostname         string // GitHub host the gist is published on (empty = default host)
+	cacheDir         string // Directory where the gist of each run is remembered (--cache-dir, empty = not remembered)
+	esaTeam          string // esa.io team that receives the post with --publish esa
+	esaCategory      string // Category of the esa.io post, with the placeholders of --output
+	kibelaTeam       string // Kibela team that receives the note with --publish kibela
+	kibelaGroup      string // Group of the Kibela note
+	kibelaFolder     string // Folder of the Kibela note in its group, with the placeholders of --output (empty = none)
+	confluenceURL    string // Confluence Cloud site that receives the page with --publish confluence
+	confluenceSpace  string // Key of the space of the Confluence page
+	confluenceParent string // ID of the page new Confluence pages are created under (empty = top level of the space)
+	gdocsFolder      string // Google Drive folder of the document created with --publish gdocs (empty = My Drive)
+	webhookURL       string // Endpoint that receives the JSON report with --post webhook
+	discordWebhook   string // Discord webhook URL of --post discord (empty = DISCORD_WEBHOOK_URL)
+	teamsWebhook     string // Microsoft Teams webhook URL of --post teams (empty = TEAMS_WEBHOOK_URL)
+	upload           string // Object storage URL the written file is copied to (s3:// or gs://, empty = disabled)
+}
+
+// validate checks that the destination is known and everything it needs is set
+func (o publishOptions) validate() error {
+	if o.upload != "" {
+		if _, err := upload.ParseDestination(o.upload); err != nil {
+			return fmt.Errorf("invalid --upload: %w", err)
+		}
+	}
+	if o.gistID != "" && o.target != "gist" {
+		return fmt.Errorf("--gist-id can only be used with --publish gist")
+	}
+	if o.esaTeam != "" && o.target != "esa" {
+		return fmt.Errorf("--esa-team can only be used with --publish esa")
+	}
+	if o.kibelaTeam != "" && o.target != "kibela" {
+		return fmt.Errorf("--kibela-team can only be used with --publish kibela")
+	}
+	if o.confluenceSpace != "" && o.target != "confluence" {
+		return fmt.Errorf("--confluence-space can only be used with --publish confluence")
+	}
+	if o.gdocsFolder != "" && o.target != "gdocs" {
+		return fmt.Errorf("--gdocs-folder can only be used with --publish gdocs")
+	}
+	if o.webhookURL != "" && o.post != "webhook" {
+		return fmt.Errorf("--webhook-url can only be used with --post webhook")
+	}
+	if o.discordWebhook != "" && o.post != "discord" {
+		return fmt.Errorf("--discord-webhook-url can only be used with --post discord")
+	}
+	if o.teamsWebhook != "" && o.post != "teams" {
+		return fmt.Errorf("--teams-webhook-url can only be used with --post teams")
+	}
+	if err := o.validatePost(); err != nil {
+		return err
+	}
+	switch o.target {
+	case "":
+		return nil
+	case "notion":
+		if o.notionDatabase == "" {
+			return fmt.Errorf("--publish notion requires --notion-database")
+		}
+		if os.Getenv("NOTION_TOKEN") == "" {
+			return fmt.Errorf("--publish notion requires the NOTION_TOKEN environment variable")
+		}
+		return nil
+	case "esa":
+		if o.outputFormat != "md" {
+			return fmt.Errorf("--publish esa can only be used with --output-format md")
+		}
+		if o.esaTeam == "" {
+			return fmt.Errorf("--publish esa requires --esa-team")
+		}
+		if os.Getenv("ESA_ACCESS_TOKEN") == "" {
+			return fmt.Errorf("--publish esa requires the ESA_ACCESS_TOKEN environment variable")
+		}
+		if _, err := expandOutputName(o.esaCategory, outputNameData{User: "user", From: "2006-01-02", To: "2006-01-02", Date: "2006-01-02"}); err != nil {
+			return fmt.Errorf("invalid --esa-category: %w", err)
+		}
+		return nil
+	case "kibela":
+		if o.outputFormat != "md" {
+			return fmt.Errorf("--publish kibela can only be used with --output-format md")
+		}
+		if o.kibelaTeam == "" {
+			return fmt.Errorf("--publish kibela requires --kibela-team")
+		}
+		if os.Getenv("KIBELA_TOKEN") == "" {
+			return fmt.Errorf("--publish kibela requires the KIBELA_TOKEN environment variable")
+		}
+		if o.kibelaFolder != "" {
+			if _, err := expandOutputName(o.kibelaFolder, outputNameData{User: "user", From: "2006-01-02", To: "2006-01-02", Date: "2006-01-02"}); err != nil {
+				return fmt.Errorf("invalid --kibela-folder: %w", err)
+			}
+		}
+		return nil
+	case "confluence":
+		if o.outputFormat != "confluence" {
+			return fmt.Errorf("--publish confluence can only be used with --output-format confluence")
+		}
+		if !strings.HasPrefix(o.confluenceURL, "https://") || o.confluenceSpace == "" {
+			return fmt.Errorf("--publish confluence requires --confluence-url (https://...) and --confluence-space")
+		}
+		if os.Getenv("CONFLUENCE_EMAIL") == "" || os.Getenv("CONFLUENCE_API_TOKEN") == "" {
+			return fmt.Errorf("--publish confluence requires the CONFLUENCE_EMAIL and CONFLUENCE_API_TOKEN environment variables")
+		}
+		return nil
+	case "gdocs":
+		if o.outputFormat != "md" {
+			return fmt.Errorf("--publish gdocs can only be used with --output-format md")
+		}
+		return nil
+	case "webhook", "discord", "teams":
+		return fmt.Errorf("%s is not a --publish destination, use --post %s instead", o.target, o.target)
+	case "gist":
+		if o.outputFormat != "md" {
+			return fmt.Errorf("--publish gist can only be used with --output-format md")
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid --publish destination: %s (please specify notion, gist, esa, kibela, confluence or gdocs)", o.target)
+	}
+}
+
+// validatePost checks that the --post destination is known and its webhook URL is set
+func (o publishOptions) validatePost() error {
+	switch o.post {
+	case "":
+		return nil
+	case "webhook":
+		if !strings.HasPrefix(o.webhookURL, "https://") && !strings.HasPrefix(o.webhookURL, "http://") {
+			return fmt.Errorf("--post webhook requires an http(s) --webhook-url")
+		}
+		return nil
+	case "discord":
+		if !strings.HasPrefix(o.discordWebhookURL(), "https://") {
+			return fmt.Errorf("--post discord requires an https --discord-webhook-url (or the DISCORD_WEBHOOK_URL environment variable)")
+		}
+		return nil
+	case "teams":
+		if !strings.HasPrefix(o.teamsWebhookURL(), "https://") {
+			return fmt.Errorf("--post teams requires an https --teams-webhook-url (or the TEAMS_WEBHOOK_URL environment variable)")
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid --post destination: %s (please specify webhook, discord or teams)", o.post)
+	}
+}
+
+// publishResults uploads, publishes and posts the reports (or the written file) as --upload, --publish and --post ask, and exits when one fails
+func publishResults(ctx context.Context, o publishOptions, reports []model.Report, outputFile string, p *progress) {
+	if o.upload != "" {
+		dest, _ := upload.ParseDestination(o.upload)
+		p.Status("Uploading to " + dest.Scheme)
+		location, err := upload.Upload(ctx, dest, outputFile, nil)
+		p.Stop()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to upload: %v\n", err)
+			os.Exit(1)
+		}
+		p.Info("Uploaded to %s", location)
+	}
+
+	if o.target != "" {
+		publishReport(ctx, o, reports, outputFile, p)
+	}
+	if o.post != "" {
+		postReport(ctx, o, reports, p)
+	}
+}
+
+// publishReport publishes the reports (or the written file) to the --publish destination
+func publishReport(ctx context.Context, o publishOptions, reports []model.Report, outputFile string, p *progress) {
+	p.Status("Publishing to " + o.target)
+	switch o.target {
+	case "notion":
+		notion := &publish.Notion{Token: os.Getenv("NOTION_TOKEN"), DatabaseID: o.notionDatabase}
+		created, err := notion.PublishItems(ctx, reports)
+		p.Stop()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to publish: %v (%d rows created before the failure)\n", err, created)
+			os.Exit(1)
+		}
+		p.Info("Published %d items to Notion", created)
+	case "gist":
+		url, err := publishGist(ctx, o, reports, outputFile)
+		p.Stop()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to publish: %v\n", err)
+			os.Exit(1)
+		}
+		p.Info("Published to %s", url)
+	case "esa":
+		url, created, err := publishEsa(ctx, o, reports, outputFile)
+		p.Stop()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to publish: %v\n", err)
+			os.Exit(1)
+		}
+		if created {
+			p.Info("Created %s", url)
+		} else {
+			p.Info("Updated %s", url)
+		}
+	case "kibela":
+		url, err := publishKibela(ctx, o, reports, outputFile)
+		p.Stop()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to publish: %v\n", err)
+			os.Exit(1)
+		}
+		p.Info("Created %s", url)
+	case "confluence":
+		content, err := os.ReadFile(outputFile)
+		if err != nil {
+			p.Stop()
+			fmt.Fprintf(os.Stderr, "Failed to publish: %v\n", err)
+			os.Exit(1)
+		}
+		confluence := &publish.Confluence{
+			BaseURL:  o.confluenceURL,
+			Email:    os.Getenv("CONFLUENCE_EMAIL"),
+			APIToken: os.Getenv("CONFLUENCE_API_TOKEN"),
+		}
+		url, created, err := confluence.PublishPage(ctx, o.confluenceSpace, o.confluenceParent, reportTitle(reportUsers(reports)), string(content))
+		p.Stop()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to publish: %v\n", err)
+			os.Exit(1)
+		}
+		if created {
+			p.Info("Created %s", url)
+		} else {
+			p.Info("Updated %s", url)
+		}
+	case "gdocs":
+		content, err := os.ReadFile(outputFile)
+		if err != nil {
+			p.Stop()
+			fmt.Fprintf(os.Stderr, "Failed to publish: %v\n", err)
+			os.Exit(1)
+		}
+		docs := &publish.GoogleDocs{FolderID: o.gdocsFolder}
+		url, err := docs.Create(ctx, reportTitle(reportUsers(reports)), string(content))
+		p.Stop()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to publish: %v\n", err)
+			os.Exit(1)
+		}
+		p.Info("Created %s", url)
+	}
+}
+
+// postReport sends the report or its summary to the --post webhook
+func postReport(ctx context.Context, o publishOptions, reports []model.Report, p *progress) {
+	p.Status("Posting to " + o.post)
+	switch o.post {
+	case "webhook":
+		webhook := &publish.Webhook{URL: o.webhookURL, Secret: os.Getenv("GH_PRIC_WEBHOOK_SECRET")}
+		err := webhook.Post(ctx, webhookPayload(reports))
+		p.Stop()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to post: %v\n", err)
+			os.Exit(1)
+		}
+		p.Info("Posted the report to %s", o.webhookURL)
+	case "discord":
+		discord := &publish.Discord{WebhookURL: o.discordWebhookURL()}
+		err := discord.Post(ctx, discordEmbeds(reports, o.profileURL))
+		p.Stop()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to post: %v\n", err)
+			os.Exit(1)
+		}
+		p.Info("Posted the summary to Discord")
+	case "teams":
+		teams := &publish.Teams{WebhookURL: o.teamsWebhookURL()}
+		err := teams.PostCard(ctx, teamsCard(reports))
+		p.Stop()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to post: %v\n", err)
+			os.Exit(1)
+		}
+		p.Info("Posted the summary to Microsoft Teams")
+	}
+}
+
+// discordWebhookURL returns the webhook URL of --post discord; the environment keeps it out of shell history
+func (o publishOptions) discordWebhookURL() string {
+	if o.discordWebhook != "" {
+		return o.discordWebhook
+	}
+	return os.Getenv("DISCORD_WEBHOOK_URL")
+}
+
+// publishEsa writes the markdown file to the esa.io post of the period, in the configured category
+func publishEsa(ctx context.Context, o publishOptions, reports []model.Report, outputFile string) (string, bool, error) {
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		return "", false, err
+	}
+	usernames, dateRange := reportUsers(reports)
+	category, err := expandOutputName(o.esaCategory, newOutputNameData(usernames, dateRange, o.outputFormat, dateRange.EndDate.Location()))
+	if err != nil {
+		return "", false, err
+	}
+
+	esa := &publish.Esa{Token: os.Getenv("ESA_ACCESS_TOKEN"), Team: o.esaTeam}
+	return esa.Publish(ctx, strings.Trim(category, "/"), reportTitle(usernames, dateRange), string(content))
+}
+
+// publishKibela creates a Kibela note with the markdown file in the configured group and folder
+func publishKibela(ctx context.Context, o publishOptions, reports []model.Report, outputFile string) (string, error) {
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		return "", err
+	}
+	usernames, dateRange := reportUsers(reports)
+	folder := o.kibelaFolder
+	if folder != "" {
+		folder, err = expandOutputName(folder, newOutputNameData(usernames, dateRange, o.outputFormat, dateRange.EndDate.Location()))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	kibela := &publish.Kibela{Token: os.Getenv("KIBELA_TOKEN"), Team: o.kibelaTeam}
+	return kibela.CreateNote(ctx, o.kibelaGroup, strings.Trim(folder, "/"), reportTitle(usernames, dateRange), string(content))
+}
+
+// reportUsers returns the logins of the reports and their common period
+func reportUsers(reports []model.Report) ([]string, model.DateRange) {
+	usernames := make([]string, len(reports))
+	for i, report := range reports {
+		usernames[i] = report.Username
+	}
+	return usernames, reports[0].DateRange
+}
+
+// reportTitle names a published report after its users and period
+func reportTitle(usernames []string, dateRange model.DateRange) string {
+	return fmt.Sprintf("GitHub activity of %s, %s to %s", strings.Join(usernames, ", "),
+		dateRange.StartDate.Format("2006-01-02"), dateRange.EndDate.Format("2006-01-02"))
+}
+
+// Counts of what the reports contain, for short summaries
+type activityCounts struct {
+	items       int
+	prs         int
+	issues      int
+	mergedPRs   int // PRs the user created and got merged
+	reviewedPRs int
+	commits     int
+}
+
+// countActivity adds up the items and commits of the reports
+func countActivity(reports []model.Report) activityCounts {
+	var counts activityCounts
+	for _, report := range reports {
+		counts.items += len(report.Items)
+		counts.commits += len(report.Commits)
+		for _, item := range report.Items {
+			switch item.Type {
+			case "PR":
+				counts.prs++
+				if item.State == "merged" && item.HasInvolvement("created") {
+					counts.mergedPRs++
+				}
+				if item.HasInvolvement("reviewed") {
+					counts.reviewedPRs++
+				}
+			case "Issue":
+				counts.issues++
+			}
+		}
+	}
+	return counts
+}
+
+// Embed color of Discord messages (GitHub green)
+const discordColor = 0x2da44e
+
+// Discord limits the value of an embed field to 1024 characters
+const discordFieldLimit = 1024
+
+// profileURL returns the web URL of a user's profile on the GitHub host of the run
+func (o publishOptions) profileURL(username string) string {
+	host := o.hostname
+	if host == "" {
+		host, _ = auth.DefaultHost()
+	}
+	return "https://" + host + "/" + username
+}
+
+// discordEmbeds summarizes each report as a Discord embed: the counts, then the items the user created and reviewed
+// profileURL gives the link of the embed title for each user
+func discordEmbeds(reports []model.Report, profileURL func(username string) string) []publish.DiscordEmbed {
+	embeds := make([]publish.DiscordEmbed, 0, len(reports))
+	for _, report := range reports {
+		counts := countActivity([]model.Report{report})
+		embed := publish.DiscordEmbed{
+			Title:       reportTitle([]string{report.Username}, report.DateRange),
+			URL:         profileURL(report.Username),
+			Description: fmt.Sprintf("%d items and %d commits", counts.items, counts.commits),
+			Color:       discordColor,
+			Fields: []publish.DiscordField{
+				{Name: "PRs", Value: fmt.Sprint(counts.prs), Inline: true},
+				{Name: "Merged (authored)", Value: fmt.Sprint(counts.mergedPRs), Inline: true},
+				{Name: "Reviewed", Value: fmt.Sprint(counts.reviewedPRs), Inline: true},
+				{Name: "Issues", Value: fmt.Sprint(counts.issues), Inline: true},
+				{Name: "Commits", Value: fmt.Sprint(counts.commits), Inline: true},
+			},
+		}
+		if report.Partial {
+			embed.Title += " (partial)"
+		}
+
+		var created, reviewed []model.Item
+		for _, item := range report.Items {
+			switch {
+			case item.HasInvolvement("created"):
+				created = append(created, item)
+			case item.HasInvolvement("reviewed"):
+				reviewed = append(reviewed, item)
+			}
+		}
+		if len(created) > 0 {
+			embed.Fields = append(embed.Fields, publish.DiscordField{Name: "Created", Value: discordItemList(created)})
+		}
+		if len(reviewed) > 0 {
+			embed.Fields = append(embed.Fields, publish.DiscordField{Name: "Reviewed", Value: discordItemList(reviewed)})
+		}
+		embeds = append(embeds, embed)
+	}
+	return embeds
+}
+
+// discordItemList lists items as links, one per line, cut off to fit in an embed field
+func discordItemList(items []model.Item) string {
+	escape := strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, "*", `\*`, "_", `\_`, "`", "\\`")
+	var b strings.Builder
+	for i, item := range items {
+		ref := fmt.Sprintf("%s#%d", item.Repository, item.Number)
+		if item.Type == "Gist" {
+			ref = "Gist"
+		}
+		line := fmt.Sprintf("[%s](%s) %s", ref, item.URL, escape.Replace(item.Title))
+		if item.State == "merged" {
+			line += " (merged)"
+		}
+		// Leave room for the "…and N more" line unless this is the last item
+		limit := discordFieldLimit
+		if i < len(items)-1 {
+			limit -= 20
+		}
+		if utf8.RuneCountInString(b.String()+line) > limit {
+			fmt.Fprintf(&b, "…and %d more", len(items)-i)
+			return b.String()
+		}
+		b.WriteString(line + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// teamsWebhookURL returns the webhook URL of --post teams
+func (o publishOptions) teamsWebhookURL() string {
+	if o.teamsWebhook != "" {
+		return o.teamsWebhook
+	}
+	return os.Getenv("TEAMS_WEBHOOK_URL")
+}
+
+// Items listed per user in a Teams card; Teams rejects messages over about 28 KB
+const teamsMaxItems = 10
+
+// teamsCard summarizes the reports as an Adaptive Card: per user, the counts as facts and the items they created
+func teamsCard(reports []model.Report) map[string]interface{} {
+	usernames, dateRange := reportUsers(reports)
+	body := []map[string]interface{}{
+		{"type": "TextBlock", "text": reportTitle(usernames, dateRange), "size": "Large", "weight": "Bolder", "wrap": true},
+	}
+
+	for _, report := range reports {
+		counts := countActivity([]model.Report{report})
+		title := report.Username
+		if report.Partial {
+			title += " (partial)"
+		}
+		section := []map[string]interface{}{
+			{"type": "TextBlock", "text": title, "size": "Medium", "weight": "Bolder"},
+			{"type": "FactSet", "facts": []map[string]string{
+				{"title": "PRs", "value": fmt.Sprint(counts.prs)},
+				{"title": "Merged (authored)", "value": fmt.Sprint(counts.mergedPRs)},
+				{"title": "Reviewed", "value": fmt.Sprint(counts.reviewedPRs)},
+				{"title": "Issues", "value": fmt.Sprint(counts.issues)},
+				{"title": "Commits", "value": fmt.Sprint(counts.commits)},
+			}},
+		}
+
+		var lines []string
+		var created int
+		for _, item := range report.Items {
+			if !item.HasInvolvement("created") {
+				continue
+			}
+			created++
+			if len(lines) < teamsMaxItems {
+				line := fmt.Sprintf("- [%s#%d](%s) %s", item.Repository, item.Number, item.URL, item.Title)
+				if item.Type == "Gist" {
+					line = fmt.Sprintf("- [Gist](%s) %s", item.URL, item.Title)
+				}
+				if item.State == "merged" {
+					line += " (merged)"
+				}
+				lines = append(lines, line)
+			}
+		}
+		if created > len(lines) {
+			lines = append(lines, fmt.Sprintf("- …and %d more", created-len(lines)))
+		}
+		if len(lines) > 0 {
+			section = append(section, map[string]interface{}{"type": "TextBlock", "text": strings.Join(lines, "\n"), "wrap": true})
+		}
+		body = append(body, map[string]interface{}{"type": "Container", "separator": true, "items": section})
+	}
+
+	return map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+		"msteams": map[string]string{"width": "Full"},
+	}
+}
+
+// webhookPayload is the body posted by --post webhook, shaped like --output-format json:
+// the items of a single user, or the member reports of a team report
+func webhookPayload(reports []model.Report) interface{} {
+	if len(reports) == 1 {
+		return reports[0].Items
+	}
+	return reports
+}
+
+// publishGist uploads the markdown file as a secret gist and returns its URL
+// Without --gist-id, the gist of the previous run for the same users is updated, so the link stays the same
+func publishGist(ctx context.Context, o publishOptions, reports []model.Report, outputFile string) (string, error) {
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		return "", err
+	}
+	client, err := api.NewRESTClient(api.ClientOptions{Host: o.hostname})
+	if err != nil {
+		return "", err
+	}
+	gist := &publish.Gist{Client: client}
+
+	usernames, dateRange := reportUsers(reports)
+	description := reportTitle(usernames, dateRange)
+	// Gists render markdown by the file extension
+	filename := strings.TrimSuffix(filepath.Base(outputFile), filepath.Ext(outputFile)) + ".md"
+
+	statePath := ""
+	if o.cacheDir != "" {
+		statePath = filepath.Join(o.cacheDir, "gists.json")
+	}
+	key := o.hostname + "/" + strings.Join(usernames, ",")
+	known := loadGistIDs(statePath)
+
+	id := o.gistID
+	if id == "" {
+		id = known[key]
+	}
+	id, url, err := gist.Publish(ctx, id, filename, description, string(content))
+	if errors.Is(err, publish.ErrGistNotFound) && o.gistID == "" {
+		// The remembered gist was deleted; start a new one
+		id, url, err = gist.Publish(ctx, "", filename, description, string(content))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Failing to remember the gist only means the next run creates a new one
+	known[key] = id
+	if data, err := json.MarshalIndent(known, "", "  "); err == nil && statePath != "" {
+		if os.MkdirAll(filepath.Dir(statePath), 0o755) == nil {
+			os.WriteFile(statePath, data, 0o644)
+		}
+	}
+	return url, nil
+}
+
+// loadGistIDs reads the gists published by previous runs, keyed by host and users
+func loadGistIDs(path string) map[string]string {
+	known := make(map[string]string)
+	if path == "" {
+		return known
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &known)
+	}
+	return known
+}
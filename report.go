@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github"
+	"git.pepabo.com/yukyan/gh-pric/github/jira"
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+	"git.pepabo.com/yukyan/gh-pric/github/state"
+	"git.pepabo.com/yukyan/gh-pric/pkg/pric"
+)
+
+// Settings shared by every report collected in a run
+type options struct {
+	dateRange       model.DateRange
+	cacheDir        string
+	noCache         bool
+	maxPages        int
+	closedInRange   bool
+	repos           []string
+	excludeRepos    []string
+	orgs            []string
+	hostname        string
+	visibility      string
+	anonymize       bool // Items are anonymized, so whether each repository is private must be known
+	includeProjects bool
+	sinceLastRun    bool
+	ignoreUsers     []string
+	noBots          bool
+	onlyMyComments  bool
+	offline         bool
+	inputFile       string
+	verbose         bool
+	maxRetries      int
+	maxComments     int
+	includeCI       bool
+	jira            *jira.Client                           // Also merge the Jira issues of the period (nil = disabled)
+	summaryOnly     bool                                   // Skip fetching item details (bodies, comments, reviews, ...)
+	comparePrevious bool                                   // Also collect the preceding period for comparison
+	redactPatterns  []*regexp.Regexp                       // Secrets masked in titles, bodies, comments and commit messages (empty = no redaction)
+	onItem          func(username string, item model.Item) // Receives each reported item as soon as it is fetched (nil = disabled)
+	onRateLimit     func(resource string, remaining int)   // Receives the remaining API quota after each response (nil = disabled)
+	retryWait       time.Duration
+}
+
+// defaultOptions returns the options of a run with the default flags: bots dropped and secrets redacted
+func defaultOptions() options {
+	return options{
+		visibility:     "all",
+		noBots:         true,
+		maxRetries:     github.DefaultMaxRetries,
+		retryWait:      github.DefaultRetryWait,
+		redactPatterns: github.DefaultSecretPatterns,
+	}
+}
+
+// newClient creates a GitHub client configured with the run options
+func newClient(opts options) (*github.Client, error) {
+	clientOptions := github.ClientOptions{Offline: opts.offline, Host: opts.hostname, OnRateLimit: opts.onRateLimit}
+	if opts.verbose {
+		clientOptions.Trace = os.Stderr
+	}
+	if !opts.noCache {
+		clientOptions.CacheDir = opts.cacheDir
+	}
+	client, err := github.NewClient(clientOptions)
+	if err != nil {
+		return nil, err
+	}
+	client.MaxRetries = opts.maxRetries
+	client.MaxComments = opts.maxComments
+	client.RetryWait = opts.retryWait
+	return client, nil
+}
+
+// Rough workload assumptions for the pre-flight rate limit check
+const (
+	estimatedItemsPerDay   = 5 // Items found per user and day
+	estimatedCallsPerItem  = 2 // REST calls for the timeline, and the commits and checks of your own PRs
+	estimatedPointsPerItem = 1 // GraphQL points for the batched bodies, comments and reviews (about 30 per query of 50 items)
+)
+
+// checkRateLimit compares the remaining API quota against a rough estimate of the workload
+// It returns one message per resource that is likely to run out before the run finishes
+func checkRateLimit(ctx context.Context, opts options, users int) ([]string, error) {
+	client, err := newClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	limits, err := client.FetchRateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	searchesPerUser := len(pric.Categories) + 1 // One query per category plus commits
+	if opts.closedInRange {
+		searchesPerUser += len(pric.Categories)
+	}
+	if opts.comparePrevious {
+		searchesPerUser *= 2
+	}
+	days := int(opts.dateRange.EndDate.Sub(opts.dateRange.StartDate).Hours()/24) + 1
+	items := users * days * estimatedItemsPerDay
+
+	var problems []string
+	check := func(name string, limit github.RateLimit, needed int) {
+		if limit.Remaining < needed {
+			problems = append(problems, fmt.Sprintf("%s API quota may run out: %d of %d requests remaining, about %d needed (resets at %s)",
+				name, limit.Remaining, limit.Limit, needed, limit.Reset.Format("15:04:05")))
+		}
+	}
+	check("Search", limits.Search, users*searchesPerUser)
+	check("REST", limits.Core, items*estimatedCallsPerItem)
+	points := items * estimatedPointsPerItem
+	if opts.includeProjects {
+		points += items
+	}
+	check("GraphQL", limits.GraphQL, points)
+	return problems, nil
+}
+
+// collectReport fetches and filters everything reported for a single user
+// If ctx is cancelled midway, a report marked as partial is returned with whatever was collected
+func collectReport(ctx context.Context, client *github.Client, username string, opts options, p *progress) (model.Report, error) {
+	if opts.comparePrevious {
+		return collectComparedReport(ctx, client, username, opts, p)
+	}
+
+	// Build the report from saved data without any API calls
+	if opts.offline {
+		items, err := loadOfflineItems(username, opts)
+		if err != nil {
+			return model.Report{}, err
+		}
+		items = pric.Filter(items, username, opts.pricOptions(client, username, p))
+		if opts.onItem != nil {
+			for _, item := range items {
+				opts.onItem(username, item)
+			}
+		}
+		return model.Report{
+			Username:  username,
+			DateRange: opts.dateRange,
+			Items:     items,
+			Warnings:  []string{"Generated offline from previously saved data; recent activity and commits may be missing"},
+		}, nil
+	}
+
+	report, err := pric.Report(ctx, opts.pricOptions(client, username, p))
+	var detailsErr *github.ErrPartial
+	if errors.As(err, &detailsErr) {
+		// Each failure was already logged; the items are still reported as the search found them
+		report.Warnings = append(report.Warnings, fmt.Sprintf("Details of %d items could not be retrieved; they are shown as found by the search", len(detailsErr.Failed)))
+		err = nil
+	}
+	if err != nil {
+		return model.Report{}, err
+	}
+	return *report, nil
+}
+
+// pricOptions returns the library options that collect the report of username with client
+func (opts options) pricOptions(client *github.Client, username string, p *progress) pric.Options {
+	pricOpts := pric.Options{
+		Username:          username,
+		DateRange:         opts.dateRange,
+		Repos:             opts.repos,
+		ExcludeRepos:      opts.excludeRepos,
+		Orgs:              opts.orgs,
+		Visibility:        opts.visibility,
+		ClosedInRange:     opts.closedInRange,
+		ResolveVisibility: opts.anonymize,
+		MaxPages:          opts.maxPages,
+		IncludeProjects:   opts.includeProjects,
+		IncludeCI:         opts.includeCI,
+		SummaryOnly:       opts.summaryOnly,
+		IgnoreUsers:       opts.ignoreUsers,
+		KeepBots:          !opts.noBots,
+		OnlyMyComments:    opts.onlyMyComments,
+		RedactPatterns:    opts.redactPatterns,
+		NoRedact:          len(opts.redactPatterns) == 0,
+		SinceLastRun:      opts.sinceLastRun,
+		StateDir:          filepath.Join(opts.cacheDir, "state"),
+		Jira:              opts.jira,
+		Fetcher:           client,
+		Progress:          p,
+	}
+	if opts.onItem != nil {
+		pricOpts.OnItem = func(item model.Item) { opts.onItem(username, item) }
+	}
+	return pricOpts
+}
+
+// collectComparedReport collects the report and then the equally long period before it
+// Only the summary of the previous period is needed, so its item details are not fetched
+func collectComparedReport(ctx context.Context, client *github.Client, username string, opts options, p *progress) (model.Report, error) {
+	opts.comparePrevious = false
+	report, err := collectReport(ctx, client, username, opts, p)
+	if err != nil || report.Partial {
+		return report, err
+	}
+
+	length := opts.dateRange.EndDate.Add(time.Second).Sub(opts.dateRange.StartDate)
+	previousOpts := opts
+	previousOpts.dateRange = model.DateRange{
+		StartDate: opts.dateRange.StartDate.Add(-length),
+		EndDate:   opts.dateRange.StartDate.Add(-time.Second),
+	}
+	previousOpts.summaryOnly = true
+	previousOpts.sinceLastRun = false
+	previousOpts.includeCI = false
+	previousOpts.onItem = nil
+
+	p.Info("Retrieving the previous period (%s to %s) for comparison",
+		previousOpts.dateRange.StartDate.Format("2006-01-02"), previousOpts.dateRange.EndDate.Format("2006-01-02"))
+	previous, err := collectReport(ctx, client, username, previousOpts, p)
+	if ctx.Err() != nil || previous.Partial {
+		report.Warnings = append(report.Warnings, "The run was interrupted before the previous period was fetched; the comparison is omitted")
+		return report, nil
+	}
+	if err != nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("Failed to retrieve the previous period for comparison: %v", err))
+		return report, nil
+	}
+	report.Previous = &previous
+	return report, nil
+}
+
+// fetchErrorHint suggests what to do about a failed API call (empty when there is nothing to suggest)
+func fetchErrorHint(err error) string {
+	var rateLimited *github.ErrRateLimited
+	switch {
+	case errors.As(err, &rateLimited):
+		return " (try again later, or narrow the period with --from and --to)"
+	case errors.Is(err, github.ErrAuth):
+		return " (check the token with \"gh auth status\" or GH_TOKEN)"
+	case errors.Is(err, github.ErrNotFound):
+		return " (check the user name and the --repo and --org names)"
+	}
+	return ""
+}
+
+// loadOfflineItems reads the items of the period from a saved JSON report or the incremental sync dataset
+func loadOfflineItems(username string, opts options) ([]model.Item, error) {
+	saved := &state.State{}
+	if opts.inputFile != "" {
+		data, err := os.ReadFile(opts.inputFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", opts.inputFile, err)
+		}
+		if err := json.Unmarshal(data, &saved.Items); err != nil {
+			return nil, fmt.Errorf("failed to parse %s (expected a JSON report): %w", opts.inputFile, err)
+		}
+	} else {
+		var err error
+		saved, err = state.Load(filepath.Join(opts.cacheDir, "state"), username)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load saved data: %w", err)
+		}
+		if saved.LastRun.IsZero() {
+			return nil, fmt.Errorf("no saved data for %s (run once with --since-last-run or pass --input)", username)
+		}
+	}
+
+	return saved.ItemsIn(opts.dateRange, opts.closedInRange), nil
+}
+
+// collectTeamReport fetches the reports of several users concurrently
+func collectTeamReport(ctx context.Context, usernames []string, opts options, p *progress) (model.TeamReport, error) {
+	members := make([]model.Report, len(usernames))
+	errs := make([]error, len(usernames))
+
+	p.Begin("Retrieving activity for users", len(usernames))
+	defer p.Stop()
+
+	var wg sync.WaitGroup
+	for i, username := range usernames {
+		wg.Add(1)
+		go func(i int, username string) {
+			defer wg.Done()
+
+			// Each user gets its own client since incremental sync state is per user
+			client, err := newClient(opts)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			// Per-user progress would interleave, so only failures are printed
+			members[i], errs[i] = collectReport(ctx, client, username, opts, newProgress(true))
+			p.Step("(" + username + ")")
+		}(i, username)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return model.TeamReport{}, fmt.Errorf("%s: %w", usernames[i], err)
+		}
+	}
+
+	return model.TeamReport{
+		DateRange: opts.dateRange,
+		Members:   members,
+	}, nil
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github"
+	"git.pepabo.com/yukyan/gh-pric/github/cache"
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+	"git.pepabo.com/yukyan/gh-pric/github/output"
+	"git.pepabo.com/yukyan/gh-pric/github/util"
+)
+
+// Report server of "gh pric serve"
+type server struct {
+	opts     options        // Settings shared by every request; the period is set per request
+	location *time.Location // Time zone of the from/to parameters and of the rendered dates
+	ttl      time.Duration  // How long a collected report is reused (0 = always fetch)
+
+	mu      sync.Mutex
+	reports map[string]cachedReport // Collected reports by user and period, dropped once older than ttl
+	latest  map[string]cachedReport // Most recently collected report of each user, for /metrics
+}
+
+// Most reports kept for reuse; the oldest is dropped to make room for a new one
+const maxCachedReports = 100
+
+// A collected report and when it was collected
+type cachedReport struct {
+	report    model.Report
+	fetchedAt time.Time
+}
+
+// runServe serves rendered reports over HTTP (gh pric serve)
+func runServe(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gh pric serve [--listen 127.0.0.1:8080] [--users alice,bob]\n\n")
+		fmt.Fprintf(os.Stderr, "Serves GET /report?user=&from=&to=&format= with the same report as the command line,\n")
+		fmt.Fprintf(os.Stderr, "and GET /metrics with gauges from the most recent report of each user\n\n")
+		flags.PrintDefaults()
+	}
+	listen := flags.String("listen", "127.0.0.1:8080", "Address to listen on (the server has no authentication, so only listen on other interfaces behind a proxy that adds it)")
+	cacheDir := flags.String("cache-dir", cache.DefaultDir(), "Directory for the on-disk API response cache")
+	noCache := flags.Bool("no-cache", false, "Disable the on-disk API response cache")
+	hostname := flags.String("hostname", "", "GitHub hostname to use, e.g. a GitHub Enterprise Server (default: the gh default host)")
+	timezone := flags.String("timezone", "UTC", "Time zone for the from/to parameters and every date in the output")
+	ttl := flags.Duration("cache-ttl", 5*time.Minute, "How long a collected report is reused for the same user and period (0 = always fetch)")
+	verbose := flags.Bool("verbose", false, "Log every API request and response to stderr")
+	users := flags.String("users", "", "Comma-separated GitHub usernames collected in the background for /metrics")
+	refresh := flags.Duration("refresh", 15*time.Minute, "Interval between background collections of --users")
+	noBots := flags.Bool("no-bots", true, "Drop items and comments authored by bots (use --no-bots=false to keep them)")
+	redact := flags.Bool("redact", true, "Mask tokens, API keys and e-mail addresses in titles, bodies, comments and commit messages (use --redact=false to keep them)")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return 1
+	}
+
+	location, err := time.LoadLocation(*timezone)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --timezone: %s\n", *timezone)
+		return 1
+	}
+
+	// Requests get the same report as a command line run with the default flags
+	opts := defaultOptions()
+	opts.cacheDir = *cacheDir
+	opts.noCache = *noCache
+	opts.hostname = *hostname
+	opts.verbose = *verbose
+	opts.noBots = *noBots
+	if !*redact {
+		opts.redactPatterns = nil
+	}
+
+	s := &server{
+		opts:     opts,
+		location: location,
+		ttl:      *ttl,
+		reports:  make(map[string]cachedReport),
+		latest:   make(map[string]cachedReport),
+	}
+	// Fail early when gh is not authenticated
+	if _, err := newClient(s.opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create the GitHub client: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	httpServer := &http.Server{
+		Addr:              *listen,
+		Handler:           s.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	if *users != "" {
+		var usernames []string
+		for _, user := range strings.Split(*users, ",") {
+			if user = strings.TrimSpace(user); user != "" {
+				usernames = append(usernames, user)
+			}
+		}
+		go s.refreshLoop(ctx, usernames, *refresh)
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", *listen)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// routes returns the handler of every endpoint
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/report", s.handleReport)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	return mux
+}
+
+// handleReport collects the report for the query parameters and writes it in the requested format
+func (s *server) handleReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = "md"
+	}
+	contentType, ok := serveContentType(format)
+	if !ok {
+		http.Error(w, fmt.Sprintf("unsupported format %q (%s)", format, strings.Join(serveFormats(), ", ")), http.StatusBadRequest)
+		return
+	}
+
+	// The period defaults to the last three days, as on the command line
+	now := time.Now().In(s.location)
+	from, to := query.Get("from"), query.Get("to")
+	if from == "" {
+		from = now.AddDate(0, 0, -3).Format("2006-01-02")
+	}
+	if to == "" {
+		to = now.Format("2006-01-02")
+	}
+	dateRange, err := util.ParseDateRange(from, to, s.location)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := s.report(r.Context(), query.Get("user"), dateRange)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to retrieve data: %v\n", err)
+		status := http.StatusBadGateway
+		var rateLimited *github.ErrRateLimited
+		switch {
+		case errors.As(err, &rateLimited):
+			status = http.StatusServiceUnavailable
+			if wait := time.Until(rateLimited.ResetAt); wait > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			}
+		case errors.Is(err, github.ErrNotFound):
+			status = http.StatusNotFound
+		}
+		http.Error(w, "failed to retrieve data: "+err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if err := output.WriteReport(w, report.In(s.location), format, output.Options{}); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write the report: %v\n", err)
+	}
+}
+
+// serveContentType returns the content type of a format the server can render
+// Formats without a content type, or that only write files (sqlite), are not served
+func serveContentType(format string) (string, bool) {
+	formatter, ok := output.Lookup(format)
+	if !ok {
+		return "", false
+	}
+	if _, ownsFile := formatter.(output.FileFormatter); ownsFile {
+		return "", false
+	}
+	typer, ok := formatter.(output.ContentTyper)
+	if !ok {
+		return "", false
+	}
+	return typer.ContentType(), true
+}
+
+// serveFormats lists the formats the server can render
+func serveFormats() []string {
+	var formats []string
+	for _, format := range output.Formats() {
+		if _, ok := serveContentType(format); ok {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
+
+// report returns the report of username (empty = the authenticated user) for the period
+// A report collected within the TTL is reused; API responses are also cached on disk by the client
+func (s *server) report(ctx context.Context, username string, dateRange model.DateRange) (model.Report, error) {
+	opts := s.opts
+	opts.dateRange = dateRange
+	client, err := newClient(opts)
+	if err != nil {
+		return model.Report{}, err
+	}
+	if username == "" {
+		if username, err = client.GetUsername(ctx); err != nil {
+			return model.Report{}, err
+		}
+	}
+
+	key := username + "\x00" + dateRange.StartDate.Format("2006-01-02") + "\x00" + dateRange.EndDate.Format("2006-01-02")
+	s.mu.Lock()
+	s.dropExpired()
+	cached, ok := s.reports[key]
+	s.mu.Unlock()
+	if ok {
+		return cached.report, nil
+	}
+
+	report, err := collectReport(ctx, client, username, opts, newProgress(true))
+	if err != nil {
+		return model.Report{}, err
+	}
+	if report.Partial {
+		return model.Report{}, fmt.Errorf("the request was cancelled before the report was complete")
+	}
+
+	collected := cachedReport{report: report, fetchedAt: time.Now()}
+	s.mu.Lock()
+	if s.ttl > 0 {
+		if len(s.reports) >= maxCachedReports {
+			s.dropOldest()
+		}
+		s.reports[key] = collected
+	}
+	s.latest[username] = collected
+	s.mu.Unlock()
+	return report, nil
+}
+
+// dropExpired removes the reports collected longer than the TTL ago; s.mu must be held
+func (s *server) dropExpired() {
+	for key, cached := range s.reports {
+		if time.Since(cached.fetchedAt) >= s.ttl {
+			delete(s.reports, key)
+		}
+	}
+}
+
+// dropOldest removes the report collected first; s.mu must be held
+func (s *server) dropOldest() {
+	var oldestKey string
+	var oldest time.Time
+	for key, cached := range s.reports {
+		if oldestKey == "" || cached.fetchedAt.Before(oldest) {
+			oldestKey, oldest = key, cached.fetchedAt
+		}
+	}
+	delete(s.reports, oldestKey)
+}
+
+// handleMetrics writes the gauges of the most recent report of each user in the Prometheus text format
+func (s *server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	usernames := make([]string, 0, len(s.latest))
+	for username := range s.latest {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+	reports := make([]model.Report, len(usernames))
+	collectedAt := make([]time.Time, len(usernames))
+	for i, username := range usernames {
+		reports[i] = s.latest[username].report
+		collectedAt[i] = s.latest[username].fetchedAt
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := writeMetrics(w, reports, collectedAt); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write the metrics: %v\n", err)
+	}
+}
+
+// refreshLoop collects the default period for each user right away and then every interval,
+// so /metrics has data without anyone requesting /report
+func (s *server) refreshLoop(ctx context.Context, usernames []string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		now := time.Now().In(s.location)
+		dateRange, err := util.ParseDateRange(now.AddDate(0, 0, -3).Format("2006-01-02"), now.Format("2006-01-02"), s.location)
+		if err == nil {
+			for _, username := range usernames {
+				if _, err := s.report(ctx, username, dateRange); err != nil && ctx.Err() == nil {
+					fmt.Fprintf(os.Stderr, "Failed to retrieve data for %s: %v\n", username, err)
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"git.pepabo.com/yukyan/gh-pric/github/llm"
+	"git.pepabo.com/yukyan/gh-pric/github/model"
+)
+
+// newSummarizer creates the LLM client from the config file, overridden by environment variables
+func newSummarizer(c llmConfig) *llm.Client {
+	client := &llm.Client{
+		Endpoint: llm.DefaultEndpoint,
+		APIKey:   c.APIKey,
+		Model:    llm.DefaultModel,
+	}
+	if c.Endpoint != "" {
+		client.Endpoint = c.Endpoint
+	}
+	if c.Model != "" {
+		client.Model = c.Model
+	}
+	if client.APIKey == "" {
+		client.APIKey = os.Getenv("OPENAI_API_KEY")
+	}
+	if v := os.Getenv("GH_PRIC_LLM_ENDPOINT"); v != "" {
+		client.Endpoint = v
+	}
+	if v := os.Getenv("GH_PRIC_LLM_API_KEY"); v != "" {
+		client.APIKey = v
+	}
+	if v := os.Getenv("GH_PRIC_LLM_MODEL"); v != "" {
+		client.Model = v
+	}
+	return client
+}
+
+// summarizeReports adds a generated overview to each report
+// A failed request only leaves a warning so the report is still written
+func summarizeReports(ctx context.Context, client *llm.Client, reports []model.Report, p *progress) {
+	for i := range reports {
+		p.Status(fmt.Sprintf("Summarizing the activity of %s", reports[i].Username))
+		overview, err := client.Summarize(ctx, reports[i])
+		p.Stop()
+		if err != nil {
+			warning := fmt.Sprintf("could not generate the summary with %s: %v", client.Model, err)
+			p.Warn("%s", warning)
+			reports[i].Warnings = append(reports[i].Warnings, warning)
+			continue
+		}
+		reports[i].Overview = overview
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.pepabo.com/yukyan/gh-pric/github/cache"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// Repository the extension is released from
+const releaseRepository = "n3xem/gh-pric"
+
+// Minimum interval between two release checks
+const updateCheckInterval = 24 * time.Hour
+
+// Time limit for the release request, so a slow network never delays the report
+const updateCheckTimeout = 5 * time.Second
+
+// Result of the last release check, kept in the cache directory
+type updateState struct {
+	CheckedAt     time.Time `json:"checked_at"`
+	LatestVersion string    `json:"latest_version"`
+}
+
+// startUpdateCheck looks for a newer release in the background
+// The channel receives the newer version, or an empty string when there is none or the check failed
+// The latest release is fetched at most once per day; in between the cached result is used
+func startUpdateCheck(current string) <-chan string {
+	result := make(chan string, 1)
+	go func() {
+		latest := latestRelease(filepath.Join(cache.DefaultDir(), "update-check.json"))
+		if latest != "" && newerVersion(latest, current) {
+			result <- latest
+			return
+		}
+		result <- ""
+	}()
+	return result
+}
+
+// notifyUpdate prints a notice when the background check found a newer release
+// A check that is still running is given a moment, then abandoned
+func notifyUpdate(check <-chan string, p *progress) {
+	if check == nil {
+		return
+	}
+	select {
+	case latest := <-check:
+		if latest != "" {
+			p.Notice("A new release of gh pric is available: %s -> %s\nTo upgrade, run: gh extension upgrade pric", currentBuildInfo().Version, latest)
+		}
+	case <-time.After(time.Second):
+	}
+}
+
+// updateCheckEnabled tells whether the release check should run
+// It is turned off by --no-update-check, "update_check: false" in the config file or GH_NO_UPDATE_NOTIFIER,
+// and for builds that are not releases
+func updateCheckEnabled(noUpdateCheck bool, cfg config) bool {
+	if noUpdateCheck || os.Getenv("GH_NO_UPDATE_NOTIFIER") != "" {
+		return false
+	}
+	if cfg.UpdateCheck != nil && !*cfg.UpdateCheck {
+		return false
+	}
+	_, ok := parseVersion(currentBuildInfo().Version)
+	return ok
+}
+
+// latestRelease returns the tag of the latest release, from the state file when it is recent enough
+func latestRelease(statePath string) string {
+	var state updateState
+	if data, err := os.ReadFile(statePath); err == nil {
+		if json.Unmarshal(data, &state) == nil && time.Since(state.CheckedAt) < updateCheckInterval {
+			return state.LatestVersion
+		}
+	}
+
+	client, err := api.NewRESTClient(api.ClientOptions{Host: "github.com", Timeout: updateCheckTimeout})
+	if err != nil {
+		return ""
+	}
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := client.Get("repos/"+releaseRepository+"/releases/latest", &release); err != nil {
+		return ""
+	}
+
+	// A failed write only means the next run checks again
+	state = updateState{CheckedAt: time.Now(), LatestVersion: release.TagName}
+	if data, err := json.Marshal(state); err == nil {
+		if os.MkdirAll(filepath.Dir(statePath), 0o755) == nil {
+			os.WriteFile(statePath, data, 0o644)
+		}
+	}
+	return release.TagName
+}
+
+// newerVersion reports whether version a is newer than version b (both vMAJOR.MINOR.PATCH)
+func newerVersion(a, b string) bool {
+	va, ok := parseVersion(a)
+	if !ok {
+		return false
+	}
+	vb, ok := parseVersion(b)
+	if !ok {
+		return false
+	}
+	for i := range va {
+		if va[i] != vb[i] {
+			return va[i] > vb[i]
+		}
+	}
+	return false
+}
+
+// parseVersion parses a release version such as v1.2.3
+// Pre-releases and pseudo-versions (with a "-" suffix) are not releases
+func parseVersion(v string) ([3]int, bool) {
+	var parsed [3]int
+	parts := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	if !strings.HasPrefix(v, "v") || len(parts) != 3 {
+		return parsed, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return parsed, false
+		}
+		parsed[i] = n
+	}
+	return parsed, true
+}
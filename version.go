@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build metadata, injected at build time:
+//
+//	go build -ldflags "-X main.version=v1.2.3 -X main.commit=$(git rev-parse HEAD) -X main.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Values left empty fall back to what the Go toolchain recorded in the binary
+var (
+	version = ""
+	commit  = ""
+	date    = ""
+)
+
+// Module path of go-gh, whose version is reported next to ours
+const goGHModule = "github.com/cli/go-gh/v2"
+
+// Build metadata of the running binary
+type buildInfo struct {
+	Version   string
+	Commit    string
+	Date      string
+	GoGH      string
+	GoVersion string
+	Platform  string
+}
+
+// currentBuildInfo returns the injected build metadata, completed with the build info of the binary
+func currentBuildInfo() buildInfo {
+	info := buildInfo{
+		Version:   version,
+		Commit:    commit,
+		Date:      date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		for _, setting := range bi.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.Date == "":
+				info.Date = setting.Value
+			case setting.Key == "vcs.modified" && setting.Value == "true" && info.Commit != "" && commit == "":
+				info.Commit += "-dirty"
+			}
+		}
+		for _, dep := range bi.Deps {
+			if dep.Path == goGHModule {
+				info.GoGH = dep.Version
+				if dep.Replace != nil {
+					info.GoGH = dep.Replace.Version
+				}
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	for _, field := range []*string{&info.Commit, &info.Date, &info.GoGH} {
+		if *field == "" {
+			*field = "unknown"
+		}
+	}
+	return info
+}
+
+// runVersion prints the version and build metadata (gh pric version)
+func runVersion(args []string) int {
+	if len(args) != 0 {
+		fmt.Fprintf(os.Stderr, "Usage: gh pric version\n")
+		return 1
+	}
+	info := currentBuildInfo()
+	fmt.Printf("gh-pric %s\n", info.Version)
+	fmt.Printf("commit: %s\n", info.Commit)
+	fmt.Printf("built: %s\n", info.Date)
+	fmt.Printf("go-gh: %s\n", info.GoGH)
+	fmt.Printf("go: %s %s\n", info.GoVersion, info.Platform)
+	return 0
+}